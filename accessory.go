@@ -0,0 +1,139 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+)
+
+// RingEffectID は指輪の持つ常時効果の種類
+type RingEffectID int
+
+const (
+	RingNone           RingEffectID = iota
+	RingPowerUp                     // パワーの最大値が上がる (効果はStatsのみ)
+	RingRegeneration                // HPの自然回復が早くなる
+	RingSlowHunger                  // 満腹度が減りにくくなる
+	RingPoisonImmunity              // 毒を受けなくなる
+	RingTrapSight                   // 罠が見えるようになる
+	RingAntiSleep                   // 眠らなくなる
+	RingItemFind                    // フロアに落ちているアイテムが増える
+	RingCritical                    // 会心の一撃が出やすくなる
+	RingLantern                     // 海老さんの周りを広く照らす
+)
+
+const (
+	regenerationInterval = 2    // 回復の指輪を装備しているときのHP回復間隔 (ターン)
+	slowHungerInterval   = 20   // 満腹の指輪を装備しているときの満腹度減少間隔 (ターン)
+	itemFindBonus        = 4    // 拾い物の指輪を装備しているときに増えるアイテムの数
+	criticalChance       = 0.25 // 会心の指輪を装備しているときの会心の一撃の確率
+)
+
+// RingDefinition は指輪の種類ごとの定義
+type RingDefinition struct {
+	Effect      RingEffectID
+	Name        string
+	Description string
+	Stats       StatModifier
+}
+
+var ringDefinitions = []RingDefinition{
+	{RingPowerUp, "鼓舞の指輪", "アクセサリ。パワーの最大値が3上昇する。", StatModifier{MaxPower: 3}},
+	{RingRegeneration, "回復の指輪", "アクセサリ。HPの回復が早くなる。", StatModifier{}},
+	{RingSlowHunger, "満腹の指輪", "アクセサリ。お腹が減りにくくなる。", StatModifier{}},
+	{RingPoisonImmunity, "毒消しの指輪", "アクセサリ。毒を受けなくなる。", StatModifier{}},
+	{RingTrapSight, "罠師の指輪", "アクセサリ。フロアの罠が見えるようになる。", StatModifier{}},
+	{RingAntiSleep, "眠り避けの指輪", "アクセサリ。眠らなくなる。", StatModifier{}},
+	{RingItemFind, "拾い物の指輪", "アクセサリ。フロアに落ちているアイテムが増える。", StatModifier{}},
+	{RingCritical, "会心の指輪", "アクセサリ。会心の一撃が出やすくなる。", StatModifier{AttackPower: 1}},
+	{RingLantern, "ランタン", "アクセサリ。暗い部屋や通路で周りを広く照らす。", StatModifier{}},
+}
+
+// newAccessory creates a ring from its definition.
+func newAccessory(def RingDefinition, x, y int, cursed, blessed bool) *Accessory {
+	return &Accessory{
+		BaseItem: BaseItem{
+			Entity: Entity{
+				X:    x,
+				Y:    y,
+				Char: '!',
+			},
+			ID:          ringItemID,
+			Type:        "Accessory",
+			Name:        def.Name,
+			Description: def.Description,
+			UseActions: map[string]UseAction{
+				"AccessoryEffect": func(g *Game) {
+				},
+			},
+		},
+		Effect:     def.Effect,
+		Stats:      def.Stats,
+		Cursed:     cursed,
+		Blessed:    blessed,
+		Identified: false,
+	}
+}
+
+// GetDescription hides the effect of unidentified rings.
+func (ac *Accessory) GetDescription() string {
+	if !ac.Identified {
+		return "正体のわからない指輪。識別すると効果がわかる。"
+	}
+	return ac.Description
+}
+
+// activeRing returns the equipped ring with the given effect.
+// 呪われた指輪は常時効果を発揮しない
+func (p *Player) activeRing(effect RingEffectID) *Accessory {
+	for _, equippedItem := range p.EquippedItems {
+		if ring, ok := equippedItem.(*Accessory); ok && ring.Effect == effect && !ring.Cursed {
+			return ring
+		}
+	}
+	return nil
+}
+
+// HasRingEffect reports whether an equipped ring provides the effect.
+func (p *Player) HasRingEffect(effect RingEffectID) bool {
+	return p.activeRing(effect) != nil
+}
+
+// noticeRingEffect identifies the ring when its effect becomes visible to the player.
+func (g *Game) noticeRingEffect(effect RingEffectID) {
+	ring := g.state.Player.activeRing(effect)
+	if ring == nil || ring.Identified {
+		return
+	}
+	unidentifiedName := ring.GetName()
+	action := Action{
+		Duration:     0.5,
+		Message:      fmt.Sprintf("%sの効果がわかった。", unidentifiedName),
+		ItemName:     unidentifiedName,
+		Execute:      func(g *Game) {},
+		IsIdentified: false,
+	}
+	g.Enqueue(action)
+	ring.SetIdentified(true)
+}
+
+// onRingEquipped is called after a ring is equipped.
+func (g *Game) onRingEquipped(ring *Accessory) {
+	// 罠が見えるようになった場合はその場で効果がわかる
+	if ring.Effect == RingTrapSight && !ring.Cursed && len(g.state.Traps) > 0 {
+		g.noticeRingEffect(RingTrapSight)
+	}
+	// 周りが明るくなるのですぐにわかる
+	if ring.Effect == RingLantern && !ring.Cursed {
+		g.noticeRingEffect(RingLantern)
+	}
+}
+
+// lightRadius returns how far the player's own light reaches.
+func (p *Player) lightRadius() int {
+	if p.HasRingEffect(RingLantern) {
+		return baseLightRadius + lanternLightBonus
+	}
+	return baseLightRadius
+}
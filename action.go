@@ -32,7 +32,8 @@ func (g *Game) executeGroundItemAction() {
 					itemName = getItemNameWithSharpness(item)
 				}
 				// プレイヤーのインベントリサイズをチェック
-				if len(g.state.Player.Inventory) < 20 {
+				_, isMoney := item.(*Money) // お金は所持金に加算されるため満杯でも拾える
+				if !g.state.Player.IsInventoryFull() || isMoney {
 					action := Action{
 						Duration: 0.8,
 						Message:  fmt.Sprintf("%sを拾った", itemName),
@@ -61,14 +62,15 @@ func (g *Game) executeGroundItemAction() {
 				}
 			}
 		}
-		g.ShowGroundItem = false
+		g.overlays.Close(OverlayGroundItem)
 		g.GroundItemActioned = false
 		g.selectedGroundActionIndex = 0
 	}
 
 	if g.selectedGroundActionIndex == 1 { // Assuming index 1 corresponds to '交換'
-		g.ShowGroundItem = false
-		g.showInventory = true
+		g.overlays.Close(OverlayGroundItem)
+		g.inventoryFilter = kindAll
+		g.overlays.Open(OverlayInventory)
 	}
 
 	if g.selectedGroundActionIndex == 2 { // Assuming index 2 corresponds to '使う' or '装備'
@@ -86,6 +88,10 @@ func (g *Game) executeGroundItemAction() {
 					moneyItem.Use(g)
 				} else if trapItem, ok := item.(*Trap); ok {
 					trapItem.Use(g)
+				} else if potItem, ok := item.(*Pot); ok {
+					potItem.Use(g)
+				} else if keyItem, ok := item.(*Key); ok {
+					keyItem.Use(g)
 				} else if caneItem, ok := item.(*Cane); ok {
 
 					if caneItem.Uses <= 0 {
@@ -97,7 +103,7 @@ func (g *Game) executeGroundItemAction() {
 						}
 						g.Enqueue(action)
 
-						g.ShowGroundItem = false
+						g.overlays.Close(OverlayGroundItem)
 						g.GroundItemActioned = false
 						g.selectedGroundActionIndex = 0
 						g.isActioned = true
@@ -133,7 +139,7 @@ func (g *Game) executeGroundItemAction() {
 				} else if equipableItem, ok := item.(Equipable); ok { // Check if item is of Equipable type
 
 					// インベントリのサイズを確認し、いっぱいの場合はアイテムを拾わない
-					if len(g.state.Player.Inventory) >= 20 {
+					if g.state.Player.IsInventoryFull() {
 						action := Action{
 							Duration: 0.5,
 							Message:  fmt.Sprintf("持ち物がいっぱいで%sを拾えなかった", item.GetName()),
@@ -146,74 +152,13 @@ func (g *Game) executeGroundItemAction() {
 						break
 					}
 
-					var message string
-					identified := false
-					itemName := getItemNameWithSharpness(equipableItem) // Assume this function can handle Equipable type
-
-					// Find an empty slot or use the last slot
-					equipIndex := -1
-					for i := 0; i < 4; i++ { // Search for an empty slot in EquippedItems[0] to EquippedItems[3]
-						if g.state.Player.EquippedItems[i] == nil {
-							equipIndex = i
-							break
-						}
-					}
-					if equipIndex == -1 { // If no empty slot found, use the last slot
-						equipIndex = 4
-					}
-
-					// equipableItemがAccessory型の場合はIdentifiedをtrueにしない
-					if _, ok := equipableItem.(*Accessory); !ok {
-						equipableItem.SetIdentified(true) // Set the item as identified when equipping
-						identified = true
-						itemName = getItemNameWithSharpness(equipableItem)
-					}
-
-					// Equip the item
-					message = fmt.Sprintf("%sを装備した。", itemName)
-					equipableItem.UpdatePlayerStats(&g.state.Player, true)   // Update player's stats when equipping
-					g.state.Player.EquippedItems[equipIndex] = equipableItem // Equip item
-					g.PickUpItem(item, i)
-
-					action := Action{
-						Duration: 0.5,
-						Message:  message,
-						ItemName: itemName,
-						Execute: func(g *Game) {
-							// The equipped/unequipped item is already set above
-						},
-						IsIdentified: identified,
-					}
-					g.Enqueue(action)
-					// Check if the item is cursed after equipping
-					cursedMessage := ""
-					switch v := equipableItem.(type) {
-					case *Weapon:
-						if v.Cursed {
-							cursedMessage = fmt.Sprintf("%sは呪われていた。", itemName)
-						}
-					case *Armor:
-						if v.Cursed {
-							cursedMessage = fmt.Sprintf("%sは呪われていた。", itemName)
-						}
-					}
-
-					// If the item is cursed, enqueue the cursed message
-					if cursedMessage != "" {
-						cursedAction := Action{
-							Duration: 0.5,
-							Message:  cursedMessage,
-							ItemName: itemName,
-							Execute: func(g *Game) {
-								// This can be left empty if no additional behavior is needed other than displaying the message
-							},
-							IsIdentified: identified,
-						}
-						g.Enqueue(cursedAction)
+					// 装備できた場合だけ拾う
+					if g.equipItem(equipableItem) {
+						g.PickUpItem(item, i)
 					}
 				}
 
-				g.ShowGroundItem = false
+				g.overlays.Close(OverlayGroundItem)
 				g.GroundItemActioned = false
 				g.selectedGroundActionIndex = 0
 				g.isActioned = true
@@ -243,7 +188,7 @@ func (g *Game) executeGroundItemAction() {
 				// Continue with the throwing logic if the item is not cursed and equipped
 				g.ThrowItem(item, throwRange, character, mapState, enemies, onWallHit, onTargetHit)
 
-				g.ShowGroundItem = false
+				g.overlays.Close(OverlayGroundItem)
 				g.isActioned = true
 			}
 		}
@@ -264,6 +209,10 @@ func (g *Game) executeAction() {
 			moneyItem.Use(g)
 		} else if trapItem, ok := item.(*Trap); ok {
 			trapItem.Use(g)
+		} else if potItem, ok := item.(*Pot); ok {
+			potItem.Use(g)
+		} else if keyItem, ok := item.(*Key); ok {
+			keyItem.Use(g)
 		} else if caneItem, ok := item.(*Cane); ok {
 
 			if caneItem.Uses <= 0 {
@@ -274,8 +223,7 @@ func (g *Game) executeAction() {
 					},
 				}
 				g.Enqueue(action)
-				g.showItemActions = false
-				g.showInventory = false
+				g.overlays.Close(OverlayInventory)
 				g.isActioned = true
 				g.selectedItemIndex = 0
 				g.selectedActionIndex = 0
@@ -309,111 +257,37 @@ func (g *Game) executeAction() {
 			g.ThrowItem(&caneItemCopy, throwRange, character, mapState, enemies, onWallHit, onTargetHit)
 
 		} else if equipableItem, ok := item.(Equipable); ok { // Check if item is of Equipable type
-			var message string
-			identified := false
-			itemName := getItemNameWithSharpness(equipableItem) // Assume this function can handle Equipable type
-
-			// Find an empty slot or use the last slot
-			equipIndex := -1
-			for i := 0; i < 4; i++ { // Search for an empty slot in EquippedItems[0] to EquippedItems[3]
-				if g.state.Player.EquippedItems[i] == nil {
-					equipIndex = i
-					break
-				}
-			}
-			if equipIndex == -1 { // If no empty slot found, use the last slot
-				equipIndex = 4
-			}
-
-			// Check if the item is already equipped
-			alreadyEquipped := false
-			for i := 0; i < 5; i++ {
-				if g.state.Player.EquippedItems[i] == equipableItem {
-					alreadyEquipped = true
-					equipIndex = i // Update the equipIndex to the slot where the item is already equipped
-					break
-				}
-			}
-
-			if alreadyEquipped {
+			if slot, alreadyEquipped := g.state.Player.equippedSlot(equipableItem); alreadyEquipped {
+				itemName := getItemNameWithSharpness(equipableItem)
+				var message string
 				// Check if the equipped item is cursed
-				isCursed := false
-				switch v := equipableItem.(type) {
-				case *Weapon:
-					if v.Cursed {
-						isCursed = true
-					}
-				case *Armor:
-					if v.Cursed {
-						isCursed = true
-					}
-				}
-
-				if isCursed {
+				if isCursedItem(equipableItem) {
 					// If the item is cursed, update the message and do not unequip
 					message = fmt.Sprintf("%sをはずせない。", itemName)
 				} else {
-					// Unequip the item
 					message = fmt.Sprintf("%sをはずした。", itemName)
-					equipableItem.UpdatePlayerStats(&g.state.Player, false) // Update player's stats when unequipping
-					g.state.Player.EquippedItems[equipIndex] = nil          // Remove item from equipped items
-				}
-			} else {
-				if _, ok := equipableItem.(*Accessory); !ok {
-					equipableItem.SetIdentified(true) // Set the item as identified when equipping
-					identified = true
-				}
-				itemName = getItemNameWithSharpness(equipableItem)
-				// Equip the item
-				message = fmt.Sprintf("%sを装備した。", itemName)
-				equipableItem.UpdatePlayerStats(&g.state.Player, true) // Update player's stats when equipping
-				// equipableItemがAccessory型の場合はIdentifiedをtrueにしない
-				g.state.Player.EquippedItems[equipIndex] = equipableItem // Equip item
-			}
-
-			action := Action{
-				Duration: 0.5,
-				Message:  message,
-				ItemName: itemName,
-				Execute: func(g *Game) {
-					// The equipped/unequipped item is already set above
-				},
-				IsIdentified: identified,
-			}
-			g.Enqueue(action)
-			// Check if the item is cursed after equipping
-			cursedMessage := ""
-			switch v := equipableItem.(type) {
-			case *Weapon:
-				if v.Cursed {
-					cursedMessage = fmt.Sprintf("%sは呪われていた。", itemName)
-				}
-			case *Armor:
-				if v.Cursed {
-					cursedMessage = fmt.Sprintf("%sは呪われていた。", itemName)
+					g.unequipSlot(slot)
 				}
-			}
-
-			// If the item is cursed, enqueue the cursed message
-			if cursedMessage != "" {
-				cursedAction := Action{
+				action := Action{
 					Duration: 0.5,
-					Message:  cursedMessage,
+					Message:  message,
 					ItemName: itemName,
 					Execute: func(g *Game) {
-						// This can be left empty if no additional behavior is needed other than displaying the message
+						// The unequipped item is already set above
 					},
-					IsIdentified: identified,
+					IsIdentified: equipableItem.IsIdentified(),
 				}
-				g.Enqueue(cursedAction)
+				g.Enqueue(action)
+			} else {
+				g.equipItem(equipableItem)
 			}
 		}
 
-		if !g.useidentifyItem {
-			g.showInventory = false
+		if !g.overlays.Has(OverlayItemSelect) {
+			g.overlays.Close(OverlayInventory)
 			g.isActioned = true
 		}
-		g.showItemActions = false
+		g.overlays.Close(OverlayItemActions)
 		g.selectedItemIndex = 0
 	}
 
@@ -426,20 +300,7 @@ func (g *Game) executeAction() {
 		if equipableItem, ok := item.(Equipable); ok {
 			for i, equippedItem := range g.state.Player.EquippedItems {
 				if equippedItem == equipableItem {
-					switch v := equipableItem.(type) {
-					case *Weapon:
-						if v.Cursed {
-							isCursedEquipped = true
-						}
-					case *Armor:
-						if v.Cursed {
-							isCursedEquipped = true
-						}
-					case *Accessory:
-						if v.Cursed {
-							isCursedEquipped = true
-						}
-					}
+					isCursedEquipped = isCursedItem(equipableItem)
 					if isCursedEquipped {
 						// If the item is cursed and equipped, do not throw and enqueue an action with a message that it cannot be thrown
 						action := Action{
@@ -447,8 +308,7 @@ func (g *Game) executeAction() {
 							Message:  fmt.Sprintf("%sは呪われていて投げられない", itemName),
 							Execute: func(g *Game) {
 								// Any additional logic if needed
-								g.showItemActions = false
-								g.showInventory = false
+								g.overlays.Close(OverlayInventory)
 								g.selectedItemIndex = 0
 								g.selectedActionIndex = 0
 							},
@@ -458,6 +318,7 @@ func (g *Game) executeAction() {
 					}
 					// If it is equipped and not cursed, remove it from the equipped items list
 					g.state.Player.EquippedItems[i] = nil
+					equipableItem.UpdatePlayerStats(&g.state.Player, false) // Update player's stats when unequipping
 					break
 				}
 			}
@@ -513,25 +374,7 @@ func (g *Game) executeAction() {
 		selectedItem := g.state.Player.Inventory[g.selectedItemIndex]
 
 		// Check if the item is cursed and equipped
-		isCursedEquipped := false
-		if equipableItem, ok := selectedItem.(Equipable); ok {
-			for _, equippedItem := range g.state.Player.EquippedItems {
-				if equippedItem == equipableItem {
-					// Type assertion to Weapon or Armor to access Cursed property
-					switch v := equipableItem.(type) {
-					case *Weapon:
-						if v.Cursed {
-							isCursedEquipped = true
-						}
-					case *Armor:
-						if v.Cursed {
-							isCursedEquipped = true
-						}
-					}
-					break
-				}
-			}
-		}
+		isCursedEquipped := g.isCursedEquipped(selectedItem)
 
 		if isCursedEquipped {
 			// If the item is cursed and equipped, enqueue an action with a message that it cannot be placed
@@ -541,8 +384,7 @@ func (g *Game) executeAction() {
 				Execute: func(g *Game) {
 					g.selectedItemIndex = 0
 					g.selectedActionIndex = 0
-					g.showItemActions = false
-					g.showInventory = false
+					g.overlays.Close(OverlayInventory)
 				},
 			}
 			g.Enqueue(action)
@@ -555,15 +397,7 @@ func (g *Game) executeAction() {
 					selectedItem := g.state.Player.Inventory[g.selectedItemIndex]
 
 					// Check if the item is equipped and unequip if necessary
-					if equipableItem, ok := selectedItem.(Equipable); ok {
-						for i, equippedItem := range g.state.Player.EquippedItems {
-							if equippedItem == equipableItem {
-								g.state.Player.EquippedItems[i] = nil
-								equipableItem.UpdatePlayerStats(&g.state.Player, false) // Update player's stats when unequipping
-								break
-							}
-						}
-					}
+					g.unequipItem(selectedItem)
 
 					// Remove the item from inventory
 					g.state.Player.Inventory = append(g.state.Player.Inventory[:g.selectedItemIndex], g.state.Player.Inventory[g.selectedItemIndex+1:]...)
@@ -574,8 +408,7 @@ func (g *Game) executeAction() {
 
 					g.selectedItemIndex = 0
 					g.selectedActionIndex = 0
-					g.showItemActions = false
-					g.showInventory = false
+					g.overlays.Close(OverlayInventory)
 					g.isActioned = true
 				},
 				IsIdentified: identified,
@@ -589,8 +422,7 @@ func (g *Game) executeAction() {
 				Execute: func(g *Game) {
 					g.selectedItemIndex = 0
 					g.selectedActionIndex = 0
-					g.showItemActions = false
-					g.showInventory = false
+					g.overlays.Close(OverlayInventory)
 				},
 				IsIdentified: identified,
 			}
@@ -601,7 +433,7 @@ func (g *Game) executeAction() {
 	if g.selectedActionIndex == 3 { // Assuming 0-based index and "説明" is at index 3
 		selectedItem := g.state.Player.Inventory[g.selectedItemIndex]
 		g.itemdescriptionText = selectedItem.GetDescription()
-		g.showItemDescription = true
+		g.overlays.Open(OverlayItemDescription)
 	}
 
 }
@@ -617,7 +449,7 @@ func (g *Game) Enqueue(action Action) {
 func (g *Game) processAction(action Action) {
 	// 実際のアクションの実行ロジックはアクションオブジェクトのExecuteメソッドに委譲
 	action.Execute(g)
-	g.ActionDurationCounter = action.Duration // record the duration of the next action
+	g.ActionDurationCounter = settings.actionDuration(action.Duration) // record the duration of the next action
 }
 
 // Enqueue adds a new attack to the attack queue
@@ -637,29 +469,20 @@ func (g *Game) AttackFromEnemy(enemyIndex int) {
 		}
 		g.Enqueue(action)
 
+		uid := enemy.UID
 		action = Action{
 			Duration: 0.5,
 			Message:  fmt.Sprintf("罠カード、%sが発動した。", trap.GetName()),
-			Execute:  func(g *Game) {},
-		}
-		g.Enqueue(action)
-
-		defeatAction := Action{
-			Duration: 0.5,
-			Message:  fmt.Sprintf("%sを倒した。", enemy.Name),
 			Execute: func(g *Game) {
-				g.state.Enemies = append(g.state.Enemies[:enemyIndex], g.state.Enemies[enemyIndex+1:]...)
-
-				// 敵の経験値をプレイヤーの所持経験値に加える
-				g.state.Player.ExperiencePoints += enemy.ExperiencePoints
-
-				g.state.Player.checkLevelUp() // レベルアップをチェック
+				if i := g.enemyIndexByUID(uid); i >= 0 {
+					g.DealDamage(playerUID, uid, g.state.Enemies[i].Health, DamageTrap) // 攻撃してきた敵を倒す
+				}
 
 				// トラップをリセットする (オプショナル)
 				g.state.Player.SetTrap = nil
 			},
 		}
-		g.Enqueue(defeatAction)
+		g.Enqueue(action)
 		return
 	}
 
@@ -678,17 +501,17 @@ func (g *Game) AttackFromEnemy(enemyIndex int) {
 		}
 
 		dx, dy := g.state.Player.X-enemy.X, g.state.Player.Y-enemy.Y // プレイヤーと敵の位置の差を計算
+		uid := enemy.UID
 
 		action := Action{
 			Duration: 0.5,
 			Message:  fmt.Sprintf("%sから%dダメージを受けた", enemy.Name, netDamage),
 			Execute: func(g *Game) {
-				enemy.AttackTimer = 0.5                            // ここでAttackTimerを設定することで、敵の攻撃アニメーションが実行される
-				enemy.AttackDirection = determineDirection(dx, dy) // 敵の攻撃方向を計算
-				g.state.Player.Health -= netDamage
-				if g.state.Player.Health < 0 {
-					g.state.Player.Health = 0 // Ensure health does not go below 0
+				if i := g.enemyIndexByUID(uid); i >= 0 {
+					g.state.Enemies[i].AttackTimer = 0.5                            // ここでAttackTimerを設定することで、敵の攻撃アニメーションが実行される
+					g.state.Enemies[i].AttackDirection = determineDirection(dx, dy) // 敵の攻撃方向を計算
 				}
+				g.DealDamage(uid, playerUID, netDamage, DamageMelee)
 			},
 		}
 
@@ -703,12 +526,21 @@ func (g *Game) CheckForEnemies(x, y int) bool {
 	for i, enemy := range g.state.Enemies {
 		if enemy.X == g.state.Player.X+x && enemy.Y == g.state.Player.Y+y {
 			g.isFrontEnemy = true
+			g.revealMimic(i) // 擬態している敵は攻撃されると正体を現す
+			g.makeNoise(g.state.Player.X, g.state.Player.Y, attackNoiseRadius)
 			// Player's AttackPower is considered while dealing damage
 			netDamage := g.state.Player.AttackPower + g.state.Player.Power + g.state.Player.Level - enemy.DefensePower + rand.Intn(3) - 1
 			if netDamage < 0 { // Ensure damage does not go below 0
 				netDamage = 0
 			}
 
+			// 会心の指輪を装備している場合は一定確率でダメージが1.5倍になる
+			if g.state.Player.HasRingEffect(RingCritical) && rand.Float64() < criticalChance {
+				netDamage = netDamage * 3 / 2
+				g.Enqueue(Action{Duration: 0.3, Message: "会心の一撃！", Execute: func(g *Game) {}})
+				g.noticeRingEffect(RingCritical)
+			}
+
 			dx, dy := enemy.X-g.state.Player.X, enemy.Y-g.state.Player.Y
 
 			// Determine the direction based on the change in position
@@ -732,32 +564,13 @@ func (g *Game) CheckForEnemies(x, y int) bool {
 			}
 
 			g.attackTimer = 0.5 // set timer for 0.5 seconds
+			uid := enemy.UID
 			action := Action{
 				Duration: 0.5,
 				Message:  fmt.Sprintf("%sに%dダメージを与えた。", g.state.Enemies[i].Name, netDamage),
 				Execute: func(g *Game) {
-
-					enemyIndex := i // ここでi変数の値を明示的にキャプチャ
-					g.state.Enemies[enemyIndex].Health -= netDamage
-
-					if g.state.Enemies[enemyIndex].Health <= 0 {
-						// 敵のHealthが0以下の場合、敵を配列から削除
-						defeatAction := Action{
-							Duration: 0.5,
-							Message:  fmt.Sprintf("%sを倒した。", g.state.Enemies[enemyIndex].Name),
-							Execute:  func(g *Game) {},
-						}
-						g.Enqueue(defeatAction)
-
-						g.state.Enemies = append(g.state.Enemies[:enemyIndex], g.state.Enemies[enemyIndex+1:]...)
-
-						// 敵の経験値をプレイヤーの所持経験値に加える
-						g.state.Player.ExperiencePoints += enemy.ExperiencePoints
-
-						g.state.Player.checkLevelUp() // レベルアップをチェック
-					}
+					g.DealDamage(playerUID, uid, netDamage, DamageMelee)
 					g.isActioned = true
-
 				},
 			}
 
@@ -768,9 +581,16 @@ func (g *Game) CheckForEnemies(x, y int) bool {
 	}
 	if !g.isFrontEnemy {
 		g.attackTimer = 0.5 // set timer for 0.5 seconds
+		message := ""
+		// つるはしを装備していれば正面の壁を掘る
+		if weapon, ok := g.state.Player.EquippedItems[SlotWeapon].(*Weapon); ok && weapon.Digs &&
+			digTile(g.state.Map, g.state.Player.X+x, g.state.Player.Y+y) {
+			message = "壁を掘った。"
+			g.miniMapDirty = true
+		}
 		action := Action{
 			Duration: 0.5,
-			Message:  "",
+			Message:  message,
 			Execute: func(g *Game) {
 				g.isActioned = true
 			},
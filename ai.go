@@ -0,0 +1,242 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// AIState は敵の思考状態
+type AIState int
+
+const (
+	StateWandering AIState = iota // フロアをうろついている
+	StateSleeping                 // 眠っている
+	StateHunting                  // プレイヤーを追いかけている
+	StateFleeing                  // HPが減ってプレイヤーから逃げている
+	StateGuarding                 // 持ち場を守っている
+	StateFollowing                // リーダーの後をついて歩いている
+)
+
+const (
+	defaultSightRange = 4  // 通路で敵がプレイヤーを見通せる距離
+	loseSightDistance = 15 // この距離以上離れて見えなくなると敵はプレイヤーを見失う
+	sleepWakeChance   = 0.2
+	followDistance    = 2  // リーダーとの間にあける距離
+	leaderSearchRange = 10 // リーダーを探す距離
+	attackNoiseRadius = 5  // 攻撃したときの物音が届く距離
+	throwNoiseRadius  = 4  // 投げた物が落ちたときの物音が届く距離
+)
+
+// AIProfile は敵の種類ごとの思考の設定
+type AIProfile struct {
+	InitialState AIState // 出現したときの状態
+	SleepChance  float64 // 眠った状態で出現する確率
+	FleeHPRatio  float64 // HPがこの割合以下になると逃げる (0の場合は逃げない)
+	SightRange   int     // 通路で見通せる距離 (0の場合はdefaultSightRange)
+}
+
+func (s AIState) Label() string {
+	switch s {
+	case StateSleeping:
+		return "睡眠"
+	case StateHunting:
+		return "追跡"
+	case StateFleeing:
+		return "逃走"
+	case StateGuarding:
+		return "護衛"
+	case StateFollowing:
+		return "追従"
+	default:
+		return "徘徊"
+	}
+}
+
+// initAIState sets the initial state of a newly created enemy.
+func (e *Enemy) initAIState(profile AIProfile) {
+	e.AI = profile
+	e.HomeState = profile.InitialState
+	if e.HomeState == StateSleeping || e.HomeState == StateHunting || e.HomeState == StateFleeing {
+		e.HomeState = StateWandering // 起きた後や見失った後の状態
+	}
+	e.State = profile.InitialState
+	if localRand.Float64() < profile.SleepChance {
+		e.State = StateSleeping
+	}
+	e.GuardX, e.GuardY = e.X, e.Y
+}
+
+// canSeePlayer reports whether the enemy can see the player.
+func (g *Game) canSeePlayer(e *Enemy) bool {
+	if isAdjacentToPlayer(g, e) || isSameRoom(e.X, e.Y, g.state.Player.X, g.state.Player.Y, g.rooms) {
+		return true
+	}
+	sightRange := e.AI.SightRange
+	if sightRange == 0 {
+		sightRange = defaultSightRange
+	}
+	return g.hasLineOfSight(e, sightRange)
+}
+
+func (e *Enemy) isLowHealth() bool {
+	return e.AI.FleeHPRatio > 0 && float64(e.Health) <= float64(e.MaxHealth)*e.AI.FleeHPRatio
+}
+
+// hasStolen reports whether the enemy carries an item or money it has stolen.
+func (e *Enemy) hasStolen() bool {
+	return e.StolenItem != nil || e.StolenCash > 0
+}
+
+// shouldFlee reports whether the enemy runs away from the player: its HP is low, or
+// it carries something it has stolen.
+func (e *Enemy) shouldFlee() bool {
+	return e.isLowHealth() || e.hasStolen()
+}
+
+// updateAIState changes the state of the enemy according to what it sees and its HP.
+func (g *Game) updateAIState(i int) {
+	e := &g.state.Enemies[i]
+	seesPlayer := g.canSeePlayer(e)
+
+	switch e.State {
+	case StateSleeping:
+		if isAdjacentToPlayer(g, e) || (seesPlayer && localRand.Float64() < sleepWakeChance) {
+			e.State = StateHunting
+		}
+	case StateWandering, StateGuarding, StateFollowing:
+		if seesPlayer {
+			e.State = StateHunting
+		}
+	case StateHunting:
+		distance := abs(e.X-g.state.Player.X) + abs(e.Y-g.state.Player.Y)
+		if e.shouldFlee() {
+			e.State = StateFleeing
+		} else if !seesPlayer && distance >= loseSightDistance {
+			e.State = e.HomeState // プレイヤーを見失った
+		}
+	case StateFleeing:
+		if !e.shouldFlee() {
+			e.State = StateHunting // 回復したら再び追いかける
+		}
+	}
+
+	e.PlayerDiscovered = e.State == StateHunting || e.State == StateFleeing
+}
+
+// makeNoise wakes up and alerts the enemies within the radius.
+func (g *Game) makeNoise(x, y, radius int) {
+	for i := range g.state.Enemies {
+		e := &g.state.Enemies[i]
+		if max(abs(e.X-x), abs(e.Y-y)) > radius || e.Disguised {
+			continue
+		}
+		switch e.State {
+		case StateSleeping:
+			if localRand.Float64() < 0.5 {
+				e.State = StateHunting
+			}
+		case StateWandering, StateGuarding, StateFollowing:
+			e.State = StateHunting
+		}
+	}
+}
+
+// moveEnemyTowards moves the enemy one step towards the target. It returns true if the
+// enemy moved, or used its turn to open a door in the way.
+func (g *Game) moveEnemyTowards(i, targetX, targetY int) bool {
+	e := &g.state.Enemies[i]
+	stepX, stepY := sign(targetX-e.X), sign(targetY-e.Y)
+	for _, step := range [][2]int{{stepX, stepY}, {stepX, 0}, {0, stepY}} {
+		if step[0] == 0 && step[1] == 0 {
+			continue
+		}
+		if g.enemyOpenDoor(i, e.X+step[0], e.Y+step[1]) {
+			return true
+		}
+		if !isDiagonallyBlockedMove(g, e.X, e.Y, step[0], step[1]) && moveEnemy(g, i, step[0], step[1]) {
+			e.dx, e.dy = step[0], step[1]
+			e.Direction = determineDirection(step[0], step[1])
+			e.Animating = true
+			return true
+		}
+	}
+	return false
+}
+
+// enemyOpenDoor lets an enemy that can open doors open the closed door at (x, y)
+// next to it. 斜めの扉と鍵のかかった扉は開けられない
+func (g *Game) enemyOpenDoor(i, x, y int) bool {
+	e := &g.state.Enemies[i]
+	if !enemyDefinitions[e.ID].OpensDoors || (x != e.X && y != e.Y) || abs(x-e.X)+abs(y-e.Y) != 1 {
+		return false
+	}
+	if y < 0 || y >= len(g.state.Map) || x < 0 || x >= len(g.state.Map[y]) || g.state.Map[y][x].Type != doorClosed {
+		return false
+	}
+	placeDoor(g.state.Map, x, y, doorOpen)
+	g.miniMapDirty = true
+	if isLit(g.state.Map[y][x]) {
+		g.Enqueue(Action{Duration: 0.3, Message: fmt.Sprintf("%sが扉を開けた。", e.Name), Execute: func(g *Game) {}})
+	}
+	return true
+}
+
+// fleeFromPlayer moves the enemy away from the player. A cornered enemy fights back.
+func (g *Game) fleeFromPlayer(i int) {
+	e := &g.state.Enemies[i]
+	x, y := e.X, e.Y
+	g.moveAwayFromPlayer(i)
+	if e.X == x && e.Y == y && isAdjacentToPlayer(g, e) {
+		g.huntPlayer(i)
+	}
+}
+
+// guardPost keeps the enemy at its post.
+func (g *Game) guardPost(i int) {
+	e := &g.state.Enemies[i]
+	if e.X != e.GuardX || e.Y != e.GuardY {
+		g.moveEnemyTowards(i, e.GuardX, e.GuardY)
+	}
+}
+
+// followLeader moves the enemy behind the nearest enemy that is not a follower.
+func (g *Game) followLeader(i int) {
+	e := &g.state.Enemies[i]
+	leader := -1
+	leaderDistance := leaderSearchRange + 1
+	for j, other := range g.state.Enemies {
+		distance := max(abs(other.X-e.X), abs(other.Y-e.Y))
+		if j != i && other.HomeState != StateFollowing && distance < leaderDistance {
+			leader, leaderDistance = j, distance
+		}
+	}
+	if leader == -1 {
+		moveRandomly(g, i) // リーダーがいない場合はうろつく
+		return
+	}
+	if leaderDistance > followDistance {
+		g.moveEnemyTowards(i, g.state.Enemies[leader].X, g.state.Enemies[leader].Y)
+	}
+}
+
+// drawAIDebugOverlay draws the AI state of each visible enemy above it.
+func (g *Game) drawAIDebugOverlay(screen *ebiten.Image, offsetX, offsetY int) {
+	if !g.showAIDebug {
+		return
+	}
+	for _, enemy := range g.state.Enemies {
+		if !isLit(g.state.Map[enemy.Y][enemy.X]) {
+			continue
+		}
+		label := fmt.Sprintf("%s %d/%d", enemy.State.Label(), enemy.Health, enemy.MaxHealth)
+		x := enemy.X*tileSize + offsetX
+		y := enemy.Y*tileSize + offsetY - 2
+		text.Draw(screen, label, mplusSmallFont, x, y, color.RGBA{0xff, 0xff, 0x00, 0xff})
+	}
+}
@@ -112,7 +112,6 @@ func (g *Game) HandleActionQueue() {
 
 	if len(g.ActionQueue.Queue) == 0 && g.isCombatActive && g.ActionDurationCounter <= 0 {
 		g.isCombatActive = false // reset the combat active flag when the queue is empty
-		g.showDescription = false
 	}
 }
 
@@ -121,6 +120,7 @@ func (g *Game) CheckCombatState() {
 		if !g.isCombatActive {
 			g.IncrementMoveCount()
 			g.MoveEnemies()
+			g.updateFloorTurn()
 			g.isActioned = false
 		}
 	}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArenaLayout は地図ファイルから読み込んだボス部屋の配置
+type ArenaLayout struct {
+	Tiles            [][]Tile
+	PlayerX, PlayerY int // プレイヤーの開始位置
+	BossX, BossY     int // ボスの位置
+	StairsX, StairsY int // ボスを倒すと現れる階段の位置
+}
+
+// parseArena parses an arena map file. Each character is one tile:
+//
+//	'#' 壁, '.' 床, '@' プレイヤーの開始位置, 'B' ボス, '>' ボスを倒すと現れる階段, ' ' 何もない場所
+func parseArena(data string) (ArenaLayout, error) {
+	var layout ArenaLayout
+	lines := strings.Split(strings.ReplaceAll(data, "\r", ""), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return layout, fmt.Errorf("arena map is empty")
+	}
+
+	width := 0
+	for _, line := range lines {
+		width = max(width, len([]rune(line)))
+	}
+
+	found := map[rune]int{}
+	layout.Tiles = make([][]Tile, len(lines))
+	for y, line := range lines {
+		layout.Tiles[y] = make([]Tile, width)
+		for x := range layout.Tiles[y] {
+			layout.Tiles[y][x] = Tile{Type: "other", Blocked: true, BlockSight: true}
+		}
+		for x, c := range []rune(line) {
+			switch c {
+			case '#':
+				layout.Tiles[y][x] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+			case '.', '@', 'B':
+				layout.Tiles[y][x] = Tile{Type: "floor", Blocked: false, BlockSight: false}
+			case '>':
+				layout.Tiles[y][x] = Tile{Type: "sealed_stairs", Blocked: false, BlockSight: false}
+			case ' ':
+			default:
+				return layout, fmt.Errorf("unknown tile %q at line %d, column %d", c, y+1, x+1)
+			}
+			switch c {
+			case '@':
+				layout.PlayerX, layout.PlayerY = x, y
+			case 'B':
+				layout.BossX, layout.BossY = x, y
+			case '>':
+				layout.StairsX, layout.StairsY = x, y
+			}
+			found[c]++
+		}
+	}
+
+	for _, c := range []rune{'@', 'B', '>'} {
+		if found[c] != 1 {
+			return layout, fmt.Errorf("arena map must have exactly one %q, found %d", c, found[c])
+		}
+	}
+	return layout, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestParseArena(t *testing.T) {
+	data := "#####\n#.B.#\n#...#\n#@>.#\n#####\n"
+	layout, err := parseArena(data)
+	if err != nil {
+		t.Fatalf("parseArena returned error: %v", err)
+	}
+	if len(layout.Tiles) != 5 || len(layout.Tiles[0]) != 5 {
+		t.Fatalf("unexpected size %dx%d", len(layout.Tiles[0]), len(layout.Tiles))
+	}
+	if layout.PlayerX != 1 || layout.PlayerY != 3 {
+		t.Errorf("player at (%d, %d), want (1, 3)", layout.PlayerX, layout.PlayerY)
+	}
+	if layout.BossX != 2 || layout.BossY != 1 {
+		t.Errorf("boss at (%d, %d), want (2, 1)", layout.BossX, layout.BossY)
+	}
+	if got := layout.Tiles[3][2].Type; got != "sealed_stairs" {
+		t.Errorf("stairs tile type = %q, want sealed_stairs", got)
+	}
+	if !layout.Tiles[0][0].Blocked || layout.Tiles[1][1].Blocked {
+		t.Errorf("unexpected Blocked flags for wall/floor")
+	}
+}
+
+func TestParseArenaErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"#####\n#.@>#\n#####\n", // no boss
+		"#####\n#B@>#\n#?..#\n", // unknown tile
+		"#B@>@#\n",              // two players
+	}
+	for _, data := range tests {
+		if _, err := parseArena(data); err == nil {
+			t.Errorf("parseArena(%q) returned no error", data)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+)
+
+const maxMultiplyCount = 10 // 分裂する敵が同じフロアに増えられる上限
+
+// EnemyBehavior は敵の行動パターン
+type EnemyBehavior interface {
+	// Act runs the enemy's turn. It returns false to fall back to the default
+	// movement and attack.
+	Act(g *Game, i int) bool
+}
+
+// SleepingBehavior は眠っている間も手番のある行動パターン (擬態して待ち伏せる敵など)。
+// これを実装していない行動パターンは、敵が眠っている間は何もしない
+type SleepingBehavior interface {
+	// ActAsleep runs the turn of the sleeping enemy.
+	ActAsleep(g *Game, i int)
+}
+
+// rangedBehavior は離れた場所から攻撃してくる敵
+type rangedBehavior struct {
+	Range      int    // 射程
+	Projectile string // 撃ってくるものの名前 (矢、または projectileDefinitions の鍵)
+}
+
+// thiefBehavior はアイテムやお金を盗んだ後に逃げる敵
+type thiefBehavior struct{}
+
+// wallWalkBehavior は壁の中を通り抜けて移動する敵
+type wallWalkBehavior struct{}
+
+// multiplyBehavior はプレイヤーを見つけると分裂して増える敵
+type multiplyBehavior struct {
+	Chance float64 // 分裂する確率
+}
+
+// mimicBehavior はアイテムに擬態して眠っている敵
+type mimicBehavior struct {
+	DisguiseType string // 擬態しているアイテムの種類 (Item.GetType)
+}
+
+// healerBehavior は近くの仲間の傷を癒す敵
+type healerBehavior struct {
+	Range  int // 回復できる距離
+	Amount int // 回復量
+}
+
+func isAdjacentToPlayer(g *Game, e *Enemy) bool {
+	return abs(g.state.Player.X-e.X) <= 1 && abs(g.state.Player.Y-e.Y) <= 1
+}
+
+func (b rangedBehavior) Act(g *Game, i int) bool {
+	e := &g.state.Enemies[i]
+	if !e.PlayerDiscovered || isAdjacentToPlayer(g, e) || !g.hasLineOfFire(e, b.Range) {
+		return false
+	}
+
+	dx, dy := g.state.Player.X-e.X, g.state.Player.Y-e.Y
+	e.Direction = determineDirection(sign(dx), sign(dy))
+	g.enemyThrow(i, newProjectile(b.Projectile, e.X, e.Y), b.Range)
+	return true
+}
+
+// hasLineOfFire reports whether the player is on a straight or diagonal line from
+// the enemy within maxRange with nothing in between.
+func (g *Game) hasLineOfFire(e *Enemy, maxRange int) bool {
+	return g.lineToPlayerClear(e, maxRange, func(x, y int) bool {
+		return g.state.Map[y][x].Blocked || isOccupied(g, x, y)
+	})
+}
+
+// hasLineOfSight is hasLineOfFire for seeing: only tiles that block sight, such as
+// walls and closed doors, are in the way. 他の敵の向こうは見える
+func (g *Game) hasLineOfSight(e *Enemy, maxRange int) bool {
+	return g.lineToPlayerClear(e, maxRange, func(x, y int) bool {
+		return g.state.Map[y][x].BlockSight
+	})
+}
+
+// lineToPlayerClear walks the straight or diagonal line from the enemy to the player
+// and reports whether no tile on it is blocked.
+func (g *Game) lineToPlayerClear(e *Enemy, maxRange int, blocked func(x, y int) bool) bool {
+	dx, dy := g.state.Player.X-e.X, g.state.Player.Y-e.Y
+	if (dx != 0 && dy != 0 && abs(dx) != abs(dy)) || max(abs(dx), abs(dy)) > maxRange {
+		return false
+	}
+	stepX, stepY := sign(dx), sign(dy)
+	for x, y := e.X+stepX, e.Y+stepY; x != g.state.Player.X || y != g.state.Player.Y; x, y = x+stepX, y+stepY {
+		if blocked(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b thiefBehavior) Act(g *Game, i int) bool {
+	// 盗んだ物を持って逃げている間は、追い詰められても攻撃せずに離れようとする
+	if e := &g.state.Enemies[i]; e.State != StateFleeing || !e.hasStolen() {
+		return false
+	}
+	g.moveAwayFromPlayer(i)
+	return true
+}
+
+// moveAwayFromPlayer moves the enemy one step to the tile farthest from the player.
+func (g *Game) moveAwayFromPlayer(i int) {
+	e := &g.state.Enemies[i]
+	bestDx, bestDy := 0, 0
+	bestDistance := max(abs(g.state.Player.X-e.X), abs(g.state.Player.Y-e.Y)) + abs(g.state.Player.X-e.X) + abs(g.state.Player.Y-e.Y)
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			x, y := e.X+dx, e.Y+dy
+			distance := max(abs(g.state.Player.X-x), abs(g.state.Player.Y-y)) + abs(g.state.Player.X-x) + abs(g.state.Player.Y-y)
+			if distance > bestDistance && isPositionFree(g, x, y, i) && !isDiagonallyBlockedMove(g, e.X, e.Y, dx, dy) {
+				bestDx, bestDy, bestDistance = dx, dy, distance
+			}
+		}
+	}
+	if (bestDx != 0 || bestDy != 0) && moveEnemy(g, i, bestDx, bestDy) {
+		e.dx, e.dy = bestDx, bestDy
+		e.Direction = determineDirection(bestDx, bestDy)
+		e.Animating = true
+	}
+}
+
+// isDiagonallyBlockedMove reports whether a diagonal step from (x, y) cuts a wall corner.
+func isDiagonallyBlockedMove(g *Game, x, y, dx, dy int) bool {
+	if dx == 0 || dy == 0 {
+		return false
+	}
+	return g.state.Map[y][x+dx].Blocked || g.state.Map[y+dy][x].Blocked
+}
+
+func (b wallWalkBehavior) Act(g *Game, i int) bool {
+	e := &g.state.Enemies[i]
+	if isAdjacentToPlayer(g, e) {
+		e.PlayerDiscovered = true
+		g.AttackFromEnemy(i) // 壁の中からでも攻撃してくる
+		return true
+	}
+	if !e.PlayerDiscovered && !g.state.Map[e.Y][e.X].Blocked {
+		return false
+	}
+
+	// 壁を無視してプレイヤーに近づく
+	stepX, stepY := sign(g.state.Player.X-e.X), sign(g.state.Player.Y-e.Y)
+	for _, step := range [][2]int{{stepX, stepY}, {stepX, 0}, {0, stepY}} {
+		x, y := e.X+step[0], e.Y+step[1]
+		if (step[0] == 0 && step[1] == 0) || x < 1 || y < 1 || x >= len(g.state.Map[0])-1 || y >= len(g.state.Map)-1 || isOccupied(g, x, y) {
+			continue
+		}
+		e.X, e.Y = x, y
+		e.dx, e.dy = step[0], step[1]
+		e.Direction = determineDirection(step[0], step[1])
+		e.Animating = true
+		break
+	}
+	return true
+}
+
+func (b multiplyBehavior) Act(g *Game, i int) bool {
+	e := &g.state.Enemies[i]
+	if !e.PlayerDiscovered || localRand.Float64() >= b.Chance {
+		return false
+	}
+
+	count := 0
+	for _, other := range g.state.Enemies {
+		if other.Type == e.Type {
+			count++
+		}
+	}
+	if count >= maxMultiplyCount {
+		return false
+	}
+
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			x, y := e.X+dx, e.Y+dy
+			if !isPositionFree(g, x, y, -1) {
+				continue
+			}
+			id, name := e.ID, e.Name
+			action := Action{
+				Duration: 0.5,
+				Message:  fmt.Sprintf("%sが分裂した。", name),
+				Execute: func(g *Game) {
+					if isPositionFree(g, x, y, -1) {
+						clone := newEnemy(id, x, y)
+						clone.PlayerDiscovered = true
+						g.state.Enemies = append(g.state.Enemies, clone)
+					}
+				},
+			}
+			g.Enqueue(action)
+			return true
+		}
+	}
+	return false
+}
+
+func (b mimicBehavior) Act(g *Game, i int) bool {
+	e := &g.state.Enemies[i]
+	if !e.Disguised {
+		return false
+	}
+	if isAdjacentToPlayer(g, e) {
+		g.revealMimic(i)
+		g.AttackFromEnemy(i)
+	}
+	return true // 擬態している間は動かない
+}
+
+// ActAsleep lets the disguised enemy ambush the player who comes next to it.
+func (b mimicBehavior) ActAsleep(g *Game, i int) {
+	b.Act(g, i)
+}
+
+// revealMimic makes a disguised enemy show its true form.
+func (g *Game) revealMimic(i int) {
+	e := &g.state.Enemies[i]
+	if !e.Disguised {
+		return
+	}
+	e.Disguised = false
+	e.PlayerDiscovered = true
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("アイテムは%sだった！", e.Name), Execute: func(g *Game) {}})
+}
+
+func (b healerBehavior) Act(g *Game, i int) bool {
+	e := &g.state.Enemies[i]
+	if !e.PlayerDiscovered || localRand.Float64() >= 0.5 {
+		return false
+	}
+	for _, other := range g.state.Enemies {
+		if other.Health >= other.MaxHealth || max(abs(other.X-e.X), abs(other.Y-e.Y)) > b.Range {
+			continue
+		}
+		amount := b.Amount
+		uid := other.UID
+		action := Action{
+			Duration: 0.5,
+			Message:  fmt.Sprintf("%sは%sの傷を癒した。", e.Name, other.Name),
+			Execute: func(g *Game) {
+				if j := g.enemyIndexByUID(uid); j >= 0 {
+					g.state.Enemies[j].Health = min(g.state.Enemies[j].MaxHealth, g.state.Enemies[j].Health+amount)
+				}
+			},
+		}
+		g.Enqueue(action)
+		return true
+	}
+	return false
+}
+
+// stealItemAttack steals an item the player is not wearing and makes the enemy flee.
+var stealItemAttack SpecialAttackFunc = func(e *Enemy, g *Game) {
+	var candidates []int
+	for i, item := range g.state.Player.Inventory {
+		if _, equipped := g.state.Player.equippedSlot(item); !equipped {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 || e.StolenItem != nil {
+		g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sは何も盗めなかった。", e.Name), Execute: func(g *Game) {}})
+		return
+	}
+
+	index := candidates[localRand.Intn(len(candidates))]
+	item := g.state.Player.Inventory[index]
+	itemName := getItemNameWithSharpness(item)
+	identified := true
+	if identifiableItem, ok := item.(Identifiable); ok {
+		identified = identifiableItem.IsIdentified()
+	}
+	g.state.Player.Inventory = append(g.state.Player.Inventory[:index], g.state.Player.Inventory[index+1:]...)
+	e.StolenItem = item
+	e.State = StateFleeing
+
+	action := Action{
+		Duration:     0.5,
+		Message:      fmt.Sprintf("%sは%sを盗んだ。", e.Name, itemName),
+		ItemName:     itemName,
+		Execute:      func(g *Game) {},
+		IsIdentified: identified,
+	}
+	g.Enqueue(action)
+}
+
+// stealCashAttack steals some of the player's money and makes the enemy flee.
+var stealCashAttack SpecialAttackFunc = func(e *Enemy, g *Game) {
+	if g.state.Player.Cash <= 0 {
+		g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sは何も盗めなかった。", e.Name), Execute: func(g *Game) {}})
+		return
+	}
+
+	amount := min(g.state.Player.Cash, localRand.Intn(500)+100)
+	g.state.Player.Cash -= amount
+	e.StolenCash += amount
+	e.State = StateFleeing
+
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sに%d円盗まれた。", e.Name, amount), Execute: func(g *Game) {}})
+}
+
+// drainLevelAttack lowers the player's level by one.
+var drainLevelAttack SpecialAttackFunc = func(e *Enemy, g *Game) {
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sは海老さんの力を吸い取った。", e.Name), Execute: func(g *Game) {}})
+	if g.state.Player.Level <= 1 {
+		g.Enqueue(Action{Duration: 0.5, Message: "しかし何も起こらなかった。", Execute: func(g *Game) {}})
+		return
+	}
+
+	action := Action{
+		Duration: 0.5,
+		Message:  "海老さんのレベルが下がった。",
+		Execute: func(g *Game) {
+			player := &g.state.Player
+			player.Level--
+			player.ExperiencePoints = levelExpRequirements[player.Level-1] // 下がったレベルの最低経験値にする
+			player.MaxHealth -= 10
+			player.Health = min(player.Health, player.MaxHealth)
+		},
+	}
+	g.Enqueue(action)
+}
+
+// dropStolenGoods drops what the enemy has stolen at its position.
+func (g *Game) dropStolenGoods(e Enemy) {
+	if e.StolenItem != nil {
+		g.placeItem(e.StolenItem, e.X, e.Y)
+	}
+	if e.StolenCash > 0 {
+		moneyItem := &Money{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    e.X,
+					Y:    e.Y,
+					Char: '!',
+				},
+				ID:          0,
+				Type:        "Kane",
+				Name:        "小銭",
+				Description: "小銭。それは海老さんが絆と呼ぶもの。",
+				UseActions: map[string]UseAction{
+					"UseMoney": money,
+				},
+			},
+			Amount:     e.StolenCash,
+			Identified: true,
+		}
+		g.placeItem(moneyItem, e.X, e.Y)
+	}
+}
@@ -0,0 +1,253 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const (
+	bossSummonChance = 0.2 // ボスが手下を呼ぶ確率
+	bossSummonCount  = 2   // 一度に呼ぶ手下の数
+)
+
+// isBossFloor reports whether the current floor was built from the boss map of the
+// dungeon. A boss floor whose map failed to load becomes an ordinary floor instead.
+func (g *Game) isBossFloor() bool {
+	return g.bossFloor
+}
+
+// hasBoss reports whether a unique enemy is on the floor.
+func (g *Game) hasBoss() bool {
+	for _, enemy := range g.state.Enemies {
+		if enemyDefinitions[enemy.ID].Unique {
+			return true
+		}
+	}
+	return false
+}
+
+// BossPhase はボスのHPが減ったときに切り替わる攻撃パターン
+type BossPhase struct {
+	HPRatio     float64 // HPがこの割合以下になるとこのフェーズに移る
+	Message     string  // フェーズが切り替わったときのメッセージ
+	Attack      string  // このフェーズで使う特殊攻撃 (bossAttacksの名前)
+	Probability float64 // 特殊攻撃を使う確率
+	AttackBonus int     // このフェーズで上がる攻撃力
+	Summon      string  // このフェーズで呼び寄せる手下の種類 (空の場合は呼ばない)
+}
+
+// bossBehavior は複数のフェーズを持つボス
+type bossBehavior struct {
+	Phases []BossPhase // HPRatioの大きい順に並べる
+}
+
+// Act switches the boss to the next phase when its HP drops. The boss may call
+// minions instead of acting, otherwise it moves and attacks normally.
+func (b bossBehavior) Act(g *Game, i int) bool {
+	e := &g.state.Enemies[i]
+	ratio := float64(e.Health) / float64(e.MaxHealth)
+	for e.Phase < len(b.Phases) && ratio <= b.Phases[e.Phase].HPRatio {
+		phase := b.Phases[e.Phase]
+		e.SpecialAttack = bossAttacks[phase.Attack]
+		e.SpecialAttackProbability = phase.Probability
+		e.AttackPower += phase.AttackBonus
+		e.Phase++
+		if phase.Message != "" {
+			g.Enqueue(Action{Duration: 1.0, Message: phase.Message, Execute: func(g *Game) {}})
+		}
+	}
+
+	if e.Phase > 0 && e.PlayerDiscovered {
+		phase := b.Phases[e.Phase-1]
+		if phase.Summon != "" && localRand.Float64() < bossSummonChance {
+			g.summonMinions(e, phase.Summon, bossSummonCount)
+			return true
+		}
+	}
+	return false
+}
+
+// bossAttacks はボスのフェーズで使う特殊攻撃。攻撃はDealDamageを通じてenemyDefinitionsを
+// 参照するので、初期化の循環を避けるため定義からは名前で指定する
+var bossAttacks = map[string]SpecialAttackFunc{
+	"ClawCombo": clawComboAttack,
+	"TidalWave": tidalWaveAttack,
+}
+
+// enemyDamage returns the damage of a normal attack of the enemy.
+func (g *Game) enemyDamage(e *Enemy) int {
+	return max(0, e.AttackPower-g.state.Player.DefensePower+localRand.Intn(3)-1)
+}
+
+// clawComboAttack attacks the player twice in a row.
+func clawComboAttack(e *Enemy, g *Game) {
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sの連続攻撃！", e.Name), Execute: func(g *Game) {}})
+	dx, dy := g.state.Player.X-e.X, g.state.Player.Y-e.Y
+	uid := e.UID
+	for hit := 0; hit < 2; hit++ {
+		damage := g.enemyDamage(e)
+		action := Action{
+			Duration: 0.5,
+			Message:  fmt.Sprintf("%sから%dダメージを受けた", e.Name, damage),
+			Execute: func(g *Game) {
+				if i := g.enemyIndexByUID(uid); i >= 0 {
+					g.state.Enemies[i].AttackTimer = 0.5
+					g.state.Enemies[i].AttackDirection = determineDirection(dx, dy)
+				}
+				g.DealDamage(uid, playerUID, damage, DamageMelee)
+			},
+		}
+		g.Enqueue(action)
+	}
+}
+
+// tidalWaveAttack hits the player ignoring defense and rusts the equipment.
+func tidalWaveAttack(e *Enemy, g *Game) {
+	damage := e.AttackPower/2 + localRand.Intn(5)
+	uid := e.UID
+	action := Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("%sは大津波を起こした！%dダメージを受けた", e.Name, damage),
+		Execute: func(g *Game) {
+			g.DealDamage(uid, playerUID, damage, DamageMagic)
+		},
+	}
+	g.Enqueue(action)
+	g.rustEquipment()
+}
+
+// summonMinions calls minions of the given type around the enemy.
+func (g *Game) summonMinions(e *Enemy, minionType string, count int) {
+	id := enemyIDByType(minionType)
+	if id < 0 {
+		return
+	}
+	x, y := e.X, e.Y
+	action := Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("%sは仲間を呼んだ！", e.Name),
+		Execute: func(g *Game) {
+			summoned := 0
+			for dy := -1; dy <= 1 && summoned < count; dy++ {
+				for dx := -1; dx <= 1 && summoned < count; dx++ {
+					if !isPositionFree(g, x+dx, y+dy, -1) || (x+dx == g.state.Player.X && y+dy == g.state.Player.Y) {
+						continue
+					}
+					minion := newEnemy(id, x+dx, y+dy)
+					minion.State = StateHunting
+					minion.PlayerDiscovered = true
+					g.state.Enemies = append(g.state.Enemies, minion)
+					summoned++
+				}
+			}
+		},
+	}
+	g.Enqueue(action)
+}
+
+// generateBossFloor builds the boss floor from its map file.
+// The whole arena is treated as one room.
+func generateBossFloor(width, height int, boss BossFloor, player *Player) ([][]Tile, []Enemy, []Room, error) {
+	data, err := os.ReadFile(boss.MapFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	layout, err := parseArena(string(data))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	arenaWidth, arenaHeight := len(layout.Tiles[0]), len(layout.Tiles)
+	if arenaWidth > width || arenaHeight > height {
+		return nil, nil, nil, fmt.Errorf("arena %dx%d does not fit in the %dx%d map", arenaWidth, arenaHeight, width, height)
+	}
+	bossID := enemyIDByType(boss.BossType)
+	if bossID < 0 {
+		return nil, nil, nil, fmt.Errorf("unknown boss type %q", boss.BossType)
+	}
+
+	mapGrid := make([][]Tile, height)
+	for y := range mapGrid {
+		mapGrid[y] = make([]Tile, width)
+		for x := range mapGrid[y] {
+			mapGrid[y][x] = Tile{Type: "other", Blocked: true, BlockSight: true}
+		}
+	}
+
+	// 地図の中央にボス部屋を置く
+	offsetX, offsetY := (width-arenaWidth)/2, (height-arenaHeight)/2
+	for y, row := range layout.Tiles {
+		for x, tile := range row {
+			mapGrid[y+offsetY][x+offsetX] = tile
+		}
+	}
+
+	room := Room{ID: 0, X: offsetX, Y: offsetY, Width: arenaWidth, Height: arenaHeight}
+	setRoomCenter(&room)
+
+	player.Entity.X = layout.PlayerX + offsetX
+	player.Entity.Y = layout.PlayerY + offsetY
+
+	enemies := []Enemy{newEnemy(bossID, layout.BossX+offsetX, layout.BossY+offsetY)}
+	return mapGrid, enemies, []Room{room}, nil
+}
+
+// checkBossDefeated opens the stairs of the boss floor once every unique enemy is defeated.
+func (g *Game) checkBossDefeated() {
+	if g.bossDefeated || !g.isBossFloor() || g.hasBoss() {
+		return
+	}
+	g.bossDefeated = true
+
+	for y, row := range g.state.Map {
+		for x, tile := range row {
+			if tile.Type == "sealed_stairs" {
+				g.state.Map[y][x] = Tile{Type: "stairs", Blocked: false, BlockSight: false, Visited: tile.Visited}
+				g.miniMapDirty = true
+			}
+		}
+	}
+	g.Enqueue(Action{Duration: 1.0, Message: "ボスを倒した！階段が現れた。", Execute: func(g *Game) {}})
+}
+
+// EndingScene は最深部を踏破した後のエンディング画面
+type EndingScene struct {
+	game *Game
+}
+
+func (s *EndingScene) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		// 次に遊ぶダンジョンを選ぶ。持ち込み可のダンジョンには今の持ち物を持ち込める
+		carried := s.game.state.Player
+		scenes.Switch(newDungeonSelectScene(&carried))
+	}
+	return nil
+}
+
+// Draw draws the ending screen shown after clearing the final floor.
+func (s *EndingScene) Draw(screen *ebiten.Image) {
+	g := s.game
+	screen.Fill(color.Black)
+
+	lines := []string{
+		fmt.Sprintf("海老さんは%sを踏破し、", g.dungeon.Name),
+		"ダンジョンの最深部から生還した！",
+		"",
+		fmt.Sprintf("到達階層: B%dF", g.Floor),
+		fmt.Sprintf("レベル: %d", g.state.Player.Level),
+		fmt.Sprintf("ターン数: %d", g.moveCount),
+		fmt.Sprintf("所持金: %d", g.state.Player.Cash),
+		"",
+		"Zキーでダンジョンを選ぶ",
+	}
+	for i, line := range lines {
+		text.Draw(screen, line, mplusNormalFont, 120, 120+i*30, color.White)
+	}
+}
@@ -0,0 +1,119 @@
+//go:build !test
+// +build !test
+
+package main
+
+import "fmt"
+
+const (
+	playerUID = 0  // 海老さんを表すUID (敵のUIDは1から始まる)
+	noSource  = -1 // 罠などダメージを与えた者がいない場合のUID
+)
+
+// DamageKind は与えるダメージの種類
+type DamageKind int
+
+const (
+	DamageMelee      DamageKind = iota // 直接攻撃
+	DamageProjectile                   // 投げた物や飛び道具
+	DamageMagic                        // カードや杖、特殊攻撃の効果
+	DamageTrap                         // 罠
+	DamageTerrain                      // 溶岩などの地形
+)
+
+// CombatEventType は戦闘で起きた出来事の種類
+type CombatEventType int
+
+const (
+	EventDamaged CombatEventType = iota // ダメージを受けた
+	EventKilled                         // 倒された
+)
+
+// CombatEvent は DealDamage が知らせる戦闘の出来事
+type CombatEvent struct {
+	Type   CombatEventType
+	Source int // ダメージを与えた者のUID
+	Target int // ダメージを受けた者のUID
+	Amount int
+	Kind   DamageKind
+	Enemy  Enemy // ダメージを受けた敵 (海老さんの場合はゼロ値)
+}
+
+// CombatListener は戦闘の出来事を受け取る
+type CombatListener func(g *Game, ev CombatEvent)
+
+// enemyIndexByUID returns the current index of the enemy with the UID, or -1 if it is gone.
+func (g *Game) enemyIndexByUID(uid int) int {
+	return g.state.enemyIndexByUID(uid)
+}
+
+// AddCombatListener registers a listener that is told about every damage and death.
+func (g *Game) AddCombatListener(listener CombatListener) {
+	g.combatListeners = append(g.combatListeners, listener)
+}
+
+func (g *Game) emitCombatEvent(ev CombatEvent) {
+	for _, listener := range g.combatListeners {
+		listener(g, ev)
+	}
+}
+
+// DealDamage is the single place where damage is applied. It handles the death
+// of the target, its drops, the experience points and the level-ups.
+// It returns true if the target was defeated.
+func (g *Game) DealDamage(source, target, amount int, kind DamageKind) bool {
+	amount = max(0, amount)
+
+	if target == playerUID {
+		player := &g.state.Player
+		wasAlive := player.Health > 0
+		player.Health = max(0, player.Health-amount)
+		g.emitCombatEvent(CombatEvent{Type: EventDamaged, Source: source, Target: target, Amount: amount, Kind: kind})
+		if !wasAlive || player.Health > 0 {
+			return false
+		}
+		g.emitCombatEvent(CombatEvent{Type: EventKilled, Source: source, Target: target, Amount: amount, Kind: kind})
+		if source != playerUID && source != noSource {
+			g.levelUpEnemy(g.enemyIndexByUID(source)) // 相手を倒した敵はレベルが上がる
+		}
+		return true
+	}
+
+	// 倒れた敵は配列から削除され、海老さんが倒したなら経験値が入る
+	enemy, found, defeated := g.state.damageEnemy(target, amount, source == playerUID)
+	if !found {
+		return false // すでに倒されている
+	}
+	g.emitCombatEvent(CombatEvent{Type: EventDamaged, Source: source, Target: target, Amount: amount, Kind: kind, Enemy: enemy})
+	if !defeated {
+		return false
+	}
+
+	message := fmt.Sprintf("%sは倒れた。", enemy.Name)
+	if source == playerUID {
+		message = fmt.Sprintf("%sを倒した。", enemy.Name)
+	}
+	g.Enqueue(Action{Duration: 0.5, Message: message, Execute: func(g *Game) {}})
+
+	g.dropEnemyLoot(enemy)
+	g.emitCombatEvent(CombatEvent{Type: EventKilled, Source: source, Target: target, Amount: amount, Kind: kind, Enemy: enemy})
+
+	switch source {
+	case playerUID:
+		g.state.Player.checkLevelUp() // レベルアップをチェック
+	case noSource:
+	default:
+		g.levelUpEnemy(g.enemyIndexByUID(source)) // 相手を倒した敵はレベルが上がる
+	}
+	return true
+}
+
+// wakeOnDamage wakes up the enemy that is hurt.
+func wakeOnDamage(g *Game, ev CombatEvent) {
+	if ev.Type != EventDamaged || ev.Target == playerUID {
+		return
+	}
+	if i := g.enemyIndexByUID(ev.Target); i >= 0 && g.state.Enemies[i].State == StateSleeping {
+		g.state.Enemies[i].State = StateHunting
+	}
+}
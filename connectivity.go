@@ -0,0 +1,147 @@
+package main
+
+import "fmt"
+
+const (
+	maxRepairLength       = 30 // つながっていない場所に掘る通路の長さの上限。これより長くなるフロアは作り直す
+	maxGenerationAttempts = 5  // フロアを作り直す回数の上限
+)
+
+// FloorValidation は validateFloor の結果
+type FloorValidation int
+
+const (
+	FloorConnected FloorValidation = iota // 最初から全ての場所に行けた
+	FloorRepaired                         // 通路を掘って行けるようにした
+	FloorBroken                           // 直せなかった (作り直す必要がある)
+)
+
+// GenerationStats はフロア生成の統計
+type GenerationStats struct {
+	Floors      int // 生成したフロアの数
+	Repaired    int // 通路を掘って直したフロアの数
+	Regenerated int // 直せずに作り直した回数
+	Failed      int // 作り直しても直らず、大部屋に切り替えたフロアの数
+	DugTiles    int // 直すために掘ったタイルの数
+}
+
+var generationStats GenerationStats
+
+// Record adds a floor that has been accepted to the statistics.
+func (s *GenerationStats) Record(result FloorValidation) {
+	s.Floors++
+	switch result {
+	case FloorRepaired:
+		s.Repaired++
+	}
+}
+
+// String summarizes the statistics in one line for the log.
+func (s GenerationStats) String() string {
+	return fmt.Sprintf("floors %d, repaired %d, regenerated %d, failed %d, dug %d", s.Floors, s.Repaired, s.Regenerated, s.Failed, s.DugTiles)
+}
+
+// floodFill returns the tiles that can be walked to from start, moving up, down, left and right.
+func floodFill(tiles [][]Tile, start Coordinate) [][]bool {
+	return floodFillBy(tiles, start, isPassable)
+}
+
+// floodFillBy is floodFill with the rule of which tiles can be walked on.
+func floodFillBy(tiles [][]Tile, start Coordinate, passable func(Tile) bool) [][]bool {
+	reached := make([][]bool, len(tiles))
+	for y := range tiles {
+		reached[y] = make([]bool, len(tiles[y]))
+	}
+	canWalk := func(x, y int) bool {
+		return y >= 0 && y < len(tiles) && x >= 0 && x < len(tiles[y]) && passable(tiles[y][x])
+	}
+	if !canWalk(start.X, start.Y) {
+		return reached
+	}
+	reached[start.Y][start.X] = true
+	queue := []Coordinate{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range []Coordinate{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			x, y := p.X+d.X, p.Y+d.Y
+			if canWalk(x, y) && !reached[y][x] {
+				reached[y][x] = true
+				queue = append(queue, Coordinate{X: x, Y: y})
+			}
+		}
+	}
+	return reached
+}
+
+// isPassable reports whether the tile can be walked through. 扉は開けて通れる
+func isPassable(t Tile) bool {
+	return !t.Blocked || isClosedDoor(t)
+}
+
+func isWalkable(tiles [][]Tile, x, y int) bool {
+	return y >= 0 && y < len(tiles) && x >= 0 && x < len(tiles[y]) && isPassable(tiles[y][x])
+}
+
+// validateFloor checks that every target (the stairs, the rooms...) can be reached from
+// start, and digs corridors to the ones that cannot. It returns the result and the
+// number of tiles dug. 直せない場合もタイルは掘られたままなので、フロアは作り直すこと
+func validateFloor(tiles [][]Tile, start Coordinate, targets []Coordinate) (FloorValidation, int) {
+	if !isWalkable(tiles, start.X, start.Y) {
+		return FloorBroken, 0
+	}
+	reached := floodFill(tiles, start)
+	result, dug := FloorConnected, 0
+	for _, target := range targets {
+		if target.Y < 0 || target.Y >= len(tiles) || target.X < 0 || target.X >= len(tiles[target.Y]) {
+			return FloorBroken, dug
+		}
+		if reached[target.Y][target.X] {
+			continue
+		}
+		// 一番近い行ける場所までL字の通路を掘る
+		nearest, ok := nearestReached(reached, target)
+		if !ok || abs(nearest.X-target.X)+abs(nearest.Y-target.Y) > maxRepairLength {
+			return FloorBroken, dug
+		}
+		dug += digCorridor(tiles, target, nearest)
+		reached = floodFill(tiles, start)
+		result = FloorRepaired
+	}
+	return result, dug
+}
+
+// nearestReached returns the reached tile closest to p.
+func nearestReached(reached [][]bool, p Coordinate) (Coordinate, bool) {
+	best, bestDistance := Coordinate{}, -1
+	for y := range reached {
+		for x := range reached[y] {
+			if !reached[y][x] {
+				continue
+			}
+			if d := abs(x-p.X) + abs(y-p.Y); bestDistance < 0 || d < bestDistance {
+				best, bestDistance = Coordinate{X: x, Y: y}, d
+			}
+		}
+	}
+	return best, bestDistance >= 0
+}
+
+// digCorridor turns the blocked tiles on the L-shaped path from a to b into corridor.
+// It returns the number of tiles dug.
+func digCorridor(tiles [][]Tile, a, b Coordinate) int {
+	dug := 0
+	dig := func(x, y int) {
+		if tiles[y][x].Blocked {
+			tiles[y][x] = Tile{Type: "corridor", Blocked: false, BlockSight: false}
+			dug++
+		}
+	}
+	for x := min(a.X, b.X); x <= max(a.X, b.X); x++ {
+		dig(x, a.Y)
+	}
+	for y := min(a.Y, b.Y); y <= max(a.Y, b.Y); y++ {
+		dig(b.X, y)
+	}
+	return dug
+}
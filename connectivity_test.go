@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// tilesFromRows builds a map from rows of '#' (wall), '.' (floor) and ' ' (rock).
+func tilesFromRows(rows ...string) [][]Tile {
+	tiles := make([][]Tile, len(rows))
+	for y, row := range rows {
+		tiles[y] = make([]Tile, len(row))
+		for x, c := range row {
+			switch c {
+			case '.':
+				tiles[y][x] = Tile{Type: "floor"}
+			case '#':
+				tiles[y][x] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+			default:
+				tiles[y][x] = Tile{Type: "other", Blocked: true, BlockSight: true}
+			}
+		}
+	}
+	return tiles
+}
+
+func TestValidateFloorConnected(t *testing.T) {
+	tiles := tilesFromRows(
+		"#########",
+		"#...#...#",
+		"#.......#",
+		"#########",
+	)
+	result, dug := validateFloor(tiles, Coordinate{X: 1, Y: 1}, []Coordinate{{X: 7, Y: 1}})
+	if result != FloorConnected || dug != 0 {
+		t.Errorf("validateFloor = (%v, %d), want (FloorConnected, 0)", result, dug)
+	}
+}
+
+func TestValidateFloorRepair(t *testing.T) {
+	tiles := tilesFromRows(
+		"#####  #####",
+		"#...#  #...#",
+		"#...#  #...#",
+		"#####  #####",
+	)
+	start, stairs := Coordinate{X: 1, Y: 1}, Coordinate{X: 10, Y: 2}
+	result, dug := validateFloor(tiles, start, []Coordinate{stairs})
+	if result != FloorRepaired || dug == 0 {
+		t.Fatalf("validateFloor = (%v, %d), want FloorRepaired with dug tiles", result, dug)
+	}
+	if !floodFill(tiles, start)[stairs.Y][stairs.X] {
+		t.Errorf("stairs are still unreachable after repair")
+	}
+}
+
+func TestValidateFloorBroken(t *testing.T) {
+	tiles := tilesFromRows(
+		"#.#                                      #.#",
+	)
+	// 通路が長すぎる場合は直さずに作り直す
+	if result, _ := validateFloor(tiles, Coordinate{X: 1, Y: 0}, []Coordinate{{X: 42, Y: 0}}); result != FloorBroken {
+		t.Errorf("long repair: result = %v, want FloorBroken", result)
+	}
+	// 開始位置が壁の中
+	if result, _ := validateFloor(tiles, Coordinate{X: 0, Y: 0}, nil); result != FloorBroken {
+		t.Errorf("blocked start: result = %v, want FloorBroken", result)
+	}
+	// 地図の外
+	if result, _ := validateFloor(tiles, Coordinate{X: 1, Y: 0}, []Coordinate{{X: 99, Y: 0}}); result != FloorBroken {
+		t.Errorf("target outside: result = %v, want FloorBroken", result)
+	}
+}
+
+// TestValidateFloorFuzz checks on many random maps that every target is reachable
+// whenever validateFloor does not report the floor as broken.
+func TestValidateFloorFuzz(t *testing.T) {
+	const width, height = 40, 30
+	for seed := int64(0); seed < 3000; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		tiles := make([][]Tile, height)
+		for y := range tiles {
+			tiles[y] = make([]Tile, width)
+			for x := range tiles[y] {
+				if r.Float64() < 0.55 {
+					tiles[y][x] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+				} else {
+					tiles[y][x] = Tile{Type: "floor"}
+				}
+			}
+		}
+		start := Coordinate{X: r.Intn(width), Y: r.Intn(height)}
+		tiles[start.Y][start.X] = Tile{Type: "floor"}
+		var targets []Coordinate
+		for i := 0; i < 5; i++ {
+			target := Coordinate{X: r.Intn(width), Y: r.Intn(height)}
+			tiles[target.Y][target.X] = Tile{Type: "floor"}
+			targets = append(targets, target)
+		}
+
+		result, _ := validateFloor(tiles, start, targets)
+		if result == FloorBroken {
+			continue
+		}
+		reached := floodFill(tiles, start)
+		for _, target := range targets {
+			if !reached[target.Y][target.X] {
+				t.Fatalf("seed %d: target %v unreachable after validateFloor returned %v", seed, target, result)
+			}
+		}
+	}
+}
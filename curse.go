@@ -0,0 +1,214 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	curseStatPenalty = 3 // 呪われた武器・防具の攻撃力/防御力の減少量
+	blessStatBonus   = 1 // 祝福された武器・防具の攻撃力/防御力の増加量
+)
+
+// curseStatModifier returns the stat change caused by the curse/blessing of a weapon or armor.
+func curseStatModifier(c Cursable) int {
+	if c.IsCursed() {
+		return -curseStatPenalty
+	}
+	if c.IsBlessed() {
+		return blessStatBonus
+	}
+	return 0
+}
+
+// isCursedItem reports whether the item is cursed.
+func isCursedItem(item Item) bool {
+	if cursable, ok := item.(Cursable); ok {
+		return cursable.IsCursed()
+	}
+	return false
+}
+
+// isCursedEquipped reports whether the item is equipped and cursed, i.e. it cannot be removed.
+func (g *Game) isCursedEquipped(item Item) bool {
+	equipableItem, ok := item.(Equipable)
+	if !ok {
+		return false
+	}
+	return isEquipped(g.state.Player.EquippedItems[:], equipableItem) && isCursedItem(item)
+}
+
+// updateEquippedItem applies change to the item while keeping the player stats consistent.
+// 装備中のアイテムの場合は一度能力変化を外してから変更し、再度適用する
+func (g *Game) updateEquippedItem(item Item, change func()) {
+	equipableItem, ok := item.(Equipable)
+	if !ok || !isEquipped(g.state.Player.EquippedItems[:], equipableItem) {
+		change()
+		return
+	}
+	equipableItem.UpdatePlayerStats(&g.state.Player, false)
+	change()
+	equipableItem.UpdatePlayerStats(&g.state.Player, true)
+}
+
+// uncurseItem removes the curse from the item. It returns true if the item was cursed.
+func (g *Game) uncurseItem(item Item) bool {
+	cursable, ok := item.(Cursable)
+	if !ok || !cursable.IsCursed() {
+		return false
+	}
+	g.updateEquippedItem(item, func() {
+		cursable.SetCursed(false)
+	})
+	return true
+}
+
+// curseItem curses the item. Blessed items lose their blessing instead.
+func (g *Game) curseItem(item Item) {
+	cursable, ok := item.(Cursable)
+	if !ok {
+		return
+	}
+	g.updateEquippedItem(item, func() {
+		if cursable.IsBlessed() {
+			cursable.SetBlessed(false)
+		} else {
+			cursable.SetCursed(true)
+		}
+	})
+}
+
+var removeCurse = func(g *Game) {
+	item, isInventoryItem := determineItemSource(g)
+	action := Action{
+		Duration: 0.4,
+		Message:  fmt.Sprintf("%sを使った。", item.GetName()),
+		Execute:  func(g *Game) {},
+	}
+	g.Enqueue(action)
+
+	removeUsedItem(g, isInventoryItem)
+
+	action = Action{
+		Duration: 0.5,
+		Message:  "持ち物の呪いが解けた。",
+		Execute: func(g *Game) {
+			for _, inventoryItem := range g.state.Player.Inventory {
+				g.uncurseItem(inventoryItem)
+			}
+		},
+	}
+	g.Enqueue(action)
+}
+
+var detectCurse = func(g *Game) {
+	item, isInventoryItem := determineItemSource(g)
+	action := Action{
+		Duration: 0.4,
+		Message:  fmt.Sprintf("%sを使った。", item.GetName()),
+		Execute:  func(g *Game) {},
+	}
+	g.Enqueue(action)
+
+	removeUsedItem(g, isInventoryItem)
+
+	cursed, blessed := detectCurses(cursableItems(g.state.Player.Inventory))
+	action = Action{
+		Duration: 0.5,
+		Message:  curseDetectionMessage(cursed, blessed),
+		Execute:  func(g *Game) {},
+	}
+	g.Enqueue(action)
+}
+
+// curseRandomItem curses one random cursable item in the player's inventory.
+func (g *Game) curseRandomItem() {
+	var candidates []Item
+	for _, item := range g.state.Player.Inventory {
+		if cursable, ok := item.(Cursable); ok && !cursable.IsCursed() {
+			candidates = append(candidates, item)
+		}
+	}
+
+	if len(candidates) == 0 {
+		action := Action{
+			Duration: 0.5,
+			Message:  "しかし何も起こらなかった。",
+			Execute:  func(g *Game) {},
+		}
+		g.Enqueue(action)
+		return
+	}
+
+	target := candidates[localRand.Intn(len(candidates))]
+	itemName := getItemNameWithSharpness(target)
+	identified := true
+	if identifiableItem, ok := target.(Identifiable); ok {
+		identified = identifiableItem.IsIdentified()
+	}
+	action := Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("%sが呪われた。", itemName),
+		ItemName: itemName,
+		Execute: func(g *Game) {
+			g.curseItem(target)
+		},
+		IsIdentified: identified,
+	}
+	g.Enqueue(action)
+}
+
+// curseAttack is the special attack of enemies that spread curses.
+var curseAttack SpecialAttackFunc = func(e *Enemy, g *Game) {
+	action := Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("%sの呪い攻撃。", e.Name),
+		Execute:  func(g *Game) {},
+	}
+	g.Enqueue(action)
+	g.curseRandomItem()
+}
+
+// checkForShrine uncurses the equipped items when the player steps on a shrine.
+// 祠は一度使うと消える
+func (g *Game) checkForShrine() {
+	player := &g.state.Player
+	if g.state.Map[player.Y][player.X].Type != "shrine" {
+		return
+	}
+	g.state.Map[player.Y][player.X] = Tile{Type: "floor", Blocked: false, BlockSight: false, Visited: true}
+
+	action := Action{
+		Duration: 0.5,
+		Message:  "祠の光に包まれた。",
+		Execute:  func(g *Game) {},
+	}
+	g.Enqueue(action)
+
+	action = Action{
+		Duration: 0.5,
+		Message:  "装備品の呪いが解けた。",
+		Execute: func(g *Game) {
+			for _, equippedItem := range g.state.Player.EquippedItems {
+				if equippedItem != nil {
+					g.uncurseItem(equippedItem)
+				}
+			}
+		},
+	}
+	g.Enqueue(action)
+}
+
+// placeShrine places a shrine on a random free floor tile with the given probability.
+func placeShrine(mapGrid [][]Tile, spawns []Coordinate, probability float64) {
+	if len(spawns) == 0 || localRand.Float64() >= probability {
+		return
+	}
+	spawn := spawns[localRand.Intn(len(spawns))]
+	x, y := spawn.X, spawn.Y
+	if mapGrid[y][x].Type == "floor" {
+		mapGrid[y][x] = Tile{Type: "shrine", Blocked: false, BlockSight: false}
+	}
+}
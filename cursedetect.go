@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// Cursable is implemented by items that can be cursed or blessed
+type Cursable interface {
+	IsCursed() bool
+	SetCursed(value bool)
+	IsBlessed() bool
+	SetBlessed(value bool)
+	IsCurseKnown() bool // 識別していなくても呪い・祝福がわかっているかどうか
+	SetCurseKnown(value bool)
+}
+
+// curseMark returns the suffix shown after the name of a cursed or blessed item whose
+// state is known.
+func curseMark(c Cursable) string {
+	if c.IsCursed() {
+		return "(呪)"
+	}
+	if c.IsBlessed() {
+		return "(祝)"
+	}
+	return ""
+}
+
+// cursableItems returns the items that can be cursed or blessed.
+func cursableItems(items []Item) []Cursable {
+	var cursables []Cursable
+	for _, item := range items {
+		if cursable, ok := item.(Cursable); ok {
+			cursables = append(cursables, cursable)
+		}
+	}
+	return cursables
+}
+
+// detectCurses reveals the curse and blessing of the items without identifying them,
+// and returns how many cursed and blessed items were found.
+func detectCurses(items []Cursable) (cursed, blessed int) {
+	for _, cursable := range items {
+		cursable.SetCurseKnown(true)
+		if cursable.IsCursed() {
+			cursed++
+		} else if cursable.IsBlessed() {
+			blessed++
+		}
+	}
+	return cursed, blessed
+}
+
+// curseDetectionMessage returns the message shown after detecting curses.
+func curseDetectionMessage(cursed, blessed int) string {
+	switch {
+	case cursed == 0 && blessed == 0:
+		return "呪われた持ち物も祝福された持ち物もなかった。"
+	case blessed == 0:
+		return fmt.Sprintf("呪われた持ち物が%d個見つかった。", cursed)
+	case cursed == 0:
+		return fmt.Sprintf("祝福された持ち物が%d個見つかった。", blessed)
+	}
+	return fmt.Sprintf("呪われた持ち物が%d個、祝福された持ち物が%d個見つかった。", cursed, blessed)
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+type fakeCursable struct {
+	cursed, blessed, known bool
+}
+
+func (f *fakeCursable) IsCursed() bool           { return f.cursed }
+func (f *fakeCursable) SetCursed(value bool)     { f.cursed = value }
+func (f *fakeCursable) IsBlessed() bool          { return f.blessed }
+func (f *fakeCursable) SetBlessed(value bool)    { f.blessed = value }
+func (f *fakeCursable) IsCurseKnown() bool       { return f.known }
+func (f *fakeCursable) SetCurseKnown(value bool) { f.known = value }
+
+func TestDetectCurses(t *testing.T) {
+	cursedItem := &fakeCursable{cursed: true}
+	blessedItem := &fakeCursable{blessed: true}
+	plainItem := &fakeCursable{}
+	items := []Cursable{cursedItem, blessedItem, plainItem}
+
+	cursed, blessed := detectCurses(items)
+	if cursed != 1 || blessed != 1 {
+		t.Errorf("detectCurses = %d cursed, %d blessed, want 1 and 1", cursed, blessed)
+	}
+	for _, c := range []*fakeCursable{cursedItem, blessedItem, plainItem} {
+		if !c.known {
+			t.Errorf("%+v was not revealed", c)
+		}
+	}
+	if curseMark(cursedItem) != "(呪)" || curseMark(blessedItem) != "(祝)" || curseMark(plainItem) != "" {
+		t.Errorf("curse marks are %q, %q, %q", curseMark(cursedItem), curseMark(blessedItem), curseMark(plainItem))
+	}
+	if msg := curseDetectionMessage(0, 0); msg != "呪われた持ち物も祝福された持ち物もなかった。" {
+		t.Errorf("curseDetectionMessage(0, 0) = %q", msg)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+// 扉のタイルの種類
+const (
+	doorClosed = "door"        // 閉じた扉。通れず、向こうも見えない
+	doorOpen   = "open_door"   // 開いた扉
+	doorLocked = "locked_door" // 鍵のかかった扉。鍵を使わないと開かない
+)
+
+// doorTile returns the tile of a door of the given kind.
+func doorTile(kind string) Tile {
+	closed := kind != doorOpen
+	return Tile{Type: kind, Blocked: closed, BlockSight: closed}
+}
+
+// doorResult は扉に手をかけたときに起きたこと
+type doorResult int
+
+const (
+	doorNoChange    doorResult = iota // 扉ではないか、何も起きない
+	doorOpened                        // 閉じた扉を開けた
+	doorUnlocked                      // 鍵を使って鍵のかかった扉を開けた
+	doorStillLocked                   // 鍵がなくて開けられなかった
+	doorShut                          // 開いた扉を閉めた
+)
+
+// doorTransition returns the kind a door of the given kind becomes when the player
+// tries it, and what happened. When closing, only an open door changes; otherwise a
+// closed door opens and a locked door opens only with a key.
+func doorTransition(kind string, hasKey, closing bool) (string, doorResult) {
+	if closing {
+		if kind == doorOpen {
+			return doorClosed, doorShut
+		}
+		return kind, doorNoChange
+	}
+	switch kind {
+	case doorClosed:
+		return doorOpen, doorOpened
+	case doorLocked:
+		if hasKey {
+			return doorOpen, doorUnlocked
+		}
+		return doorLocked, doorStillLocked
+	}
+	return kind, doorNoChange
+}
+
+// isClosedDoor reports whether the tile is a door that is closed or locked.
+func isClosedDoor(t Tile) bool {
+	return t.Type == doorClosed || t.Type == doorLocked
+}
+
+// isDoorway reports whether the tile at (x, y) is a one-tile opening in a wall:
+// passable, with blocked tiles on both sides and open tiles in front and behind.
+// 部屋の壁に通路がつながっている場所が扉を置ける場所になる
+func isDoorway(tiles [][]Tile, x, y int) bool {
+	if !isWalkable(tiles, x, y) {
+		return false
+	}
+	horizontalWall := !isWalkable(tiles, x-1, y) && !isWalkable(tiles, x+1, y) && isWalkable(tiles, x, y-1) && isWalkable(tiles, x, y+1)
+	verticalWall := !isWalkable(tiles, x, y-1) && !isWalkable(tiles, x, y+1) && isWalkable(tiles, x-1, y) && isWalkable(tiles, x+1, y)
+	return horizontalWall || verticalWall
+}
+
+// reachableWithoutKey returns the candidates that can be reached from start
+// without going through a locked door. 鍵はこの中に置けば必ず拾える
+func reachableWithoutKey(tiles [][]Tile, start Coordinate, candidates []Coordinate) []Coordinate {
+	reached := floodFillBy(tiles, start, func(t Tile) bool {
+		return t.Type != doorLocked && isPassable(t)
+	})
+	var reachable []Coordinate
+	for _, c := range candidates {
+		if c.Y >= 0 && c.Y < len(reached) && c.X >= 0 && c.X < len(reached[c.Y]) && reached[c.Y][c.X] {
+			reachable = append(reachable, c)
+		}
+	}
+	return reachable
+}
+
+const (
+	doorChance       = 0.3  // 部屋の入口に扉を置く確率
+	lockedDoorChance = 0.15 // 置いた扉に鍵がかかっている確率
+	maxLockedDoors   = 1    // フロアに置く鍵のかかった扉の上限
+)
+
+// placeDoor puts a door of the given kind at (x, y), keeping whether the tile has been seen.
+func placeDoor(mapGrid [][]Tile, x, y int, kind string) {
+	tile := doorTile(kind)
+	tile.Visited, tile.Brightness = mapGrid[y][x].Visited, mapGrid[y][x].Brightness
+	mapGrid[y][x] = tile
+}
+
+// placeDoors puts doors on some of the entrances of the rooms.
+// It returns the number of locked doors on the floor, including those of the prefabs.
+func placeDoors(mapGrid [][]Tile, rooms []Room) int {
+	locked := 0
+	for _, row := range mapGrid {
+		for _, tile := range row {
+			if tile.Type == doorLocked {
+				locked++ // 部屋の型の扉
+			}
+		}
+	}
+	for _, room := range rooms {
+		for y := room.Y; y < room.Y+room.Height; y++ {
+			for x := room.X; x < room.X+room.Width; x++ {
+				if !isOnBoundary(x, y, room) || mapGrid[y][x].Type != "corridor" || !isDoorway(mapGrid, x, y) || localRand.Float64() >= doorChance {
+					continue
+				}
+				kind := doorClosed
+				if locked < maxLockedDoors && localRand.Float64() < lockedDoorChance {
+					kind = doorLocked
+					locked++
+				}
+				placeDoor(mapGrid, x, y, kind)
+			}
+		}
+	}
+	return locked
+}
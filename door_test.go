@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestIsDoorway(t *testing.T) {
+	tiles := tilesFromRows(
+		"#####",
+		"#...#",
+		"##.##",
+		"#...#",
+		"#####",
+	)
+	if !isDoorway(tiles, 2, 2) {
+		t.Errorf("isDoorway(2, 2) = false, want true")
+	}
+	if isDoorway(tiles, 2, 1) {
+		t.Errorf("isDoorway(2, 1) = true, want false for a room tile")
+	}
+	if isDoorway(tiles, 0, 2) {
+		t.Errorf("isDoorway(0, 2) = true, want false for a wall")
+	}
+}
+
+func TestReachableWithoutKey(t *testing.T) {
+	tiles := tilesFromRows(
+		"#######",
+		"#.....#",
+		"#######",
+	)
+	tiles[1][3] = doorTile(doorLocked)
+	candidates := []Coordinate{{X: 2, Y: 1}, {X: 5, Y: 1}}
+	got := reachableWithoutKey(tiles, Coordinate{X: 1, Y: 1}, candidates)
+	if len(got) != 1 || got[0] != candidates[0] {
+		t.Errorf("reachableWithoutKey = %v, want only %v", got, candidates[0])
+	}
+	// 閉じた扉は開けて通れる
+	tiles[1][3] = doorTile(doorClosed)
+	if got := reachableWithoutKey(tiles, Coordinate{X: 1, Y: 1}, candidates); len(got) != 2 {
+		t.Errorf("reachableWithoutKey through a closed door = %v, want both", got)
+	}
+}
+
+func TestDoorTransition(t *testing.T) {
+	tests := []struct {
+		kind            string
+		hasKey, closing bool
+		wantKind        string
+		wantResult      doorResult
+	}{
+		{doorClosed, false, false, doorOpen, doorOpened},
+		{doorLocked, false, false, doorLocked, doorStillLocked},
+		{doorLocked, true, false, doorOpen, doorUnlocked},
+		{doorOpen, true, false, doorOpen, doorNoChange},
+		{doorOpen, false, true, doorClosed, doorShut},
+		{doorLocked, true, true, doorLocked, doorNoChange},
+		{"floor", true, false, "floor", doorNoChange},
+	}
+	for _, tt := range tests {
+		kind, result := doorTransition(tt.kind, tt.hasKey, tt.closing)
+		if kind != tt.wantKind || result != tt.wantResult {
+			t.Errorf("doorTransition(%q, %v, %v) = %q, %d; want %q, %d", tt.kind, tt.hasKey, tt.closing, kind, result, tt.wantKind, tt.wantResult)
+		}
+	}
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/font"
-	"golang.org/x/image/math/fixed"
 )
 
 var (
@@ -44,15 +43,17 @@ func (g *Game) drawOverlay(screen *ebiten.Image) {
 }
 
 func (g *Game) DrawStairsPrompt(screen *ebiten.Image) {
-	if g.showStairsPrompt && !g.fadingOut && !g.fadingIn {
-		windowX, windowY, windowWidth, windowHeight := 100, 100, 200, 50 // Adjust these values as needed
+	if g.overlays.Has(OverlayStairsPrompt) && !g.fadingOut && !g.fadingIn {
+		// 行き先を選択肢に書く (例: "B6Fへ降りる", "脇道 B6Fへ", "B4Fへ戻る")
+		options := []string{g.stairsLabel(), "やめる"}
+		optionWidth := text.BoundString(mplusNormalFont, options[0]).Dx() + 40
+		windowX, windowY, windowWidth, windowHeight := 100, 100, optionWidth+100, 50 // Adjust these values as needed
 		drawWindowWithBorder(screen, windowX, windowY, windowWidth, windowHeight, 255)
-		options := []string{"進む", "やめる"}
 		for i, option := range options {
-			text.Draw(screen, option, mplusNormalFont, windowX+i*100+20, windowY+25, color.White) // Adjust these values as needed
+			text.Draw(screen, option, mplusNormalFont, windowX+i*optionWidth+20, windowY+25, color.White) // Adjust these values as needed
 		}
-		cursorX := windowX + g.selectedOption*100 // Adjust these values as needed
-		cursorY := windowY + 25                   // Adjust these values as needed
+		cursorX := windowX + g.selectedOption*optionWidth // Adjust these values as needed
+		cursorY := windowY + 25                           // Adjust these values as needed
 		text.Draw(screen, "→", mplusNormalFont, cursorX, cursorY, color.White)
 	}
 }
@@ -109,8 +110,8 @@ func (g *Game) updateMiniMap(screen *ebiten.Image) {
 				opts := &ebiten.DrawImageOptions{}
 				opts.GeoM.Translate(float64(x*tilePixelSize), float64(y*tilePixelSize))
 
-				// tile.Typeが"stairs"であるかどうかをチェック
-				if tile.Type == "stairs" {
+				// 階段 (上り・脇道への階段も) かどうかをチェック
+				if isStairs(tile) {
 					// 階段タイル用のボーダーのイメージを作成
 					stairsTile := ebiten.NewImage(tilePixelSize, tilePixelSize)
 					//borderSize := 1 // ボーダーの幅
@@ -254,23 +255,15 @@ func (g *Game) CalculateEnemyOffset(enemy *Enemy) (int, int) {
 	return offsetX, offsetY
 }
 
-func (g *Game) ManageDescriptions() {
-
-	if len(g.ActionQueue.Queue) > 0 {
-		action := g.ActionQueue.Queue[0]
-
-		if action.Message != "" {
-			g.descriptionText = action.Message
-			g.showDescription = true
-		}
-
-	} else {
-		g.showDescription = false
-	}
+// messageVisible reports whether the message window is shown: while the action at the
+// head of the queue has a message, unless the inventory is open.
+func (g *Game) messageVisible() bool {
+	queue := g.ActionQueue.Queue
+	return len(queue) > 0 && queue[0].Message != "" && !g.overlays.Has(OverlayInventory)
 }
 
 func (g *Game) DrawDescriptions(screen *ebiten.Image) {
-	if g.showDescription {
+	if g.messageVisible() {
 		screenWidth, screenHeight := screen.Bounds().Dx(), screen.Bounds().Dy()
 		descriptionWindowWidth, descriptionWindowHeight := 500, 120
 		windowX, windowY := (screenWidth-descriptionWindowWidth)/2, screenHeight-descriptionWindowHeight-10
@@ -278,10 +271,7 @@ func (g *Game) DrawDescriptions(screen *ebiten.Image) {
 		drawWindowWithBorder(screen, windowX, windowY, descriptionWindowWidth, descriptionWindowHeight, 127)
 
 		// アクションを取得
-		var action Action
-		if len(g.ActionQueue.Queue) > 0 {
-			action = g.ActionQueue.Queue[0]
-		}
+		action := g.ActionQueue.Queue[0]
 
 		// 描画するテキストの基本位置
 		x := windowX + 10
@@ -365,7 +355,7 @@ func drawWindowWithBorder(screen *ebiten.Image, windowX, windowY, windowWidth, w
 }
 
 func (g *Game) drawItemDescription(screen *ebiten.Image) {
-	if g.showItemDescription {
+	if g.overlays.Has(OverlayItemDescription) {
 		// Define menu window parameters
 		screenWidth, screenHeight := screen.Bounds().Dx(), screen.Bounds().Dy()
 		descriptionWindowWidth, descriptionWindowHeight := 500, 120
@@ -379,7 +369,7 @@ func (g *Game) drawItemDescription(screen *ebiten.Image) {
 }
 
 func (g *Game) DrawGroundItem(screen *ebiten.Image) {
-	if g.ShowGroundItem {
+	if g.overlays.Has(OverlayGroundItem) {
 		screenWidth, screenHeight := screen.Bounds().Dx(), screen.Bounds().Dy()
 		itemWindowWidth, itemWindowHeight := 400, 26
 		itemwindowX, itemwindowY := (screenWidth-itemWindowWidth)/2, (screenHeight-itemWindowHeight)/2
@@ -443,7 +433,7 @@ func (g *Game) DrawGroundItem(screen *ebiten.Image) {
 }
 
 func (g *Game) drawActionMenu(screen *ebiten.Image) {
-	if g.showItemActions {
+	if g.overlays.Has(OverlayItemActions) {
 		// Define menu window parameters
 		menuWidth, menuHeight := 200, 100
 		menuX, menuY := (screen.Bounds().Dx()-menuWidth)/2, (screen.Bounds().Dy()-menuHeight)/2
@@ -485,6 +475,32 @@ func (g *Game) drawUseIdentifyItemWindow(screen *ebiten.Image) {
 	text.Draw(screen, "どれを？", mplusNormalFont, windowX+10, windowY+20, color.White)
 }
 
+func (g *Game) drawEquipmentWindow(screen *ebiten.Image) {
+	screenWidth, screenHeight := screen.Bounds().Dx(), screen.Bounds().Dy()
+	windowWidth, windowHeight := 400, 175
+	windowX, windowY := (screenWidth-windowWidth)/2, (screenHeight-windowHeight)/2
+
+	drawWindowWithBorder(screen, windowX, windowY, windowWidth, windowHeight, 127)
+
+	text.Draw(screen, "装備", mplusSmallFont, windowX+10, windowY+18, color.White)
+
+	for slot, equippedItem := range g.state.Player.EquippedItems {
+		x := windowX + 50
+		y := windowY + 45 + slot*25
+
+		var textColor color.Color = color.White
+		if identifiableItem, ok := equippedItem.(Identifiable); ok && !identifiableItem.IsIdentified() {
+			textColor = color.RGBA{0xff, 0xff, 0x00, 0xff} // 識別されていないアイテムは黄色
+		}
+		text.Draw(screen, EquipSlot(slot).Label(), mplusNormalFont, x, y, color.White)
+		text.Draw(screen, equippedItemText(equippedItem), mplusNormalFont, x+70, y, textColor)
+
+		if EquipSlot(slot) == g.selectedEquipSlot {
+			text.Draw(screen, "→", mplusNormalFont, x-40, y, color.White)
+		}
+	}
+}
+
 func (g *Game) drawInventoryWindow(screen *ebiten.Image) error {
 
 	screenWidth, screenHeight := screen.Bounds().Dx(), screen.Bounds().Dy()
@@ -493,12 +509,17 @@ func (g *Game) drawInventoryWindow(screen *ebiten.Image) error {
 
 	drawWindowWithBorder(screen, windowX, windowY, windowWidth, windowHeight, 127)
 
-	// Draw items
-	const itemsPerColumn = 10 // 1列に表示するアイテムの数
-	const columnWidth = 180   // 列の幅 (ピクセル)
-
-	if len(g.state.Player.Inventory) > 0 {
-		for i, item := range g.state.Player.Inventory {
+	// 表示中の種類、ページと所持数を描画
+	inventory := g.state.Player.Inventory
+	view := g.inventoryView()
+	page := viewPage(view, g.selectedItemIndex)
+	pageCount := max((len(view)+itemsPerPage-1)/itemsPerPage, 1)
+	headerText := fmt.Sprintf("[%s] %d/%d  (%d/%d)", kindLabel(g.inventoryFilter), page+1, pageCount, len(inventory), g.state.Player.MaxInventory)
+	text.Draw(screen, headerText, mplusSmallFont, windowX+10, windowY+18, color.White)
+
+	if len(view) > 0 {
+		for row, i := range view[page*itemsPerPage : min(len(view), (page+1)*itemsPerPage)] {
+			item := inventory[i]
 			// アイテムが識別されているかどうかを判断し、表示するテキストを設定
 			var itemText string
 			var textColor color.Color = color.White // デフォルトのテキストカラーは白
@@ -516,51 +537,38 @@ func (g *Game) drawInventoryWindow(screen *ebiten.Image) error {
 				textColor = color.RGBA{0x80, 0x80, 0x80, 0xff} // 灰色
 			}
 
-			// 現在の列と行の計算
-			column := i / itemsPerColumn
-			row := i % itemsPerColumn
-
 			// アイテムテキストの描画位置の計算
-			x := windowX + 30 + column*columnWidth
-			y := windowY + 30 + row*25
+			x := windowX + 50
+			y := windowY + 45 + row*25
 
 			text.Draw(screen, itemText, mplusNormalFont, x, y, textColor) // 色を変更
 
-			// Check if the item is equipped and draw "E" if it is
+			// 装備中のアイテムには名前の左に"E"のマーカーを付ける
 			if equipableItem, ok := item.(Equipable); ok {
 				if isEquipped(g.state.Player.EquippedItems[:], equipableItem) {
-					var dr font.Drawer
-					dr.Dst = screen
-					dr.Src = image.NewUniform(color.White)
-					dr.Face = mplusNormalFont
-					dr.Dot = fixed.Point26_6{
-						X: fixed.I(x),
-						Y: fixed.I(y),
-					}
-					// Measure the width of itemText in pixels
-					textBounds, _ := dr.BoundString(itemText)
-					textWidth := textBounds.Max.X - textBounds.Min.X
-					text.Draw(screen, "E", mplusNormalFont, x+int(textWidth)/64+10, y, color.White) // Adjust the x coordinate based on the width of itemText and a small offset
+					text.Draw(screen, "E", mplusNormalFont, x-18, y, color.RGBA{0x00, 0xff, 0xff, 0xff})
 				}
 			}
 
 			if i == g.selectedItemIndex {
 				// Step 3: Draw the pointer next to the selected item
 				pointerText := "→"
-				text.Draw(screen, pointerText, mplusNormalFont, x-20, y, color.White)
+				text.Draw(screen, pointerText, mplusNormalFont, x-40, y, color.White)
 			}
 		}
 	} else {
-		text.Draw(screen, "何も持っていない", mplusNormalFont, windowX+10, windowY+20, color.White)
+		text.Draw(screen, "何も持っていない", mplusNormalFont, windowX+10, windowY+45, color.White)
 	}
 
 	return nil
 }
 
 func (g *Game) DrawMap(screen *ebiten.Image, offsetX, offsetY int) {
+	tileset := g.themeTileset()
 	for y, row := range g.state.Map {
 		for x, tile := range row {
 			var srcX, srcY int
+			tintR, tintG, tintB := 1.0, 1.0, 1.0 // タイル画像に掛ける色
 			switch tile.Type {
 			case "wall":
 				srcX, srcY = 0, 0
@@ -568,31 +576,79 @@ func (g *Game) DrawMap(screen *ebiten.Image, offsetX, offsetY int) {
 				srcX, srcY = tileSize, 0
 			case "floor":
 				srcX, srcY = 2*tileSize, 0
-			case "door":
+			case doorClosed:
 				srcX, srcY = 3*tileSize, 0
-			case "stairs":
+			case doorLocked:
+				srcX, srcY = 3*tileSize, 0 // 鍵のかかった扉は金色にする
+				tintR, tintG, tintB = 1.0, 0.8, 0.35
+			case doorOpen:
+				srcX, srcY = 2*tileSize, 0 // 開いた扉は床を扉の色にする
+				tintR, tintG, tintB = 0.8, 0.6, 0.45
+			case stairsDown:
 				srcX, srcY = 4*tileSize, 0
+			case stairsUp:
+				srcX, srcY = 4*tileSize, 0 // 上り階段は明るい水色にする
+				tintR, tintG, tintB = 0.6, 0.9, 1.0
+			case stairsBranch:
+				srcX, srcY = 4*tileSize, 0 // 脇道への階段は緑色にする
+				tintR, tintG, tintB = 0.5, 1.0, 0.5
+			case "shrine":
+				srcX, srcY = 2*tileSize, 0 // 床タイルを金色にして祠を表現
+				tintR, tintG, tintB = 1.0, 0.85, 0.3
+			case "sealed_stairs":
+				srcX, srcY = 2*tileSize, 0 // ボスを倒すまでは床に見える
+			case "water":
+				srcX, srcY = 2*tileSize, 0 // 床タイルを青くして水を表現
+				tintR, tintG, tintB = 0.35, 0.55, 1.0
+			case "lava":
+				srcX, srcY = 2*tileSize, 0 // 床タイルを赤くして溶岩を表現
+				tintR, tintG, tintB = 1.0, 0.35, 0.1
 			default:
 				continue
 			}
 
+			// テーマの色を掛け、床は種類ごとに反転と明るさを変える
+			if tint, ok := g.theme.Tints[tile.Type]; ok {
+				tintR, tintG, tintB = tintR*tint[0], tintG*tint[1], tintB*tint[2]
+			}
 			opts := &ebiten.DrawImageOptions{}
+			if tile.Type == "floor" || tile.Type == "corridor" {
+				flipFloorVariant(opts, tile.Variant)
+				shade := floorVariantShades[tile.Variant%floorVariantCount]
+				tintR, tintG, tintB = tintR*shade, tintG*shade, tintB*shade
+			}
 			opts.GeoM.Translate(float64(x*tileSize+offsetX), float64(y*tileSize+offsetY))
 
 			// ColorScaleのインスタンスを作成
 			var colorScale ebiten.ColorScale
 
 			// Brightnessに基づいて色のスケールを設定
-			colorScale.Scale(float32(tile.Brightness), float32(tile.Brightness), float32(tile.Brightness), 1)
+			colorScale.Scale(float32(tile.Brightness*tintR), float32(tile.Brightness*tintG), float32(tile.Brightness*tintB), 1)
 
 			// ColorScaleを適用
 			opts.ColorScale = colorScale
 
-			screen.DrawImage(g.tilesetImg.SubImage(image.Rect(srcX, srcY, srcX+tileSize, srcY+tileSize)).(*ebiten.Image), opts)
+			src := tileset.SubImage(image.Rect(srcX, srcY, srcX+tileSize, srcY+tileSize)).(*ebiten.Image)
+			if tile.Type == "wall" {
+				src = g.wallTile(wallMask(g.state.Map, x, y)) // 床に面した辺を縁取る
+			}
+			screen.DrawImage(src, opts)
 		}
 	}
 }
 
+// DrawTraps draws the traps that are visible to the player.
+func (g *Game) DrawTraps(screen *ebiten.Image, offsetX, offsetY int) {
+	for _, trap := range g.state.Traps {
+		if !g.isTrapVisible(trap) || !isLit(g.state.Map[trap.Y][trap.X]) {
+			continue
+		}
+		x := trap.X*tileSize + offsetX + 7
+		y := trap.Y*tileSize + offsetY + 21
+		text.Draw(screen, "※", mplusNormalFont, x, y, color.RGBA{255, 80, 80, 255})
+	}
+}
+
 func (g *Game) DrawPlayer(screen *ebiten.Image, centerX, centerY int) {
 	opts := &ebiten.DrawImageOptions{}
 	tmpPlayerOffsetX, tmpPlayerOffsetY := 0.0, 0.0
@@ -635,8 +691,12 @@ func (g *Game) DrawPlayer(screen *ebiten.Image, centerX, centerY int) {
 }
 
 func (g *Game) getItemImage(item Item) *ebiten.Image {
+	return g.getItemImageByType(item.GetType())
+}
+
+func (g *Game) getItemImageByType(itemType string) *ebiten.Image {
 	var img *ebiten.Image
-	switch item.GetType() {
+	switch itemType {
 	case "Kane":
 		img = g.kaneImg
 	case "Card":
@@ -657,6 +717,12 @@ func (g *Game) getItemImage(item Item) *ebiten.Image {
 		img = g.effectImg
 	case "Accessory":
 		img = g.accessoryImg
+	case "Pot":
+		img = g.potImg
+	case "Stone":
+		img = g.stoneImg
+	case "Key":
+		img = g.keyImg
 	}
 	return img
 }
@@ -665,12 +731,12 @@ func (g *Game) DrawThrownItem(screen *ebiten.Image, offsetX, offsetY int) {
 
 	if g.ThrownItem.Image != nil {
 		// Check if the ThrownItem is of type Arrow
-		if _, ok := g.ThrownItem.Item.(*Arrow); ok && g.dPressed {
+		if _, ok := g.ThrownItem.Item.(*Arrow); ok && g.ThrownItem.Shot {
 			opts := &ebiten.DrawImageOptions{}
 
-			// Determine the rotation angle based on the player's direction
+			// Determine the rotation angle based on the direction the arrow was shot
 			var angle float64
-			switch g.state.Player.Direction {
+			switch g.ThrownItem.Direction {
 			case Up:
 				angle = math.Pi // 180 degrees in radians
 			case Down:
@@ -710,8 +776,8 @@ func (g *Game) DrawItems(screen *ebiten.Image, offsetX, offsetY int) {
 	for _, item := range g.state.Items {
 		itemX, itemY := item.GetPosition()
 
-		// Check if the tile at the item's position is fully bright
-		if g.state.Map[itemY][itemX].Brightness == 1.0 {
+		// Check if the tile at the item's position is lit
+		if isLit(g.state.Map[itemY][itemX]) {
 			img := g.getItemImage(item)
 			opts := &ebiten.DrawImageOptions{}
 			opts.GeoM.Translate(float64(itemX*tileSize+offsetX), float64(itemY*tileSize+offsetY))
@@ -721,11 +787,21 @@ func (g *Game) DrawItems(screen *ebiten.Image, offsetX, offsetY int) {
 }
 
 func (g *Game) getEnemyImage(enemy Enemy) *ebiten.Image {
+	// 擬態している敵はアイテムの画像で描画する
+	if mimic, ok := enemy.Behavior.(mimicBehavior); ok && enemy.Disguised {
+		return g.getItemImageByType(mimic.DisguiseType)
+	}
 	var img *ebiten.Image
 	switch enemy.Type {
-	case "Snake":
+	case "Snake", "DrainEel":
 		img = g.snakeImg
-	case "Shrimp":
+	case "SeaSnake":
+		img = g.seaSnakeImg
+	case "BigShrimp":
+		img = g.bigEbiImg
+	case "ShrimpKing":
+		img = g.ebiKingImg
+	default:
 		img = g.ebiImg
 	}
 	return img
@@ -735,8 +811,8 @@ func (g *Game) DrawEnemies(screen *ebiten.Image, offsetX, offsetY int) {
 	for i := range g.state.Enemies {
 		enemy := &g.state.Enemies[i]
 
-		// Check if the tile at the enemy's position is fully bright
-		if g.state.Map[enemy.Y][enemy.X].Brightness == 1.0 {
+		// Check if the tile at the enemy's position is lit
+		if isLit(g.state.Map[enemy.Y][enemy.X]) {
 
 			// 敵のアニメーションを更新
 			g.UpdateEnemyAnimation(enemy)
@@ -746,7 +822,9 @@ func (g *Game) DrawEnemies(screen *ebiten.Image, offsetX, offsetY int) {
 			enemyOffsetX += int(enemy.OffsetX)
 			enemyOffsetY += int(enemy.OffsetY)
 
-			enemyOffsetY += g.enemyYOffset // Y座標オフセットの適用
+			if !enemy.Disguised {
+				enemyOffsetY += g.enemyYOffset // Y座標オフセットの適用
+			}
 
 			img := g.getEnemyImage(*enemy)
 
@@ -885,30 +963,9 @@ func (g *Game) DrawHUD(screen *ebiten.Image) {
 
 	yCoordinate := 110 // Initial Y-coordinate updated to position below the cash text
 
-	for i, equippedItem := range g.state.Player.EquippedItems {
-		equippedItemName := "なし"
-		sharpnessText := ""
-
-		// Check if the equipped item is not nil
-		if equippedItem != nil {
-			if arrowItem, ok := equippedItem.(*Arrow); ok {
-				// If the equipped item is of type *Arrow, format the name with shot count
-				equippedItemName = fmt.Sprintf("%d本の%s", arrowItem.ShotCount, arrowItem.GetName())
-			} else {
-				// For other item types, just get the name
-				equippedItemName = equippedItem.GetName()
-
-				// Check if the equipped item is of type *Weapon or *Armor to display sharpness
-				if weaponItem, ok := equippedItem.(*Weapon); ok && weaponItem.Sharpness != 0 {
-					sharpnessText = fmt.Sprintf("%+d", weaponItem.Sharpness) // %+d will include the sign for negative and positive numbers
-				} else if armorItem, ok := equippedItem.(*Armor); ok && armorItem.Sharpness != 0 {
-					sharpnessText = fmt.Sprintf("%+d", armorItem.Sharpness) // %+d will include the sign for negative and positive numbers
-				}
-			}
-		}
-
-		equippedItemText := fmt.Sprintf("装備%d: %s%s", i+1, equippedItemName, sharpnessText) // i+1 to display item number starting from 1
-		text.Draw(screen, equippedItemText, mplusMediumFont, 10, yCoordinate, color.White)
+	for slot, equippedItem := range g.state.Player.EquippedItems {
+		slotText := fmt.Sprintf("%s: %s", EquipSlot(slot).Label(), equippedItemText(equippedItem))
+		text.Draw(screen, slotText, mplusMediumFont, 10, yCoordinate, color.White)
 		yCoordinate += 15 // Increment the Y-coordinate to position text below the previous item
 	}
 
@@ -923,6 +980,9 @@ func (g *Game) DrawHUD(screen *ebiten.Image) {
 
 	// Floor level
 	floorText := fmt.Sprintf("階層: B%dF", g.Floor)
+	if g.location.Branch != "" {
+		floorText = fmt.Sprintf("階層: %s B%dF", g.location.Branch, g.Floor)
+	}
 	text.Draw(screen, floorText, mplusNormalFont, 10, 30, color.White) // x座標とy座標を直接指定
 
 	// Player Level
@@ -10,7 +10,6 @@ func (g *Game) UpdateAndDrawMiniMap(screen *Image)                 {}
 func (g *Game) CalculateAnimationOffset(screen *Image) (int, int)  { return 0, 0 }
 func (g *Game) UpdateEnemyAnimation(enemy *Enemy)                  {}
 func (g *Game) CalculateEnemyOffset(enemy *Enemy) (int, int)       { return 0, 0 }
-func (g *Game) ManageDescriptions()                                {}
 func (g *Game) DrawDescriptions(screen *Image)                     {}
 func (g *Game) drawItemDescription(screen *Image)                  {}
 func (g *Game) DrawGroundItem(screen *Image)                       {}
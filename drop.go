@@ -0,0 +1,113 @@
+//go:build !test
+// +build !test
+
+package main
+
+import "fmt"
+
+const (
+	defaultDropRate   = 0.05 // ドロップ表を持たない敵がアイテムを落とす確率
+	carriedThrowRange = 6    // 持っているアイテムを投げてくる距離
+	carriedThrowRate  = 0.5  // 持っているアイテムを投げてくる確率
+)
+
+// DropTable は敵が倒されたときに落とすアイテムの表
+type DropTable struct {
+	Rate    float64 // アイテムを落とす確率 (0の場合はdefaultDropRate)
+	ItemIDs []int   // 落とすアイテムのID (newItemの番号)。空の場合はランダムなアイテム
+}
+
+// roll returns the item dropped by the enemy, or nil if it drops nothing.
+func (t DropTable) roll(d *DungeonDef, x, y int) Item {
+	rate := t.Rate
+	if rate == 0 {
+		rate = defaultDropRate
+	}
+	if localRand.Float64() >= rate {
+		return nil
+	}
+	if len(t.ItemIDs) == 0 {
+		return d.createItem(x, y)
+	}
+	return newItem(t.ItemIDs[localRand.Intn(len(t.ItemIDs))], x, y)
+}
+
+// findDropPosition returns where an item falling at (x, y) comes to rest.
+// その場所にアイテムがあれば周囲8マスの空いている場所を探す
+func (g *Game) findDropPosition(x, y int) (int, int, bool) {
+	if !itemAt(g.state.Items, x, y) {
+		return x, y, true
+	}
+	directions := []Coordinate{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+	for _, dir := range directions {
+		newX := x + dir.X
+		newY := y + dir.Y
+		// Check map boundaries and tile type
+		if newX >= 0 && newY >= 0 && newX < len(g.state.Map[0]) && newY < len(g.state.Map) &&
+			canHoldItem(g.state.Map[newY][newX]) && !itemAt(g.state.Items, newX, newY) {
+			return newX, newY, true
+		}
+	}
+	return 0, 0, false
+}
+
+// placeItem puts the item on the nearest free tile around (x, y).
+// It returns false if there is no room, or the item falls into water or lava, and the item is lost.
+func (g *Game) placeItem(item Item, x, y int) bool {
+	if tile := g.state.Map[y][x]; terrainOf(tile).Swallows {
+		message := fmt.Sprintf("%sは水に沈んだ。", item.GetName())
+		if tile.Type == "lava" {
+			message = fmt.Sprintf("%sは溶岩で燃え尽きた。", item.GetName())
+		}
+		g.Enqueue(Action{Duration: 0.4, Message: message, Execute: func(g *Game) {}})
+		return false
+	}
+	dropX, dropY, ok := g.findDropPosition(x, y)
+	if !ok {
+		return false
+	}
+	item.SetPosition(dropX, dropY)
+	g.state.Items = append(g.state.Items, item)
+	g.miniMapDirty = true
+	return true
+}
+
+// dropEnemyLoot drops the items of a defeated enemy: what it has stolen, what it
+// carries and what its drop table gives.
+func (g *Game) dropEnemyLoot(e Enemy) {
+	g.dropStolenGoods(e)
+	if e.CarriedItem != nil {
+		g.placeItem(e.CarriedItem, e.X, e.Y)
+	}
+	if item := enemyDefinitions[e.ID].Drops.roll(g.currentDungeon(), e.X, e.Y); item != nil {
+		g.placeItem(item, e.X, e.Y)
+	}
+}
+
+// useCarriedItem lets the enemy drink or throw the item it carries.
+// It returns true if the enemy used its turn.
+func (g *Game) useCarriedItem(i int) bool {
+	e := &g.state.Enemies[i]
+	if e.CarriedItem == nil || !e.PlayerDiscovered {
+		return false
+	}
+
+	switch item := e.CarriedItem.(type) {
+	case *Potion:
+		if e.Health > e.MaxHealth/2 {
+			return false
+		}
+		e.Health = min(e.MaxHealth, e.Health+item.Health)
+		e.CarriedItem = nil
+		g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sは%sを使った。", e.Name, item.GetName()), Execute: func(g *Game) {}})
+		return true
+	default:
+		if isAdjacentToPlayer(g, e) || !g.hasLineOfFire(e, carriedThrowRange) || localRand.Float64() >= carriedThrowRate {
+			return false
+		}
+		e.Direction = determineDirection(sign(g.state.Player.X-e.X), sign(g.state.Player.Y-e.Y))
+		e.CarriedItem = nil
+		g.enemyThrow(i, item, carriedThrowRange)
+		return true
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	dungeonDir       = "dungeons" // ダンジョン定義ファイルを置くディレクトリ
+	defaultMapWidth  = 70
+	defaultMapHeight = 70
+	defaultItemCount = 10 // フロアに置くアイテムの数
+	minMapSize       = 40 // これより小さい地図では部屋の配置が難しい
+	maxMapSize       = 150
+)
+
+// DungeonDef はダンジョンの定義。dungeons/ のJSONファイルから読み込む
+// (キーの大文字・小文字は区別しない)
+type DungeonDef struct {
+	Name          string
+	Description   string
+	Depth         int               // この階層の階段を降りるとダンジョンを踏破したことになる
+	Floors        []FloorDef        // 階層の範囲ごとの地図の設定。どれにも当てはまらない階層は既定の設定になる
+	Enemies       []EnemySpawn      // 出現する敵の表。空の場合は敵の定義の出現階層に従う
+	Items         []ItemSpawn       // 落ちているアイテムの表。空の場合はすべてのアイテムから同じ確率で選ぶ
+	SpecialFloors map[int]BossFloor // 地図ファイルから読み込む特別な階層 (ボスフロア)
+	Branches      []BranchDef       // 本道から分かれる脇道
+	Rules         DungeonRules
+}
+
+// FloorDef は階層の範囲ごとの地図の設定
+type FloorDef struct {
+	MinFloor, MaxFloor int
+	Width, Height      int       // 地図の大きさ (0の場合は70x70)
+	Generators         []string  // mapGeneratorsの名前。この中からランダムに選ぶ (同じ名前を複数書くと選ばれやすくなる)
+	ItemCount          int       // 落ちているアイテムの数 (0の場合は10)
+	DarkChance         float64   // 部屋が暗いフロアになる確率 (1の場合は必ず暗い)
+	Theme              string    // 見た目のテーマ (tileThemesの名前。空の場合は洞窟)
+	Generation         GenParams // 部屋をばらまく生成方法 (classic) の設定。generators に classic がないフロアには書けない。省いた項目は地図の大きさに合わせる
+}
+
+// EnemySpawn は敵の表の1行
+type EnemySpawn struct {
+	Type               string // 敵の種類 (enemyDefinitionsのType)
+	MinFloor, MaxFloor int    // 出現する階層 (MaxFloorが0の場合は最深部まで)
+	Weight             int    // 出現しやすさ (0の場合は1)
+}
+
+// ItemSpawn はアイテムの表の1行
+type ItemSpawn struct {
+	ID     int // newItemのID
+	Weight int // 出現しやすさ (0の場合は1)
+}
+
+// BossFloor はボスが待ち構える階層の設定
+type BossFloor struct {
+	MapFile  string // ボス部屋の地図ファイル
+	BossType string // ボスの敵の種類
+}
+
+// BranchDef は本道の階層から分かれる脇道のダンジョン。脇道の階層も本道と同じ番号で数える
+// (FromFloor が5なら脇道の最初の階層は6)
+type BranchDef struct {
+	Name        string
+	FromFloor   int          // 脇道への階段がある本道の階層
+	Depth       int          // 脇道の階層の数
+	Floors      []FloorDef   // 空の場合は本道の設定を使う
+	Enemies     []EnemySpawn // 空の場合は本道の表を使う
+	Items       []ItemSpawn  // 空の場合は本道の表を使う
+	RewardItems int          // 最下層に置かれる宝のアイテムの数
+}
+
+// DungeonRules はダンジョンに入るときの決まり
+type DungeonRules struct {
+	StartLevel int   // 開始時のレベル (0の場合は1)
+	StartItems []int // 最初から持っているアイテムのID
+	BringItems bool  // 前のダンジョンの持ち物を持ち込めるかどうか
+}
+
+// defaultDungeon は定義ファイルが読み込めないときに遊ぶダンジョン (dungeons/ebi_cave.json と同じ)
+var defaultDungeon = DungeonDef{
+	Name:        "海老さんの洞窟",
+	Description: "深淵の海老神が眠る20階のダンジョン。",
+	Depth:       20,
+	Floors: []FloorDef{
+		{MinFloor: 1, MaxFloor: 3, Generators: []string{"grid"}, Theme: "cave"},
+		{MinFloor: 4, MaxFloor: 9, Generators: []string{"grid", "grid", "classic", "bsp"}, DarkChance: 0.1, Theme: "sea",
+			Generation: GenParams{MinRooms: 5, MaxRooms: 7, ExtraLoopChance: 0.2}},
+		{MinFloor: 11, MaxFloor: 19, Generators: []string{"grid", "bsp", "bsp", "cave", "bigroom"}, DarkChance: 0.2, Theme: "temple"},
+	},
+	SpecialFloors: map[int]BossFloor{
+		10: {MapFile: "maps/boss10.txt", BossType: "GiantLobster"},
+		20: {MapFile: "maps/boss20.txt", BossType: "AbyssShrimpGod"},
+	},
+	Branches: []BranchDef{
+		{Name: "海藻の横穴", FromFloor: 5, Depth: 3, RewardItems: 4,
+			Floors: []FloorDef{{MinFloor: 6, MaxFloor: 8, Width: 50, Height: 50, Generators: []string{"cave", "grid"}, DarkChance: 0.5, Theme: "sea"}}},
+	},
+	Rules: DungeonRules{StartLevel: 1, BringItems: true},
+}
+
+// parseDungeon parses a dungeon definition file and checks that its values make sense.
+// 敵の種類や地図生成の名前が存在するかどうかは checkDungeon で調べる
+func parseDungeon(data []byte) (DungeonDef, error) {
+	var d DungeonDef
+	if err := json.Unmarshal(data, &d); err != nil {
+		return d, err
+	}
+	if d.Name == "" {
+		return d, fmt.Errorf("dungeon has no name")
+	}
+	if d.Depth < 1 {
+		return d, fmt.Errorf("dungeon %q: depth must be at least 1, got %d", d.Name, d.Depth)
+	}
+	if err := checkTables(d.Floors, d.Enemies, d.Items); err != nil {
+		return d, fmt.Errorf("dungeon %q: %v", d.Name, err)
+	}
+	for floor, special := range d.SpecialFloors {
+		if floor < 1 || floor > d.Depth || special.MapFile == "" || special.BossType == "" {
+			return d, fmt.Errorf("dungeon %q: special floor %d is invalid", d.Name, floor)
+		}
+	}
+	names := map[string]bool{}
+	fromFloors := map[int]bool{}
+	for i, b := range d.Branches {
+		if b.Name == "" || names[b.Name] {
+			return d, fmt.Errorf("dungeon %q: branches[%d] needs a unique name", d.Name, i)
+		}
+		if b.FromFloor < 1 || b.FromFloor >= d.Depth || fromFloors[b.FromFloor] {
+			return d, fmt.Errorf("dungeon %q: branch %q must start from a floor above %d with no other branch", d.Name, b.Name, d.Depth)
+		}
+		if b.Depth < 1 || b.RewardItems < 0 {
+			return d, fmt.Errorf("dungeon %q: branch %q has an invalid depth or reward", d.Name, b.Name)
+		}
+		if err := checkTables(b.Floors, b.Enemies, b.Items); err != nil {
+			return d, fmt.Errorf("dungeon %q: branch %q: %v", d.Name, b.Name, err)
+		}
+		names[b.Name], fromFloors[b.FromFloor] = true, true
+	}
+	if d.Rules.StartLevel < 0 {
+		return d, fmt.Errorf("dungeon %q: start level must not be negative", d.Name)
+	}
+	return d, nil
+}
+
+// usesGenerator reports whether the floor may be made by the map generator of the name.
+func (f FloorDef) usesGenerator(name string) bool {
+	if len(f.Generators) == 0 {
+		return name == defaultMapGenerator
+	}
+	for _, generator := range f.Generators {
+		if generator == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTables checks the floor settings and the spawn tables of a dungeon or a branch.
+func checkTables(floors []FloorDef, enemies []EnemySpawn, items []ItemSpawn) error {
+	for i, f := range floors {
+		if f.MinFloor < 1 || f.MaxFloor < f.MinFloor {
+			return fmt.Errorf("floors[%d] has an invalid range %d-%d", i, f.MinFloor, f.MaxFloor)
+		}
+		if (f.Width != 0 || f.Height != 0) && (f.Width < minMapSize || f.Height < minMapSize || f.Width > maxMapSize || f.Height > maxMapSize) {
+			return fmt.Errorf("floors[%d] map size %dx%d must be between %d and %d", i, f.Width, f.Height, minMapSize, maxMapSize)
+		}
+		if f.ItemCount < 0 {
+			return fmt.Errorf("floors[%d] has a negative item count", i)
+		}
+		if f.DarkChance < 0 || f.DarkChance > 1 {
+			return fmt.Errorf("floors[%d] dark chance must be between 0 and 1", i)
+		}
+		if _, ok := tileThemes[f.Theme]; f.Theme != "" && !ok {
+			return fmt.Errorf("floors[%d] has an unknown theme %q", i, f.Theme)
+		}
+		width, height := f.Width, f.Height
+		if width == 0 || height == 0 {
+			width, height = defaultMapWidth, defaultMapHeight
+		}
+		if err := f.Generation.withDefaults(width, height).validate(); err != nil {
+			return fmt.Errorf("floors[%d] generation: %v", i, err)
+		}
+		// generation は classic でしか使われないので、使われない設定は書き間違いとみなす
+		if f.Generation != (GenParams{}) && !f.usesGenerator("classic") {
+			return fmt.Errorf("floors[%d] generation is only used by the classic generator", i)
+		}
+	}
+	for i, e := range enemies {
+		if e.Type == "" || e.Weight < 0 || (e.MaxFloor != 0 && e.MaxFloor < e.MinFloor) {
+			return fmt.Errorf("enemies[%d] is invalid", i)
+		}
+	}
+	for i, item := range items {
+		if item.ID < 0 || item.Weight < 0 {
+			return fmt.Errorf("items[%d] is invalid", i)
+		}
+	}
+	return nil
+}
+
+// Floor returns the map settings of the floor, with the defaults filled in.
+func (d *DungeonDef) Floor(floor int) FloorDef {
+	def := FloorDef{MinFloor: floor, MaxFloor: floor}
+	for _, f := range d.Floors {
+		if floor >= f.MinFloor && floor <= f.MaxFloor {
+			def = f
+			break
+		}
+	}
+	if def.Width == 0 || def.Height == 0 {
+		def.Width, def.Height = defaultMapWidth, defaultMapHeight
+	}
+	if def.ItemCount == 0 {
+		def.ItemCount = defaultItemCount
+	}
+	return def
+}
+
+// SpecialFloor returns the special floor settings of the floor, if it has any.
+func (d *DungeonDef) SpecialFloor(floor int) (BossFloor, bool) {
+	special, ok := d.SpecialFloors[floor]
+	return special, ok
+}
+
+// enemyTable returns the enemy types that appear on the floor and their weights.
+func (d *DungeonDef) enemyTable(floor int) ([]string, []int) {
+	var types []string
+	var weights []int
+	for _, e := range d.Enemies {
+		if floor < e.MinFloor || (e.MaxFloor != 0 && floor > e.MaxFloor) {
+			continue
+		}
+		types = append(types, e.Type)
+		weights = append(weights, spawnWeight(e.Weight))
+	}
+	return types, weights
+}
+
+// itemTable returns the item IDs that are found in the dungeon and their weights.
+func (d *DungeonDef) itemTable() ([]int, []int) {
+	ids := make([]int, len(d.Items))
+	weights := make([]int, len(d.Items))
+	for i, item := range d.Items {
+		ids[i], weights[i] = item.ID, spawnWeight(item.Weight)
+	}
+	return ids, weights
+}
+
+// spawnWeight returns the weight of a row of a spawn table. 省略した重みは1
+func spawnWeight(w int) int {
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// pickWeighted returns an index chosen at random with the given weights, or -1 if
+// the total weight is zero.
+func pickWeighted(weights []int, roll func(n int) int) int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return -1
+	}
+	r := roll(total)
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
+}
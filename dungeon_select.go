@@ -0,0 +1,178 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+var (
+	dungeons      []DungeonDef // 遊べるダンジョン (最初の NewGame で読み込む)
+	activeDungeon int          // 遊んでいるダンジョン (dungeons の添字)
+)
+
+// loadDungeons loads every dungeon definition file in the directory. Files that
+// cannot be read are skipped, and the built-in dungeon is used if none is left.
+func loadDungeons(dir string) []DungeonDef {
+	var loaded []DungeonDef
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("failed to read dungeon definitions: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("failed to read %s: %v", path, err)
+			continue
+		}
+		d, err := parseDungeon(data)
+		if err == nil {
+			err = checkDungeon(d)
+		}
+		if err != nil {
+			log.Printf("invalid dungeon definition %s: %v", path, err)
+			continue
+		}
+		loaded = append(loaded, d)
+	}
+	if len(loaded) == 0 {
+		loaded = append(loaded, defaultDungeon)
+	}
+	return loaded
+}
+
+// checkDungeon checks that the enemies, items and map generators named in the
+// definition, and in its branches, exist.
+func checkDungeon(d DungeonDef) error {
+	for _, f := range d.Floors {
+		for _, name := range f.Generators {
+			if _, ok := mapGenerators[name]; !ok {
+				return fmt.Errorf("unknown map generator %q", name)
+			}
+		}
+	}
+	for _, e := range d.Enemies {
+		if enemyIDByType(e.Type) < 0 {
+			return fmt.Errorf("unknown enemy type %q", e.Type)
+		}
+	}
+	for _, special := range d.SpecialFloors {
+		if enemyIDByType(special.BossType) < 0 {
+			return fmt.Errorf("unknown boss type %q", special.BossType)
+		}
+	}
+	ids := append([]int(nil), d.Rules.StartItems...)
+	for _, item := range d.Items {
+		ids = append(ids, item.ID)
+	}
+	for _, id := range ids {
+		if id < 0 || id >= len(itemNames) {
+			return fmt.Errorf("unknown item ID %d", id)
+		}
+	}
+	for _, b := range d.Branches {
+		if err := checkDungeon(*d.branchDungeon(b)); err != nil {
+			return fmt.Errorf("branch %q: %v", b.Name, err)
+		}
+	}
+	return nil
+}
+
+// DungeonSelectScene はダンジョン選択画面。ダンジョンを踏破した後もここで次のダンジョンを選ぶ
+type DungeonSelectScene struct {
+	cursor  int
+	carried *Player // 踏破したダンジョンの海老さん (持ち物を次のダンジョンに持ち込む)
+}
+
+// newDungeonSelectScene returns the dungeon select screen. After clearing a dungeon the
+// player is passed in so that the items can be brought into the next dungeon.
+func newDungeonSelectScene(carried *Player) *DungeonSelectScene {
+	return &DungeonSelectScene{cursor: activeDungeon, carried: carried}
+}
+
+func (s *DungeonSelectScene) Update() error {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		s.cursor = (s.cursor + len(dungeons) - 1) % len(dungeons)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		s.cursor = (s.cursor + 1) % len(dungeons)
+	case inpututil.IsKeyJustPressed(ebiten.KeyZ):
+		s.startDungeon(s.cursor)
+	case inpututil.IsKeyJustPressed(ebiten.KeyX):
+		activeDungeon = s.cursor // タイトル画面の「はじめから」はこのダンジョンで始まる
+		scenes.Switch(newTitleScene())
+	}
+	return nil
+}
+
+// startDungeon starts a new game in the dungeon.
+func (s *DungeonSelectScene) startDungeon(index int) {
+	activeDungeon = index
+	g := NewGame()
+	if s.carried != nil && g.dungeon.Rules.BringItems {
+		g.bringItems(s.carried)
+	}
+	scenes.startGame(g)
+}
+
+// bringItems gives the player the items of the previous dungeon, equipped as they were.
+func (g *Game) bringItems(carried *Player) {
+	player := &g.state.Player
+	for _, item := range carried.Inventory {
+		if len(player.Inventory) >= player.MaxInventory {
+			break
+		}
+		player.Inventory = append(player.Inventory, item)
+	}
+	for slot, item := range carried.EquippedItems {
+		if equipableItem, ok := item.(Equipable); ok {
+			equipableItem.UpdatePlayerStats(player, true)
+			player.EquippedItems[slot] = item
+		}
+	}
+}
+
+// Draw draws the list of dungeons and the details of the selected one.
+func (s *DungeonSelectScene) Draw(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+	text.Draw(screen, "ダンジョンを選んでください", mplusNormalFont, 120, 100, color.White)
+
+	for i, d := range dungeons {
+		y := 160 + i*35
+		text.Draw(screen, d.Name, mplusNormalFont, 160, y, color.White)
+		if i == s.cursor {
+			text.Draw(screen, "→", mplusNormalFont, 120, y, color.White)
+		}
+	}
+
+	d := dungeons[s.cursor]
+	startLevel := max(d.Rules.StartLevel, 1)
+	bring := "持ち込み不可"
+	if d.Rules.BringItems {
+		bring = "持ち込み可"
+	}
+	lines := []string{
+		d.Description,
+		fmt.Sprintf("全%d階 / レベル%dから / %s", d.Depth, startLevel, bring),
+		"",
+		"Zキーで出発 / Xキーで戻る",
+	}
+	top := 200 + len(dungeons)*35
+	for i, line := range lines {
+		text.Draw(screen, line, mplusNormalFont, 120, top+i*30, color.White)
+	}
+}
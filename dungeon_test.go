@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestDungeonFiles checks that every dungeon definition shipped with the game can be parsed.
+func TestDungeonFiles(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(dungeonDir, "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no dungeon definitions found: %v", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseDungeon(data); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestParseDungeonErrors(t *testing.T) {
+	tests := []string{
+		`{"depth": 5}`,              // no name
+		`{"name": "a", "depth": 0}`, // no depth
+		`{"name": "a", "depth": 5, "floors": [{"minFloor": 3, "maxFloor": 2}]}`,
+		`{"name": "a", "depth": 5, "floors": [{"minFloor": 1, "maxFloor": 2, "width": 10, "height": 10}]}`,
+		`{"name": "a", "depth": 5, "floors": [{"minFloor": 1, "maxFloor": 2, "darkChance": 1.5}]}`,
+		`{"name": "a", "depth": 5, "floors": [{"minFloor": 1, "maxFloor": 2, "theme": "space"}]}`,
+		`{"name": "a", "depth": 5, "floors": [{"minFloor": 1, "maxFloor": 2, "width": 40, "height": 40, "generation": {"minRoomSize": 30}}]}`,
+		`{"name": "a", "depth": 5, "floors": [{"minFloor": 1, "maxFloor": 2, "generators": ["grid", "cave"], "generation": {"minRooms": 3}}]}`,
+		`{"name": "a", "depth": 5, "specialFloors": {"6": {"mapFile": "x", "bossType": "y"}}}`,
+		`{"name": "a", "depth": 5, "enemies": [{"weight": 1}]}`,
+		`{"name": "a", "depth": 5, "branches": [{"name": "b", "fromFloor": 5, "depth": 2}]}`,
+		`{"name": "a", "depth": 5, "branches": [{"name": "b", "fromFloor": 2, "depth": 2}, {"name": "c", "fromFloor": 2, "depth": 1}]}`,
+		`{"name": "a", "depth": 5, "branches": [{"name": "b", "fromFloor": 2, "depth": 0}]}`,
+		`{"name": "a", "depth": 5,`,
+	}
+	for _, data := range tests {
+		if _, err := parseDungeon([]byte(data)); err == nil {
+			t.Errorf("parseDungeon(%s) returned no error", data)
+		}
+	}
+}
+
+func TestDungeonFloor(t *testing.T) {
+	d, err := parseDungeon([]byte(`{"name": "a", "depth": 5, "floors": [{"minFloor": 2, "maxFloor": 3, "width": 50, "height": 40, "itemCount": 4}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := d.Floor(2); f.Width != 50 || f.Height != 40 || f.ItemCount != 4 {
+		t.Errorf("Floor(2) = %+v, want the 50x40 floor with 4 items", f)
+	}
+	// 範囲外の階層は既定の設定になる
+	if f := d.Floor(4); f.Width != defaultMapWidth || f.Height != defaultMapHeight || f.ItemCount != defaultItemCount {
+		t.Errorf("Floor(4) = %+v, want the default floor", f)
+	}
+}
+
+func TestPickWeighted(t *testing.T) {
+	weights := []int{1, 0, 3}
+	counts := make([]int, len(weights))
+	for r := 0; r < 4; r++ {
+		counts[pickWeighted(weights, func(n int) int { return r % n })]++
+	}
+	if counts[0] != 1 || counts[1] != 0 || counts[2] != 3 {
+		t.Errorf("pickWeighted counts = %v, want [1 0 3]", counts)
+	}
+	if i := pickWeighted(nil, func(n int) int { return 0 }); i != -1 {
+		t.Errorf("pickWeighted(nil) = %d, want -1", i)
+	}
+}
+
+// TestEnemyTableFirstFloor checks that B1F (floor 1) only spawns the enemies of floor 1.
+func TestEnemyTableFirstFloor(t *testing.T) {
+	d, err := parseDungeon([]byte(`{"name": "a", "depth": 5, "enemies": [
+		{"type": "Ebi", "minFloor": 1, "maxFloor": 1},
+		{"type": "Snake", "minFloor": 2, "maxFloor": 3},
+		{"type": "Crab", "minFloor": 3}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types, _ := d.enemyTable(1); len(types) != 1 || types[0] != "Ebi" {
+		t.Errorf("enemyTable(1) = %v, want [Ebi]", types)
+	}
+	// 離れるフロアの番号 (0) で引くと表に当てはまる行がなく、すべての敵から選ばれてしまう
+	if types, _ := d.enemyTable(0); len(types) != 0 {
+		t.Errorf("enemyTable(0) = %v, want no rows", types)
+	}
+}
+
+// TestDefaultDungeon checks that the dungeon played when no definition can be loaded is
+// the same as dungeons/ebi_cave.json.
+func TestDefaultDungeon(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(dungeonDir, "ebi_cave.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := parseDungeon(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d, defaultDungeon) {
+		t.Errorf("defaultDungeon differs from ebi_cave.json:\n got  %+v\n want %+v", defaultDungeon, d)
+	}
+}
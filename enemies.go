@@ -11,8 +11,9 @@ import (
 type SpecialAttackFunc func(e *Enemy, g *Game)
 
 type Enemy struct {
-	Entity                   // Enemy inherits fields from Entity
-	ID                       int
+	Entity                       // Enemy inherits fields from Entity
+	ID                       int // 敵の種類 (enemyDefinitionsの添字)
+	UID                      int // 敵ごとに一意な識別子 (配列の添字は変わるのでこちらで敵を指す)
 	dx, dy                   int // 敵の移動方向
 	Name                     string
 	Health                   int
@@ -31,6 +32,16 @@ type Enemy struct {
 	SpecialAttack            SpecialAttackFunc // 敵の特殊攻撃処理
 	SpecialAttackProbability float64           // 敵が特殊攻撃を使ってくる確率 (0.0 to 1.0)
 	ShowOnMiniMap            bool
+	Behavior                 EnemyBehavior // 敵の行動パターン (nilの場合は通常の移動と攻撃)
+	StolenItem               Item          // 盗んだアイテム
+	StolenCash               int           // 盗んだお金
+	Disguised                bool          // アイテムに擬態しているかどうか
+	AI                       AIProfile     // 敵の思考の設定
+	State                    AIState       // 現在の思考状態
+	HomeState                AIState       // プレイヤーを見失ったときに戻る状態
+	GuardX, GuardY           int           // 護衛する持ち場の座標
+	Phase                    int           // ボスの現在のフェーズ
+	CarriedItem              Item          // 持っているアイテム (使ったり投げたりする)
 }
 
 func (g *Game) updateEnemyVisibility() {
@@ -45,7 +56,9 @@ func (g *Game) updateEnemyVisibility() {
 		// Check if the player and enemy are adjacent
 		adjacent := (math.Abs(float64(playerX-enemyX)) <= 1 && math.Abs(float64(playerY-enemyY)) <= 1)
 
-		if inSameRoom || adjacent || enemy.PlayerDiscovered {
+		if enemy.Disguised {
+			enemy.SetShowOnMiniMap(false) // 擬態中の敵はミニマップに表示しない
+		} else if inSameRoom || adjacent || enemy.PlayerDiscovered {
 			g.miniMapDirty = true
 			enemy.SetShowOnMiniMap(true)
 		} else {
@@ -54,68 +67,142 @@ func (g *Game) updateEnemyVisibility() {
 	}
 }
 
-func createEnemy(x, y int) Enemy {
-	var enemyType, enemyName, enemyChar string
-	var enemyAP, enemyDP, enemyID int
-	var enemyHealth, enemyMaxHealth, enemyExperiencePoints int
-	var enemyDirection Direction
-	var specialAttack SpecialAttackFunc
-	var specialAttackProbability float64
-	randomValue := localRand.Intn(2) // Store the random value to ensure it's only generated once and correct the range to 2 for two cases
-	switch randomValue {
-	case 0:
-		enemyID = 0
-		enemyType = "Shrimp"
-		enemyName = "エビ"
-		enemyChar = "E"
-		enemyAP = 4
-		enemyDP = 2
-		enemyHealth = 20
-		enemyMaxHealth = 20
-		enemyExperiencePoints = 5
-		enemyDirection = Down
-		specialAttack = nil // No special attack for Shrimp
-		specialAttackProbability = 0.0
-	case 1:
-		enemyID = 1
-		enemyType = "Snake"
-		enemyName = "毒ヘビ"
-		enemyChar = "S"
-		enemyAP = 7
-		enemyDP = 1
-		enemyHealth = 30
-		enemyMaxHealth = 30
-		enemyExperiencePoints = 10
-		enemyDirection = Down
-		specialAttack = func(e *Enemy, g *Game) {
-			if g.state.Player.Power > 0 {
-				action := Action{
-					Duration: 0.5,
-					Message:  fmt.Sprintf("%sの毒攻撃。海老さんのパワーが1下がった。", e.Name),
-					Execute: func(g *Game) {
-						g.state.Player.Power--
+// EnemyDefinition は敵の種類ごとの定義
+type EnemyDefinition struct {
+	Type                     string
+	Name                     string
+	Char                     string
+	AttackPower              int
+	DefensePower             int
+	Health                   int
+	ExperiencePoints         int
+	MinFloor, MaxFloor       int               // 出現する階層の範囲
+	Behavior                 EnemyBehavior     // 敵の行動パターン (nilの場合は通常の移動と攻撃)
+	AI                       AIProfile         // 敵の思考の設定
+	SpecialAttack            SpecialAttackFunc // 敵の特殊攻撃処理
+	SpecialAttackProbability float64           // 敵が特殊攻撃を使ってくる確率 (0.0 to 1.0)
+	Unique                   bool              // ボスフロアにだけ出現する固有の敵
+	EvolvesTo                string            // レベルアップしたときに変化する敵の種類 (空の場合はレベルアップしない)
+	EatsItems                bool              // 投げられた食べ物を食べてレベルアップするかどうか
+	OpensDoors               bool              // 閉じた扉を開けられるかどうか (鍵のかかった扉は開けられない)
+	Flying                   bool              // 水や溶岩の上を通れるかどうか
+	Drops                    DropTable         // 倒されたときに落とすアイテム
+	CarryItemID              int               // 持っているアイテムのID (newItemの番号)
+	CarryChance              float64           // アイテムを持って出現する確率
+}
 
-					},
-				}
-				g.Enqueue(action)
-			}
-		}
-		specialAttackProbability = 0.3 // Assuming a 100% chance to use special attack for simplicity, adjust as necessary
+// enemyDefinitions の添字が敵のIDになる。EvolvesTo で進化の系統 (エビ → 大エビ → 海老王 など) を定義する
+var enemyDefinitions = []EnemyDefinition{
+	{Type: "Shrimp", Name: "エビ", Char: "E", AttackPower: 4, DefensePower: 2, Health: 20, ExperiencePoints: 5, MinFloor: 1, MaxFloor: 5, EvolvesTo: "BigShrimp", EatsItems: true,
+		Drops: DropTable{Rate: 0.1, ItemIDs: []int{itemIDByName("ウインナー")}},
+		AI:    AIProfile{InitialState: StateFollowing}},
+	{Type: "Snake", Name: "毒ヘビ", Char: "S", AttackPower: 7, DefensePower: 1, Health: 30, ExperiencePoints: 10, MinFloor: 1, MaxFloor: 8, EvolvesTo: "SeaSnake",
+		SpecialAttack: poisonAttack, SpecialAttackProbability: 0.3, AI: AIProfile{SleepChance: 0.5}},
+	{Type: "CursedShrimp", Name: "呪いエビ", Char: "C", AttackPower: 5, DefensePower: 2, Health: 25, ExperiencePoints: 8, MinFloor: 4, MaxFloor: 12,
+		SpecialAttack: curseAttack, SpecialAttackProbability: 0.25}, // 持ち物を呪う
+	{Type: "RustCrab", Name: "錆ガニ", Char: "R", AttackPower: 6, DefensePower: 4, Health: 30, ExperiencePoints: 10, MinFloor: 3, MaxFloor: 10, EvolvesTo: "ArmorCrab",
+		SpecialAttack: rustAttack, SpecialAttackProbability: 0.3}, // 装備を錆びさせる
+	{Type: "PistolShrimp", Name: "テッポウエビ", Char: "P", AttackPower: 4, DefensePower: 1, Health: 18, ExperiencePoints: 7, MinFloor: 2, MaxFloor: 9,
+		Behavior: rangedBehavior{Range: 5, Projectile: "水鉄砲"}, AI: AIProfile{InitialState: StateGuarding, SightRange: 5}},
+	{Type: "ArcherCrab", Name: "弓ガニ", Char: "A", AttackPower: 8, DefensePower: 4, Health: 35, ExperiencePoints: 16, MinFloor: 6, MaxFloor: 15,
+		Behavior: rangedBehavior{Range: 7, Projectile: "矢"}, AI: AIProfile{InitialState: StateGuarding, SightRange: 7}},
+	{Type: "ThiefHermit", Name: "ヤドカリ盗賊", Char: "T", AttackPower: 3, DefensePower: 3, Health: 22, ExperiencePoints: 12, MinFloor: 3, MaxFloor: 12,
+		Behavior: thiefBehavior{}, SpecialAttack: stealItemAttack, SpecialAttackProbability: 0.5, AI: AIProfile{SleepChance: 0.3, FleeHPRatio: 0.5}, OpensDoors: true},
+	{Type: "CoinShrimp", Name: "ゼニエビ", Char: "Z", AttackPower: 3, DefensePower: 2, Health: 20, ExperiencePoints: 10, MinFloor: 2, MaxFloor: 10,
+		Drops:    DropTable{Rate: 0.5, ItemIDs: []int{itemIDByName("小銭")}},
+		Behavior: thiefBehavior{}, SpecialAttack: stealCashAttack, SpecialAttackProbability: 0.5, AI: AIProfile{FleeHPRatio: 0.3}},
+	{Type: "WallMantis", Name: "カベシャコ", Char: "W", AttackPower: 7, DefensePower: 3, Health: 28, ExperiencePoints: 14, MinFloor: 5, MaxFloor: 14,
+		Behavior: wallWalkBehavior{}, CarryItemID: itemIDByName("銀の弓矢"), CarryChance: 0.5}, // 矢を持っていて投げてくる
+	{Type: "GhostShrimp", Name: "幽霊エビ", Char: "G", AttackPower: 10, DefensePower: 5, Health: 40, ExperiencePoints: 25, MinFloor: 10, MaxFloor: 20,
+		Behavior: wallWalkBehavior{}, Flying: true},
+	{Type: "SplitJelly", Name: "分裂クラゲ", Char: "J", AttackPower: 4, DefensePower: 1, Health: 15, ExperiencePoints: 6, MinFloor: 3, MaxFloor: 11,
+		Behavior: multiplyBehavior{Chance: 0.2}, AI: AIProfile{InitialState: StateFollowing}, Flying: true},
+	{Type: "Plankton", Name: "増殖プランクトン", Char: "p", AttackPower: 7, DefensePower: 2, Health: 20, ExperiencePoints: 12, MinFloor: 8, MaxFloor: 20,
+		Behavior: multiplyBehavior{Chance: 0.3}, AI: AIProfile{InitialState: StateFollowing}, Flying: true},
+	{Type: "MimicClam", Name: "擬態貝", Char: "M", AttackPower: 8, DefensePower: 5, Health: 30, ExperiencePoints: 15, MinFloor: 4, MaxFloor: 14,
+		Drops:    DropTable{Rate: 0.3, ItemIDs: []int{itemIDByName("強化の壺")}},
+		Behavior: mimicBehavior{DisguiseType: "Pot"}, AI: AIProfile{InitialState: StateSleeping}},
+	{Type: "MimicCrab", Name: "擬態ガニ", Char: "m", AttackPower: 13, DefensePower: 7, Health: 50, ExperiencePoints: 35, MinFloor: 12, MaxFloor: 25,
+		Drops:    DropTable{Rate: 0.5, ItemIDs: []int{itemIDByName("伝説の剣"), itemIDByName("錆びない鱗")}},
+		Behavior: mimicBehavior{DisguiseType: "Weapon"}, AI: AIProfile{InitialState: StateSleeping}},
+	{Type: "HealerAnemone", Name: "癒しイソギンチャク", Char: "H", AttackPower: 3, DefensePower: 3, Health: 25, ExperiencePoints: 10, MinFloor: 3, MaxFloor: 12,
+		Behavior: healerBehavior{Range: 5, Amount: 10}, AI: AIProfile{FleeHPRatio: 0.4}},
+	{Type: "PriestCucumber", Name: "ナマコ僧侶", Char: "N", AttackPower: 6, DefensePower: 6, Health: 45, ExperiencePoints: 22, MinFloor: 10, MaxFloor: 22,
+		Behavior: healerBehavior{Range: 7, Amount: 25}, AI: AIProfile{FleeHPRatio: 0.3}, CarryItemID: itemIDByName("すごいミンティア"), CarryChance: 0.5, OpensDoors: true}, // 傷つくとすごいミンティアを使う
+	{Type: "DrainEel", Name: "吸魂ウナギ", Char: "U", AttackPower: 7, DefensePower: 3, Health: 32, ExperiencePoints: 18, MinFloor: 6, MaxFloor: 15,
+		SpecialAttack: drainLevelAttack, SpecialAttackProbability: 0.2, AI: AIProfile{SleepChance: 0.3}},
+	{Type: "Anglerfish", Name: "深海アンコウ", Char: "F", AttackPower: 12, DefensePower: 6, Health: 55, ExperiencePoints: 40, MinFloor: 14, MaxFloor: 25,
+		SpecialAttack: drainLevelAttack, SpecialAttackProbability: 0.3, AI: AIProfile{InitialState: StateGuarding, SleepChance: 0.5}},
+	{Type: "BigShrimp", Name: "大エビ", Char: "B", AttackPower: 9, DefensePower: 5, Health: 40, ExperiencePoints: 20, MinFloor: 6, MaxFloor: 15, EvolvesTo: "ShrimpKing", EatsItems: true,
+		CarryItemID: itemIDByName("ミンティア"), CarryChance: 0.3}, // 傷つくとミンティアを使う
+	{Type: "ShrimpKing", Name: "海老王", Char: "K", AttackPower: 15, DefensePower: 9, Health: 70, ExperiencePoints: 60, MinFloor: 15, MaxFloor: 30,
+		Drops: DropTable{Rate: 0.3}, OpensDoors: true,
+		AI: AIProfile{InitialState: StateGuarding}},
+	{Type: "SeaSnake", Name: "毒ウミヘビ", Char: "s", AttackPower: 10, DefensePower: 3, Health: 40, ExperiencePoints: 24, MinFloor: 9, MaxFloor: 20,
+		SpecialAttack: poisonAttack, SpecialAttackProbability: 0.35},
+	{Type: "ArmorCrab", Name: "鎧ガニ", Char: "a", AttackPower: 8, DefensePower: 10, Health: 35, ExperiencePoints: 22, MinFloor: 7, MaxFloor: 18,
+		Drops: DropTable{Rate: 0.15, ItemIDs: []int{itemIDByName("光の角"), itemIDByName("錆びない鱗")}},
+		AI:    AIProfile{InitialState: StateGuarding, SleepChance: 0.5}},
+	{Type: "CurseOctopus", Name: "呪いダコ", Char: "O", AttackPower: 11, DefensePower: 5, Health: 50, ExperiencePoints: 32, MinFloor: 12, MaxFloor: 25,
+		SpecialAttack: curseAttack, SpecialAttackProbability: 0.3, OpensDoors: true},
+	{Type: "StoneCrab", Name: "石投げガニ", Char: "t", AttackPower: 5, DefensePower: 4, Health: 28, ExperiencePoints: 12, MinFloor: 4, MaxFloor: 12,
+		Behavior: rangedBehavior{Range: 6, Projectile: "石"}, AI: AIProfile{SightRange: 6}},
+	{Type: "MageSquid", Name: "魔導イカ", Char: "I", AttackPower: 9, DefensePower: 4, Health: 38, ExperiencePoints: 28, MinFloor: 11, MaxFloor: 22,
+		Behavior: rangedBehavior{Range: 8, Projectile: "魔法弾"}, AI: AIProfile{InitialState: StateGuarding, SightRange: 8, FleeHPRatio: 0.2}, OpensDoors: true, Flying: true},
+	{Type: "GiantLobster", Name: "巨大ロブスター", Char: "L", AttackPower: 13, DefensePower: 8, Health: 160, ExperiencePoints: 300, Unique: true,
+		Drops: DropTable{Rate: 1.0, ItemIDs: []int{itemIDByName("伝説の剣")}},
+		AI:    AIProfile{InitialState: StateGuarding},
+		Behavior: bossBehavior{Phases: []BossPhase{
+			{HPRatio: 0.6, Message: "巨大ロブスターのハサミが赤く光った！", Attack: "ClawCombo", Probability: 0.3, AttackBonus: 2},
+			{HPRatio: 0.3, Message: "巨大ロブスターは仲間を呼び始めた！", Attack: "ClawCombo", Probability: 0.3, AttackBonus: 2, Summon: "Shrimp"},
+		}}},
+	{Type: "AbyssShrimpGod", Name: "深淵の海老神", Char: "G", AttackPower: 20, DefensePower: 12, Health: 280, ExperiencePoints: 1000, Unique: true,
+		AI: AIProfile{InitialState: StateGuarding},
+		Behavior: bossBehavior{Phases: []BossPhase{
+			{HPRatio: 1.0, Attack: "ClawCombo", Probability: 0.2},
+			{HPRatio: 0.66, Message: "深淵の海老神の周りで海がうねり始めた…", Attack: "TidalWave", Probability: 0.3, AttackBonus: 2},
+			{HPRatio: 0.33, Message: "深淵の海老神は眷属を呼び寄せた！", Attack: "TidalWave", Probability: 0.3, AttackBonus: 3, Summon: "BigShrimp"},
+			{HPRatio: 0.15, Message: "深淵の海老神は怒り狂っている！", Attack: "TidalWave", Probability: 0.5, AttackBonus: 5},
+		}}},
+}
+
+// poisonAttack is the special attack of enemies that poison the player.
+var poisonAttack SpecialAttackFunc = func(e *Enemy, g *Game) {
+	action := Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("%sの毒攻撃。", e.Name),
+		Execute:  func(g *Game) {},
 	}
+	g.Enqueue(action)
+	g.poisonPlayer(1) // 毒消しの指輪を装備していれば効かない
+}
 
-	return Enemy{
-		Entity:                   Entity{X: x, Y: y, Char: rune(enemyChar[0])},
-		ID:                       enemyID,
-		Health:                   enemyHealth,
-		MaxHealth:                enemyMaxHealth,
-		Name:                     enemyName,
-		AttackPower:              enemyAP,
-		DefensePower:             enemyDP,
-		Type:                     enemyType,
-		ExperiencePoints:         enemyExperiencePoints,
-		Direction:                enemyDirection,
+// newEnemy creates the enemy with the given ID from its definition.
+func newEnemy(id, x, y int) Enemy {
+	def := enemyDefinitions[id]
+	enemy := Enemy{
+		Entity:                   Entity{X: x, Y: y, Char: rune(def.Char[0])},
+		ID:                       id,
+		UID:                      nextEnemyUID(),
+		Health:                   def.Health,
+		MaxHealth:                def.Health,
+		Name:                     def.Name,
+		AttackPower:              def.AttackPower,
+		DefensePower:             def.DefensePower,
+		Type:                     def.Type,
+		ExperiencePoints:         def.ExperiencePoints,
+		Direction:                Down,
 		PlayerDiscovered:         false,
-		SpecialAttack:            specialAttack,
-		SpecialAttackProbability: specialAttackProbability,
+		Behavior:                 def.Behavior,
+		SpecialAttack:            def.SpecialAttack,
+		SpecialAttackProbability: def.SpecialAttackProbability,
+	}
+	enemy.initAIState(def.AI)
+	if _, ok := def.Behavior.(mimicBehavior); ok {
+		enemy.Disguised = true // 擬態する敵はアイテムのふりをして眠っている
+	}
+	if def.CarryChance > 0 && localRand.Float64() < def.CarryChance {
+		enemy.CarriedItem = newItem(def.CarryItemID, x, y)
 	}
+	return enemy
 }
@@ -0,0 +1,87 @@
+package main
+
+// SpawnConfig は敵の出現数と湧き、風の設定
+type SpawnConfig struct {
+	BaseEnemies       int // 1階に出現する敵の数
+	FloorsPerEnemy    int // この階層ごとに出現する敵が1体増える
+	MaxInitialEnemies int // フロア生成時に出現する敵の上限
+	RespawnInterval   int // 敵が新しく湧くターン間隔
+	MaxEnemies        int // フロアの敵の上限 (これ以上は湧かない)
+	WindWarningTurns  int // 風が吹き始めるターン数
+	WindStrongTurns   int // 風が強くなるターン数
+	WindBlowTurns     int // 風に吹き飛ばされるターン数
+}
+
+const minSpawnDistance = 6 // プレイヤーからこの距離以上離れた場所に敵が湧く
+
+var spawnConfig = SpawnConfig{
+	BaseEnemies:       3,
+	FloorsPerEnemy:    3,
+	MaxInitialEnemies: 10,
+	RespawnInterval:   30,
+	MaxEnemies:        15,
+	WindWarningTurns:  500,
+	WindStrongTurns:   750,
+	WindBlowTurns:     1000,
+}
+
+// InitialEnemies returns the number of enemies placed when the floor is generated.
+func (c SpawnConfig) InitialEnemies(floor int) int {
+	count := c.BaseEnemies
+	if c.FloorsPerEnemy > 0 {
+		count += (floor - 1) / c.FloorsPerEnemy
+	}
+	return min(count, c.MaxInitialEnemies)
+}
+
+// generateEnemies places the enemies on the spawn points away from the player.
+func generateEnemies(d *DungeonDef, spawns []Coordinate, rooms []Room, player Coordinate, floor int) []Enemy {
+	// 海老さんと同じ部屋 (大部屋では近く) には置かない。置ける場所が足りなければ敵を減らす
+	awayFromPlayer := func(spawn Coordinate) bool {
+		sameRoom := len(rooms) > 1 && isSameRoom(spawn.X, spawn.Y, player.X, player.Y, rooms)
+		return !sameRoom && max(abs(spawn.X-player.X), abs(spawn.Y-player.Y)) >= minSpawnDistance
+	}
+	var enemies []Enemy
+	for _, spawn := range pickSpawns(spawns, spawnConfig.InitialEnemies(floor), awayFromPlayer, localRand.Perm) {
+		enemies = append(enemies, d.createEnemy(spawn.X, spawn.Y, floor))
+	}
+	return enemies
+}
+
+// createEnemy creates a random enemy that appears on the given floor of the dungeon.
+// ダンジョンに敵の表があればその中から選ぶ
+func (d *DungeonDef) createEnemy(x, y, floor int) Enemy {
+	types, weights := d.enemyTable(floor)
+	if i := pickWeighted(weights, localRand.Intn); i >= 0 {
+		if id := enemyIDByType(types[i]); id >= 0 {
+			return newEnemy(id, x, y)
+		}
+	}
+
+	var candidates []int
+	var all []int
+	for id, def := range enemyDefinitions {
+		if def.Unique {
+			continue // ボスはボスフロアにだけ出現する
+		}
+		all = append(all, id)
+		if floor >= def.MinFloor && floor <= def.MaxFloor {
+			candidates = append(candidates, id)
+		}
+	}
+	if len(candidates) == 0 {
+		// 出現範囲外の階層ではすべての敵から選ぶ
+		return newEnemy(all[localRand.Intn(len(all))], x, y)
+	}
+	return newEnemy(candidates[localRand.Intn(len(candidates))], x, y)
+}
+
+// enemyIDByType returns the enemy ID of the given type, or -1 if there is none.
+func enemyIDByType(enemyType string) int {
+	for id, def := range enemyDefinitions {
+		if def.Type == enemyType {
+			return id
+		}
+	}
+	return -1
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestGenerateFirstFloorEnemies generates the enemies of B1F and checks that every
+// one of them is an enemy of B1F in the table of the dungeon.
+func TestGenerateFirstFloorEnemies(t *testing.T) {
+	savedRand, savedDefinitions := localRand, enemyDefinitions
+	defer func() { localRand, enemyDefinitions = savedRand, savedDefinitions }()
+	enemyDefinitions = []EnemyDefinition{
+		{Type: "Ebi", MinFloor: 1, MaxFloor: 1},
+		{Type: "Snake", MinFloor: 2, MaxFloor: 3},
+		{Type: "Crab", MinFloor: 3, MaxFloor: 99},
+	}
+	d, err := parseDungeon([]byte(`{"name": "a", "depth": 5, "enemies": [
+		{"type": "Ebi", "minFloor": 1, "maxFloor": 1},
+		{"type": "Snake", "minFloor": 2, "maxFloor": 3},
+		{"type": "Crab", "minFloor": 3}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spawns []Coordinate
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 30; x++ {
+			spawns = append(spawns, Coordinate{X: x, Y: y})
+		}
+	}
+	for seed := int64(0); seed < 50; seed++ {
+		localRand = rand.New(rand.NewSource(seed))
+		enemies := generateEnemies(&d, spawns, nil, Coordinate{}, 1)
+		if len(enemies) == 0 {
+			t.Fatalf("seed %d: no enemies generated on B1F", seed)
+		}
+		for _, enemy := range enemies {
+			if enemy.Type != "Ebi" {
+				t.Fatalf("seed %d: %s generated on B1F, want only Ebi", seed, enemy.Type)
+			}
+		}
+	}
+}
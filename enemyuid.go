@@ -0,0 +1,40 @@
+package main
+
+var lastEnemyUID int
+
+// nextEnemyUID returns a new UID for an enemy. 敵は配列の添字ではなくこのUIDで指す
+func nextEnemyUID() int {
+	lastEnemyUID++
+	return lastEnemyUID
+}
+
+// enemyIndexByUID returns the current index of the enemy with the UID, or -1 if it is gone.
+func (s *GameState) enemyIndexByUID(uid int) int {
+	for i, enemy := range s.Enemies {
+		if enemy.UID == uid {
+			return i
+		}
+	}
+	return -1
+}
+
+// damageEnemy takes amount of health from the enemy with the UID and returns the enemy
+// after the damage. An enemy whose health runs out is removed from the floor, and the
+// player gets its experience points if byPlayer is set. An enemy that is already gone
+// is not found and gives nothing, so an attack queued against it does no harm.
+func (s *GameState) damageEnemy(uid, amount int, byPlayer bool) (enemy Enemy, found, defeated bool) {
+	i := s.enemyIndexByUID(uid)
+	if i < 0 {
+		return Enemy{}, false, false
+	}
+	s.Enemies[i].Health = max(0, s.Enemies[i].Health-amount)
+	enemy = s.Enemies[i]
+	if enemy.Health > 0 {
+		return enemy, true, false
+	}
+	s.Enemies = append(s.Enemies[:i], s.Enemies[i+1:]...)
+	if byPlayer {
+		s.Player.ExperiencePoints += enemy.ExperiencePoints
+	}
+	return enemy, true, true
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+// TestDamageEnemyQueued kills an enemy while attacks on it and on another enemy are
+// still queued. The queued attacks must find their targets by UID even though the
+// indices have shifted, and the experience points must be given once per enemy.
+func TestDamageEnemyQueued(t *testing.T) {
+	s := &GameState{Enemies: []Enemy{
+		{UID: 1, Health: 3, ExperiencePoints: 5},
+		{UID: 2, Health: 3, ExperiencePoints: 7},
+		{UID: 3, Health: 3, ExperiencePoints: 11},
+	}}
+	var queued []func()
+	for _, uid := range []int{2, 1} {
+		queued = append(queued, func() { s.damageEnemy(uid, 10, true) })
+	}
+
+	if _, found, defeated := s.damageEnemy(1, 10, true); !found || !defeated {
+		t.Fatalf("damageEnemy(1) = found %v, defeated %v; want both", found, defeated)
+	}
+	for _, attack := range queued {
+		attack()
+	}
+
+	if len(s.Enemies) != 1 || s.Enemies[0].UID != 3 || s.Enemies[0].Health != 3 {
+		t.Errorf("enemies = %+v, want only the unhurt enemy 3", s.Enemies)
+	}
+	if got := s.Player.ExperiencePoints; got != 5+7 {
+		t.Errorf("experience = %d, want %d", got, 5+7)
+	}
+}
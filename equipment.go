@@ -0,0 +1,207 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+)
+
+// EquipSlot は装備欄の種類
+type EquipSlot int
+
+const (
+	SlotWeapon EquipSlot = iota // 武器
+	SlotArmor                   // 盾・鎧
+	SlotArrow                   // 矢
+	SlotRing1                   // 指輪 (1つ目)
+	SlotRing2                   // 指輪 (2つ目)
+	equipSlotCount
+)
+
+func (s EquipSlot) Label() string {
+	switch s {
+	case SlotWeapon:
+		return "武器"
+	case SlotArmor:
+		return "防具"
+	case SlotArrow:
+		return "矢"
+	case SlotRing1:
+		return "指輪1"
+	case SlotRing2:
+		return "指輪2"
+	default:
+		return "装備"
+	}
+}
+
+// slotsFor returns the slots the item can be equipped in.
+func slotsFor(item Item) []EquipSlot {
+	switch item.(type) {
+	case *Weapon:
+		return []EquipSlot{SlotWeapon}
+	case *Armor:
+		return []EquipSlot{SlotArmor}
+	case *Arrow:
+		return []EquipSlot{SlotArrow}
+	case *Accessory:
+		return []EquipSlot{SlotRing1, SlotRing2}
+	default:
+		return nil
+	}
+}
+
+// equippedSlot returns the slot the item is equipped in.
+func (p *Player) equippedSlot(item Item) (EquipSlot, bool) {
+	for slot, equippedItem := range p.EquippedItems {
+		if equippedItem != nil && equippedItem == item {
+			return EquipSlot(slot), true
+		}
+	}
+	return 0, false
+}
+
+// chooseSlot returns the slot used to equip the item: an empty slot if there is one,
+// otherwise a slot whose item can be removed.
+func (p *Player) chooseSlot(item Item) (EquipSlot, bool) {
+	slots := slotsFor(item)
+	if len(slots) == 0 {
+		return 0, false
+	}
+	for _, slot := range slots {
+		if p.EquippedItems[slot] == nil {
+			return slot, true
+		}
+	}
+	for _, slot := range slots {
+		if !isCursedItem(p.EquippedItems[slot]) {
+			return slot, true
+		}
+	}
+	return slots[0], true
+}
+
+// unequipSlot removes the item in the slot and reverts its stats.
+func (g *Game) unequipSlot(slot EquipSlot) {
+	equippedItem := g.state.Player.EquippedItems[slot]
+	if equippedItem == nil {
+		return
+	}
+	if equipableItem, ok := equippedItem.(Equipable); ok {
+		equipableItem.UpdatePlayerStats(&g.state.Player, false) // Update player's stats when unequipping
+	}
+	g.state.Player.EquippedItems[slot] = nil
+}
+
+// unequipItem removes the item from its slot if it is equipped.
+func (g *Game) unequipItem(item Item) {
+	if slot, ok := g.state.Player.equippedSlot(item); ok {
+		g.unequipSlot(slot)
+	}
+}
+
+// equipItem equips the item in its slot. The item already in the slot is removed
+// unless it is cursed. It returns false if the item could not be equipped.
+func (g *Game) equipItem(equipableItem Equipable) bool {
+	slot, ok := g.state.Player.chooseSlot(equipableItem)
+	if !ok {
+		return false
+	}
+
+	// 同じ装備欄の装備を外して入れ替える
+	if current := g.state.Player.EquippedItems[slot]; current != nil {
+		currentName := getItemNameWithSharpness(current)
+		if isCursedItem(current) {
+			action := Action{
+				Duration:     0.5,
+				Message:      fmt.Sprintf("%sは呪われていてはずせない。", currentName),
+				ItemName:     currentName,
+				Execute:      func(g *Game) {},
+				IsIdentified: true,
+			}
+			g.Enqueue(action)
+			return false
+		}
+		g.unequipSlot(slot)
+		action := Action{
+			Duration:     0.5,
+			Message:      fmt.Sprintf("%sをはずした。", currentName),
+			ItemName:     currentName,
+			Execute:      func(g *Game) {},
+			IsIdentified: true,
+		}
+		g.Enqueue(action)
+	}
+
+	identified := false
+	// equipableItemがAccessory型の場合はIdentifiedをtrueにしない
+	if _, ok := equipableItem.(*Accessory); !ok {
+		equipableItem.SetIdentified(true) // Set the item as identified when equipping
+		identified = true
+	}
+	itemName := getItemNameWithSharpness(equipableItem)
+
+	equipableItem.UpdatePlayerStats(&g.state.Player, true) // Update player's stats when equipping
+	g.state.Player.EquippedItems[slot] = equipableItem
+	if ring, ok := equipableItem.(*Accessory); ok {
+		g.onRingEquipped(ring)
+	}
+
+	action := Action{
+		Duration:     0.5,
+		Message:      fmt.Sprintf("%sを装備した。", itemName),
+		ItemName:     itemName,
+		Execute:      func(g *Game) {},
+		IsIdentified: identified,
+	}
+	g.Enqueue(action)
+
+	// Check if the item is cursed after equipping
+	if isCursedItem(equipableItem) {
+		cursedAction := Action{
+			Duration:     0.5,
+			Message:      fmt.Sprintf("%sは呪われていた。", itemName),
+			ItemName:     itemName,
+			Execute:      func(g *Game) {},
+			IsIdentified: identified,
+		}
+		g.Enqueue(cursedAction)
+	}
+	return true
+}
+
+// unequipSelectedSlot removes the item selected in the equipment screen.
+func (g *Game) unequipSelectedSlot() {
+	equippedItem := g.state.Player.EquippedItems[g.selectedEquipSlot]
+	if equippedItem == nil {
+		return
+	}
+	itemName := getItemNameWithSharpness(equippedItem)
+	message := fmt.Sprintf("%sをはずした。", itemName)
+	if isCursedItem(equippedItem) {
+		message = fmt.Sprintf("%sをはずせない。", itemName)
+	} else {
+		g.unequipSlot(g.selectedEquipSlot)
+	}
+	action := Action{
+		Duration:     0.5,
+		Message:      message,
+		ItemName:     itemName,
+		Execute:      func(g *Game) {},
+		IsIdentified: true,
+	}
+	g.Enqueue(action)
+
+	g.overlays.Close(OverlayEquipment)
+	g.selectedEquipSlot = SlotWeapon
+	g.isActioned = true
+}
+
+// equippedItemText returns the text shown for the item in an equipment slot.
+func equippedItemText(equippedItem Item) string {
+	if equippedItem == nil {
+		return "なし"
+	}
+	return getItemNameWithSharpness(equippedItem)
+}
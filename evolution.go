@@ -0,0 +1,95 @@
+//go:build !test
+// +build !test
+
+package main
+
+import "fmt"
+
+// nextForm returns the enemy ID the enemy evolves into, or -1 if it cannot evolve.
+func nextForm(id int) int {
+	if enemyDefinitions[id].EvolvesTo == "" {
+		return -1
+	}
+	return enemyIDByType(enemyDefinitions[id].EvolvesTo)
+}
+
+// previousForm returns the enemy ID the enemy evolved from, or -1 if it is the first form.
+func previousForm(id int) int {
+	for prev, def := range enemyDefinitions {
+		if def.EvolvesTo == enemyDefinitions[id].Type {
+			return prev
+		}
+	}
+	return -1
+}
+
+// changeForm replaces the enemy at index i with another form of its evolution chain.
+// 位置や思考状態はそのままに、能力値と見た目だけが新しい姿のものになる
+func (g *Game) changeForm(i, id int) {
+	old := g.state.Enemies[i]
+	enemy := newEnemy(id, old.X, old.Y)
+	enemy.UID = old.UID
+	enemy.Direction = old.Direction
+	enemy.State = old.State
+	enemy.PlayerDiscovered = old.PlayerDiscovered
+	enemy.StolenItem = old.StolenItem
+	enemy.StolenCash = old.StolenCash
+	enemy.CarriedItem = old.CarriedItem
+	enemy.GuardX, enemy.GuardY = old.GuardX, old.GuardY
+	enemy.Disguised = false
+	if id == previousForm(old.ID) {
+		enemy.Health = min(old.Health, enemy.MaxHealth) // レベルが下がった場合は回復しない
+	}
+	g.state.Enemies[i] = enemy
+	g.miniMapDirty = true
+}
+
+// levelUpEnemy evolves the enemy at index i into its next form.
+func (g *Game) levelUpEnemy(i int) {
+	if i < 0 || i >= len(g.state.Enemies) {
+		return
+	}
+	next := nextForm(g.state.Enemies[i].ID)
+	if next < 0 {
+		return
+	}
+	name := g.state.Enemies[i].Name
+	g.changeForm(i, next)
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sはレベルが上がって%sになった！", name, g.state.Enemies[i].Name), Execute: func(g *Game) {}})
+}
+
+// levelDownEnemy turns the enemy at index i back into its previous form.
+func (g *Game) levelDownEnemy(i int) {
+	if i < 0 || i >= len(g.state.Enemies) {
+		return
+	}
+	prev := previousForm(g.state.Enemies[i].ID)
+	if prev < 0 {
+		g.Enqueue(Action{Duration: 0.5, Message: "しかし何も起こらなかった。", Execute: func(g *Game) {}})
+		return
+	}
+	name := g.state.Enemies[i].Name
+	g.changeForm(i, prev)
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sはレベルが下がって%sになった。", name, g.state.Enemies[i].Name), Execute: func(g *Game) {}})
+}
+
+// eatThrownItem lets an enemy that eats items swallow the thrown food and level up.
+// It returns false if the enemy does not eat the item.
+func (g *Game) eatThrownItem(i int, item Item) bool {
+	if i < 0 || i >= len(g.state.Enemies) || !enemyDefinitions[g.state.Enemies[i].ID].EatsItems {
+		return false
+	}
+	switch item.(type) {
+	case *Food, *Potion:
+	default:
+		return false
+	}
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sは%sを食べた。", g.state.Enemies[i].Name, item.GetName()), Execute: func(g *Game) {}})
+	g.levelUpEnemy(i)
+	return true
+}
+
+// levelDownCane turns the enemy hit by the cane back into its previous form.
+func levelDownCane(g *Game) {
+	g.levelDownEnemy(g.TargetEnemyIndex)
+}
@@ -0,0 +1,164 @@
+//go:build !test
+// +build !test
+
+package main
+
+import "fmt"
+
+// SavedFloor は離れたフロアの様子。戻ってきたときにそのまま元に戻す
+type SavedFloor struct {
+	Map          [][]Tile
+	Enemies      []Enemy
+	Items        []Item
+	Traps        []FloorTrap
+	Rooms        []Room
+	PlayerPos    Coordinate // 離れたときに海老さんがいた場所 (戻るとここに立つ)
+	Turns        int
+	BossFloor    bool
+	BossDefeated bool
+}
+
+// FloorCache は離れたフロアを場所ごとに覚えておく
+type FloorCache map[FloorKey]*SavedFloor
+
+// currentDungeon returns the dungeon the player is in: the main dungeon or the branch.
+func (g *Game) currentDungeon() *DungeonDef {
+	if b, ok := g.dungeon.branch(g.location.Branch); ok {
+		return g.dungeon.branchDungeon(b)
+	}
+	return g.dungeon
+}
+
+// changeFloor leaves the current floor, keeping it in the floor cache, and moves the
+// player to the floor. A floor visited before is restored as it was left.
+func (g *Game) changeFloor(to FloorKey) {
+	g.floorCache[g.location] = &SavedFloor{
+		Map:          g.state.Map,
+		Enemies:      g.state.Enemies,
+		Items:        g.state.Items,
+		Traps:        g.state.Traps,
+		Rooms:        g.rooms,
+		PlayerPos:    Coordinate{X: g.state.Player.X, Y: g.state.Player.Y},
+		Turns:        g.floorTurns,
+		BossFloor:    g.bossFloor,
+		BossDefeated: g.bossDefeated,
+	}
+	g.location = to
+	g.Floor = to.Floor
+	g.miniMap = nil
+	g.ignoreStairs = true // 着いた階段の上では確認を出さない
+	g.updateTheme()
+
+	if saved, ok := g.floorCache[to]; ok {
+		delete(g.floorCache, to)
+		g.state.Map = saved.Map
+		g.state.Enemies = saved.Enemies
+		g.state.Items = saved.Items
+		g.state.Traps = saved.Traps
+		g.rooms = saved.Rooms
+		g.state.Player.X, g.state.Player.Y = saved.PlayerPos.X, saved.PlayerPos.Y
+		g.floorTurns = saved.Turns
+		g.bossFloor = saved.BossFloor
+		g.bossDefeated = saved.BossDefeated
+		return
+	}
+
+	mapGrid, enemies, items, traps, newRoom := GenerateRandomMap(g.currentDungeon(), to.Floor, &g.state.Player)
+	g.state.Map = mapGrid
+	g.state.Enemies = enemies
+	g.state.Items = items
+	g.state.Traps = traps
+	g.rooms = newRoom
+	g.floorTurns = 0
+	g.bossFloor = g.hasBoss() // ボスの地図が読めずに普通のフロアになったときはボスフロアにしない
+	g.bossDefeated = false
+	g.placeStairs()
+	if isDarkFloor(g.rooms) {
+		g.Enqueue(Action{Duration: 0.5, Message: "このフロアは暗い…", Execute: func(g *Game) {}})
+	}
+}
+
+// placeStairs links a new floor to the others: the up stairs under the player and the
+// stairs down to the branch that starts here. The deepest floor of a branch has no
+// down stairs, and its treasure lies there instead.
+func (g *Game) placeStairs() {
+	player := g.state.Player
+	if _, ok := g.dungeon.stairsDestination(g.location, stairsUp); ok {
+		g.state.Map[player.Y][player.X] = Tile{Type: stairsUp, Blocked: false, BlockSight: false}
+	}
+	if _, ok := g.dungeon.stairsDestination(g.location, stairsBranch); ok {
+		if p, ok := g.freeFloorTile(); ok {
+			g.state.Map[p.Y][p.X] = Tile{Type: stairsBranch, Blocked: false, BlockSight: false}
+		}
+	}
+
+	b, ok := g.dungeon.branch(g.location.Branch)
+	if !ok || g.location.Floor < g.dungeon.lastFloor(b.Name) {
+		return
+	}
+	for y, row := range g.state.Map {
+		for x, tile := range row {
+			if tile.Type == stairsDown {
+				g.state.Map[y][x] = Tile{Type: "floor", Blocked: false, BlockSight: false, Visited: tile.Visited}
+			}
+		}
+	}
+	for i := 0; i < b.RewardItems; i++ {
+		if p, ok := g.freeFloorTile(); ok {
+			g.state.Items = append(g.state.Items, g.currentDungeon().createItem(p.X, p.Y))
+		}
+	}
+}
+
+// freeFloorTile returns a random empty floor tile in a room that the player can reach.
+// 部屋のないフロア (洞窟) ではどの床でもよい
+func (g *Game) freeFloorTile() (Coordinate, bool) {
+	player := g.state.Player
+	reachable := floodFill(g.state.Map, Coordinate{X: player.X, Y: player.Y})
+	var candidates []Coordinate
+	for y, row := range g.state.Map {
+		for x, tile := range row {
+			if tile.Type != "floor" || !reachable[y][x] || (x == player.X && y == player.Y) ||
+				(len(g.rooms) > 0 && !isInsideRoom(x, y, g.rooms)) ||
+				itemAt(g.state.Items, x, y) || enemyAt(g.state.Enemies, x, y) {
+				continue
+			}
+			candidates = append(candidates, Coordinate{X: x, Y: y})
+		}
+	}
+	if len(candidates) == 0 {
+		return Coordinate{}, false
+	}
+	return candidates[localRand.Intn(len(candidates))], true
+}
+
+// stairsLabel returns the prompt option that takes the stairs the player stands on.
+func (g *Game) stairsLabel() string {
+	kind := g.state.Map[g.state.Player.Y][g.state.Player.X].Type
+	to, ok := g.dungeon.stairsDestination(g.location, kind)
+	if !ok {
+		return "ダンジョンを出る"
+	}
+	label := fmt.Sprintf("B%dFへ降りる", to.Floor)
+	switch {
+	case kind == stairsBranch:
+		label = fmt.Sprintf("%s B%dFへ", to.Branch, to.Floor)
+	case kind == stairsUp && to.Branch != g.location.Branch:
+		label = fmt.Sprintf("本道 B%dFへ戻る", to.Floor)
+	case kind == stairsUp:
+		label = fmt.Sprintf("B%dFへ戻る", to.Floor)
+	}
+	if _, visited := g.floorCache[to]; visited {
+		label += " (来たことがある)"
+	}
+	return label
+}
+
+// windStairs returns the stairs the wind blows the player through: down, or back up
+// from the deepest floor of a branch.
+func (g *Game) windStairs() string {
+	if _, ok := g.dungeon.stairsDestination(g.location, stairsDown); !ok && g.location.Branch != "" {
+		return stairsUp
+	}
+	return stairsDown
+}
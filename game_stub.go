@@ -9,6 +9,11 @@ type Tile struct {
 	BlockSight bool
 	Visited    bool
 	Brightness float64
+	Variant    int
+}
+
+type Coordinate struct {
+	X, Y int
 }
 
 type Entity struct {
@@ -18,17 +23,36 @@ type Entity struct {
 
 type Player struct {
 	Entity
+	ExperiencePoints int
 }
 
 type Enemy struct {
 	Entity
+	ID               int
+	UID              int
+	Type             string
+	Health           int
+	ExperiencePoints int
+}
+
+type EnemyDefinition struct {
+	Type               string
+	MinFloor, MaxFloor int
+	Unique             bool
+}
+
+var enemyDefinitions []EnemyDefinition
+
+func newEnemy(id, x, y int) Enemy {
+	return Enemy{Entity: Entity{X: x, Y: y}, ID: id, Type: enemyDefinitions[id].Type}
 }
 
 type Item interface{}
 
 type GameState struct {
-	Map    [][]Tile
-	Player Player
+	Map     [][]Tile
+	Player  Player
+	Enemies []Enemy
 }
 
 type Game struct {
@@ -0,0 +1,134 @@
+package main
+
+import "fmt"
+
+// 部屋どうしをつなぐ通路の引き方 (GenParams の CorridorStyle)
+const (
+	corridorRing = "ring" // 最寄りの部屋とつなぎ、全ての部屋を輪のようにつなぐ (従来の引き方)
+	corridorTree = "tree" // 最寄りの部屋と順番につなぐだけで、輪を作らない
+)
+
+const (
+	referenceMapSize    = 70  // 既定の設定の基準にする地図の大きさ
+	defaultRoomAttempts = 100 // 1部屋を置くのを試す回数
+	minRoomSize         = 5   // 壁を含む部屋の大きさの下限 (床が3x3)
+	maxSpawnRooms       = 12  // 既定の設定で作る部屋の数の上限
+)
+
+// GenParams は部屋をばらまく地図生成の設定。0の項目は地図の大きさに合わせた既定値になる
+type GenParams struct {
+	Width, Height                    int     // 地図の大きさ (FloorDef の大きさが入る)
+	MinRooms, MaxRooms               int     // 作ろうとする部屋の数の範囲
+	MinRoomSize, MaxRoomSize         int     // 部屋の幅と高さの範囲 (壁を含む)
+	RoomSeparation                   int     // 部屋と部屋の間に空ける隙間
+	MinRoomDistance, MaxRoomDistance int     // 部屋の中心どうしの距離の範囲
+	CorridorStyle                    string  // 通路の引き方 (corridorRing か corridorTree)
+	ExtraLoopChance                  float64 // 部屋ごとに別の部屋へ余分な通路を引いて輪を作る確率
+	RoomAttempts                     int     // 1部屋を置くのを試す回数
+}
+
+// scaledGenParams returns the default parameters for a map of the size. The room
+// count and the largest room are those of the old generator on a 70x70 map and scale
+// with the map on other sizes. MaxRoomDistance spans the whole map, so only
+// MinRoomDistance keeps the room centers apart.
+func scaledGenParams(width, height int) GenParams {
+	rooms := min(max(6*width*height/(referenceMapSize*referenceMapSize), 2), maxSpawnRooms)
+	return GenParams{
+		Width:           width,
+		Height:          height,
+		MinRooms:        rooms,
+		MaxRooms:        rooms,
+		MinRoomSize:     6,
+		MaxRoomSize:     min(max(15*min(width, height)/referenceMapSize, 6), 15),
+		RoomSeparation:  5,
+		MinRoomDistance: 10,
+		MaxRoomDistance: width + height,
+		CorridorStyle:   corridorRing,
+		RoomAttempts:    defaultRoomAttempts,
+	}
+}
+
+// withDefaults returns the parameters for a map of the size, filling the fields left
+// at zero with the defaults of scaledGenParams.
+func (p GenParams) withDefaults(width, height int) GenParams {
+	def := scaledGenParams(width, height)
+	p.Width, p.Height = width, height
+	if p.MinRooms == 0 && p.MaxRooms == 0 {
+		p.MinRooms, p.MaxRooms = def.MinRooms, def.MaxRooms
+	} else if p.MaxRooms == 0 {
+		p.MaxRooms = max(p.MinRooms, def.MaxRooms)
+	} else if p.MinRooms == 0 {
+		p.MinRooms = min(p.MaxRooms, def.MinRooms)
+	}
+	if p.MinRoomSize == 0 {
+		p.MinRoomSize = def.MinRoomSize
+	}
+	if p.MaxRoomSize == 0 {
+		p.MaxRoomSize = max(p.MinRoomSize, def.MaxRoomSize)
+	}
+	if p.RoomSeparation == 0 {
+		p.RoomSeparation = def.RoomSeparation
+	}
+	if p.MinRoomDistance == 0 {
+		p.MinRoomDistance = def.MinRoomDistance
+	}
+	if p.MaxRoomDistance == 0 {
+		p.MaxRoomDistance = def.MaxRoomDistance
+	}
+	if p.CorridorStyle == "" {
+		p.CorridorStyle = def.CorridorStyle
+	}
+	if p.RoomAttempts == 0 {
+		p.RoomAttempts = def.RoomAttempts
+	}
+	return p
+}
+
+// validate reports whether rooms can be placed with the parameters, so that a bad
+// setting is caught when the dungeon is loaded instead of while a floor is generated.
+func (p GenParams) validate() error {
+	switch {
+	case p.MinRooms < 1 || p.MaxRooms < p.MinRooms:
+		return fmt.Errorf("room count %d-%d is invalid", p.MinRooms, p.MaxRooms)
+	case p.MinRoomSize < minRoomSize || p.MaxRoomSize < p.MinRoomSize:
+		return fmt.Errorf("room size %d-%d is invalid (the smallest room is %d)", p.MinRoomSize, p.MaxRoomSize, minRoomSize)
+	case p.MaxRoomSize > min(p.Width, p.Height)-2:
+		return fmt.Errorf("room size %d does not fit in a %dx%d map", p.MaxRoomSize, p.Width, p.Height)
+	case p.RoomSeparation < 0:
+		return fmt.Errorf("room separation must not be negative")
+	case p.MinRoomDistance < 0 || p.MaxRoomDistance < p.MinRoomDistance:
+		return fmt.Errorf("room distance %d-%d is invalid", p.MinRoomDistance, p.MaxRoomDistance)
+	case p.CorridorStyle != corridorRing && p.CorridorStyle != corridorTree:
+		return fmt.Errorf("unknown corridor style %q", p.CorridorStyle)
+	case p.ExtraLoopChance < 0 || p.ExtraLoopChance > 1:
+		return fmt.Errorf("extra loop chance must be between 0 and 1")
+	case p.RoomAttempts < 1:
+		return fmt.Errorf("room attempts must be at least 1")
+	}
+	// 最小の部屋が隙間を空けて最小の数だけ並ぶ広さがあるか
+	cell := p.MinRoomSize + p.RoomSeparation
+	if (p.Width-2)/cell*((p.Height-2)/cell) < p.MinRooms {
+		return fmt.Errorf("%d rooms of size %d do not fit in a %dx%d map", p.MinRooms, p.MinRoomSize, p.Width, p.Height)
+	}
+	return nil
+}
+
+// pickSpawns picks up to count different spawn points that pass the check, in the
+// order of perm (a permutation such as rand.Perm). It returns fewer points when there
+// are not enough, so a small map never waits for a free spot that does not exist.
+func pickSpawns(spawns []Coordinate, count int, ok func(Coordinate) bool, perm func(int) []int) []Coordinate {
+	var picked []Coordinate
+	taken := make(map[Coordinate]bool)
+	for _, i := range perm(len(spawns)) {
+		if len(picked) >= count {
+			break
+		}
+		spawn := spawns[i]
+		if taken[spawn] || (ok != nil && !ok(spawn)) {
+			continue
+		}
+		taken[spawn] = true
+		picked = append(picked, spawn)
+	}
+	return picked
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestScaledGenParams(t *testing.T) {
+	if p := scaledGenParams(referenceMapSize, referenceMapSize); p.MinRooms != 6 || p.MaxRoomSize != 15 {
+		t.Errorf("70x70 params = %+v, want 6 rooms of size 6-15", p)
+	}
+	// 既定の設定は許される全ての大きさの地図で使える
+	for _, size := range [][2]int{{minMapSize, minMapSize}, {minMapSize, maxMapSize}, {maxMapSize, maxMapSize}} {
+		if err := scaledGenParams(size[0], size[1]).validate(); err != nil {
+			t.Errorf("scaledGenParams(%d, %d): %v", size[0], size[1], err)
+		}
+	}
+}
+
+func TestGenParamsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		p    GenParams
+	}{
+		{"too few rooms", GenParams{MaxRooms: 3, MinRooms: 4}},
+		{"room larger than the map", GenParams{MinRoomSize: 39}},
+		{"tiny rooms", GenParams{MinRoomSize: 2}},
+		{"too many rooms", GenParams{MinRooms: 40}},
+		{"unknown corridor", GenParams{CorridorStyle: "zigzag"}},
+		{"loop chance", GenParams{ExtraLoopChance: 2}},
+	}
+	for _, tt := range tests {
+		if err := tt.p.withDefaults(minMapSize, minMapSize).validate(); err == nil {
+			t.Errorf("%s: validate returned no error", tt.name)
+		}
+	}
+	p := GenParams{MinRooms: 3, CorridorStyle: corridorTree}.withDefaults(minMapSize, minMapSize)
+	if err := p.validate(); err != nil || p.MaxRooms < 3 {
+		t.Errorf("withDefaults = %+v, %v, want a valid range from 3 rooms", p, err)
+	}
+}
+
+func TestPickSpawns(t *testing.T) {
+	identity := func(n int) []int {
+		perm := make([]int, n)
+		for i := range perm {
+			perm[i] = i
+		}
+		return perm
+	}
+	spawns := []Coordinate{{X: 1, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}}
+	// 置ける場所より多く頼んでも止まり、同じ場所には置かない
+	if got := pickSpawns(spawns, 10, nil, identity); len(got) != 3 {
+		t.Errorf("pickSpawns = %v, want the 3 different points", got)
+	}
+	farFromStart := func(c Coordinate) bool { return c.X >= 2 }
+	if got := pickSpawns(spawns, 1, farFromStart, identity); len(got) != 1 || got[0].X != 2 {
+		t.Errorf("pickSpawns with a check = %v, want [{2 1}]", got)
+	}
+}
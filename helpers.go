@@ -1,5 +1,12 @@
 package main
 
+import (
+	"math/rand"
+	"time"
+)
+
+var localRand *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -21,6 +28,15 @@ func abs(x int) int {
 	return x
 }
 
+// moveCursor moves a cursor in a list of count entries by delta, stopping at the
+// first and the last entry.
+func moveCursor(pos, delta, count int) int {
+	if count == 0 {
+		return 0
+	}
+	return min(max(pos+delta, 0), count-1)
+}
+
 // sign function returns the sign of an integer.
 func sign(x int) int {
 	if x > 0 {
@@ -65,3 +65,20 @@ func TestSign(t *testing.T) {
 		}
 	}
 }
+
+func TestMoveCursor(t *testing.T) {
+	tests := []struct {
+		pos, delta, count int
+		want              int
+	}{
+		{7, 10, 15, 14}, // 短い最後のページにも移れる
+		{12, -10, 15, 2},
+		{3, -10, 15, 0},
+		{0, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := moveCursor(tt.pos, tt.delta, tt.count); got != tt.want {
+			t.Errorf("moveCursor(%d, %d, %d) = %d, want %d", tt.pos, tt.delta, tt.count, got, tt.want)
+		}
+	}
+}
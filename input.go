@@ -6,60 +6,103 @@ package main
 import (
 	_ "image/png" // PNG画像を読み込むために必要
 	"log"
-	"sort"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
+// doorDirections は扉を開け閉めできる方向 (斜めの扉には手が届かない)
+var doorDirections = []Coordinate{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+
+// OpenDoor opens the doors next to the player. 鍵のかかった扉は鍵を持っていれば開く
+// 開ける扉がなければ、隣の開いた扉を閉める
 func (g *Game) OpenDoor() {
 	playerX, playerY := g.state.Player.X, g.state.Player.Y
-	directions := []struct{ dx, dy int }{
-		{0, -1}, // Up
-		{0, 1},  // Down
-		{-1, 0}, // Left
-		{1, 0},  // Right
-	}
-	for _, dir := range directions {
-		nx, ny := playerX+dir.dx, playerY+dir.dy
+	changed := false
+	for _, dir := range doorDirections {
+		nx, ny := playerX+dir.X, playerY+dir.Y
 		if ny < 0 || ny >= len(g.state.Map) || nx < 0 || nx >= len(g.state.Map[0]) {
 			continue
 		}
-		tile := g.state.Map[ny][nx]
-		if tile.Type == "door" {
-			g.state.Map[ny][nx] = Tile{Type: "corridor"}
-			g.isActioned = true
+		next, result := doorTransition(g.state.Map[ny][nx].Type, g.keyIndex() >= 0, false)
+		switch result {
+		case doorOpened:
+			placeDoor(g.state.Map, nx, ny, next)
+			changed = true
+		case doorUnlocked:
+			i := g.keyIndex()
+			g.state.Player.Inventory = append(g.state.Player.Inventory[:i], g.state.Player.Inventory[i+1:]...)
+			g.unlockDoor(nx, ny)
+			changed = true
+		case doorStillLocked:
+			g.Enqueue(Action{Duration: 0.4, Message: "扉には鍵がかかっている。", Execute: func(g *Game) {}})
+		}
+	}
+
+	if !changed {
+		for _, dir := range doorDirections {
+			nx, ny := playerX+dir.X, playerY+dir.Y
+			if ny < 0 || ny >= len(g.state.Map) || nx < 0 || nx >= len(g.state.Map[0]) {
+				continue
+			}
+			if isOccupied(g, nx, ny) || itemAt(g.state.Items, nx, ny) {
+				continue
+			}
+			if next, result := doorTransition(g.state.Map[ny][nx].Type, false, true); result == doorShut {
+				placeDoor(g.state.Map, nx, ny, next)
+				changed = true
+			}
 		}
 	}
+
+	if changed {
+		g.isActioned = true
+		g.miniMapDirty = true
+	}
+}
+
+// keyIndex returns the index of a key in the inventory, or -1 if the player has none.
+func (g *Game) keyIndex() int {
+	for i, item := range g.state.Player.Inventory {
+		if _, ok := item.(*Key); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// adjacentDoor returns the position of a door of the given kind next to the player.
+func (g *Game) adjacentDoor(kind string) (int, int, bool) {
+	for _, dir := range doorDirections {
+		nx, ny := g.state.Player.X+dir.X, g.state.Player.Y+dir.Y
+		if ny >= 0 && ny < len(g.state.Map) && nx >= 0 && nx < len(g.state.Map[0]) && g.state.Map[ny][nx].Type == kind {
+			return nx, ny, true
+		}
+	}
+	return 0, 0, false
+}
+
+// unlockDoor opens the locked door at (x, y) with a key.
+func (g *Game) unlockDoor(x, y int) {
+	placeDoor(g.state.Map, x, y, doorOpen)
+	g.miniMapDirty = true
+	g.Enqueue(Action{Duration: 0.4, Message: "鍵を使って扉を開けた。", Execute: func(g *Game) {}})
 }
 
 func (g *Game) processDKeyPress() {
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyD) && !g.showInventory && !g.isCombatActive && !g.ShowGroundItem && !g.showStairsPrompt {
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) && g.overlays.Empty() && !g.isCombatActive {
 		g.dPressed = true
 		// Find the equipped Arrow item
-		var equippedArrow *Arrow
-		for _, item := range g.state.Player.Inventory {
-			if arrow, ok := item.(*Arrow); ok {
-				for i, equippedItem := range g.state.Player.EquippedItems {
-					if equippedItem == arrow {
-						equippedArrow = arrow
-
-						// If an Arrow item is equipped, decrement its ShotCount
-						equippedArrow.ShotCount--
-
-						// Check if ShotCount becomes 0, and if so, set the corresponding slot in EquippedItems to nil
-						if equippedArrow.ShotCount == 0 {
-							g.state.Player.EquippedItems[i] = nil
-						}
+		equippedArrow, _ := g.state.Player.EquippedItems[SlotArrow].(*Arrow)
+		if equippedArrow != nil {
+			// If an Arrow item is equipped, decrement its ShotCount
+			equippedArrow.ShotCount--
 
-						break // Break the inner loop as the equipped arrow is found
-					}
-				}
-				if equippedArrow != nil {
-					break // Break the outer loop if equippedArrow is found
-				}
+			// Check if ShotCount becomes 0, and if so, clear the arrow slot
+			if equippedArrow.ShotCount == 0 {
+				g.state.Player.EquippedItems[SlotArrow] = nil
 			}
 		}
 
@@ -71,6 +114,8 @@ func (g *Game) processDKeyPress() {
 				ShotCount:   1,
 				AttackPower: equippedArrow.AttackPower,
 				Cursed:      equippedArrow.Cursed,
+				Blessed:     equippedArrow.Blessed,
+				CurseKnown:  equippedArrow.CurseKnown,
 				Identified:  equippedArrow.Identified,
 			}
 			throwRange := 10
@@ -100,16 +145,21 @@ func (g *Game) processDKeyPress() {
 
 func (g *Game) HandleGroundItemInput() {
 	sPressed := inpututil.IsKeyJustPressed(ebiten.KeyS)
-	if sPressed && !g.showInventory && !g.isCombatActive && !g.ShowGroundItem && !g.showStairsPrompt && !g.ignoreStairs {
-		g.ShowGroundItem = true
+	if sPressed && g.overlays.Empty() && !g.isCombatActive && !g.ignoreStairs {
+		g.overlays.Open(OverlayGroundItem)
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyX) && g.ShowGroundItem {
-		g.ShowGroundItem = false
+	if g.overlays.Top() != OverlayGroundItem {
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyX) {
+		g.overlays.Close(OverlayGroundItem)
 		g.selectedGroundActionIndex = 0
+		return
 	}
 
-	if g.ShowGroundItem && g.currentGroundItem != nil {
+	if g.currentGroundItem != nil {
 		if inpututil.IsKeyJustPressed(ebiten.KeyUp) && g.selectedGroundActionIndex > 0 {
 			g.selectedGroundActionIndex--
 		} else if inpututil.IsKeyJustPressed(ebiten.KeyDown) && g.selectedGroundActionIndex < 3 {
@@ -125,6 +175,29 @@ func (g *Game) HandleGroundItemInput() {
 	}
 }
 
+func (g *Game) handleEquipmentInput() {
+	ePressed := inpututil.IsKeyJustPressed(ebiten.KeyE)
+	if ePressed && g.overlays.Empty() && !g.isCombatActive && g.CanAcceptInput() {
+		g.overlays.Open(OverlayEquipment)
+		return
+	}
+
+	if !g.overlays.Has(OverlayEquipment) {
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && g.selectedEquipSlot > 0 {
+		g.selectedEquipSlot--
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyDown) && g.selectedEquipSlot < equipSlotCount-1 {
+		g.selectedEquipSlot++
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		g.unequipSelectedSlot() // 選択中の装備欄の装備をはずす
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyX) || ePressed {
+		g.overlays.Close(OverlayEquipment)
+		g.selectedEquipSlot = SlotWeapon
+	}
+}
+
 func (g *Game) handleItemActionsInput() error {
 	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && g.selectedActionIndex > 0 {
 		g.selectedActionIndex--
@@ -138,7 +211,7 @@ func (g *Game) handleItemActionsInput() error {
 	}
 
 	if inpututil.IsKeyJustPressed(ebiten.KeyX) {
-		g.showItemActions = false // Toggle the item actions menu
+		g.overlays.Close(OverlayItemActions) // Toggle the item actions menu
 		g.selectedActionIndex = 0
 		return nil
 	}
@@ -147,38 +220,43 @@ func (g *Game) handleItemActionsInput() error {
 }
 
 func (g *Game) handleInventoryNavigationInput() error {
-	if inpututil.IsKeyJustPressed(ebiten.KeyUp) && g.selectedItemIndex > 0 {
-		g.selectedItemIndex--
-	} else if inpututil.IsKeyJustPressed(ebiten.KeyDown) && g.selectedItemIndex < len(g.state.Player.Inventory)-1 {
-		g.selectedItemIndex++
-	} else if inpututil.IsKeyJustPressed(ebiten.KeyLeft) && g.selectedItemIndex >= 10 {
-		g.selectedItemIndex -= 10
-	} else if inpututil.IsKeyJustPressed(ebiten.KeyRight) && g.selectedItemIndex < len(g.state.Player.Inventory)-10 {
-		g.selectedItemIndex += 10
+	// 絞り込みで選択中のアイテムが隠れている場合はカーソルを表示中のアイテムに合わせる
+	g.moveInventoryCursor(0)
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		g.moveInventoryCursor(-1)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		g.moveInventoryCursor(1)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		g.moveInventoryCursor(-itemsPerPage) // 前のページへ
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		g.moveInventoryCursor(itemsPerPage) // 次のページへ
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		g.cycleInventoryFilter() // 表示するアイテムの種類を切り替える
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyZ) && len(g.state.Player.Inventory) > 0 {
-		if g.selectedGroundActionIndex == 1 && g.showInventory {
+		if g.selectedGroundActionIndex == 1 && g.overlays.Has(OverlayInventory) {
 			if len(g.state.Player.Inventory) > 0 {
 				g.executeItemSwap() // execute your item swapping function here
 				g.selectedGroundActionIndex = 0
-				g.showInventory = false
+				g.overlays.Close(OverlayInventory)
 			}
-		} else if g.useidentifyItem && g.tmpselectedItemIndex != g.selectedItemIndex {
-			g.executeItemIdentify()
-		} else if !g.useidentifyItem {
-			g.showItemActions = true // Toggle the item actions menu
+		} else if g.overlays.Has(OverlayItemSelect) && g.tmpselectedItemIndex != g.selectedItemIndex {
+			g.executeItemSelect()
+		} else if !g.overlays.Has(OverlayItemSelect) {
+			g.overlays.Open(OverlayItemActions) // Toggle the item actions menu
 		}
-	} else if inpututil.IsKeyJustPressed(ebiten.KeyX) && g.useidentifyItem {
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyX) && g.overlays.Has(OverlayItemSelect) {
 		g.selectedItemIndex = 0
 		g.selectedActionIndex = 0
 		g.tmpselectedItemIndex = -1
-		g.useidentifyItem = false
+		g.overlays.Close(OverlayItemSelect)
+		g.itemSelectPurpose = SelectIdentify
+		g.selectSourceItem = nil
 	}
 
 	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
-		// Sort the inventory by ID
-		sort.Slice(g.state.Player.Inventory, func(i, j int) bool {
-			return g.state.Player.Inventory[i].GetID() < g.state.Player.Inventory[j].GetID()
-		})
+		// Sort the inventory by kind (装備中のアイテムは先頭に固定)
+		g.sortInventory()
 
 		// Initialize a map to keep track of Arrow items with the same ID
 		arrowItemsMap := make(map[int][]*Arrow)
@@ -230,6 +308,7 @@ func (g *Game) handleInventoryNavigationInput() error {
 				g.state.Player.Inventory = newInventory // Update the player's inventory
 			}
 		}
+		g.moveInventoryCursor(0) // 矢がまとまって減ったときもカーソルを持ち物の上に置く
 		return nil
 	}
 
@@ -238,7 +317,7 @@ func (g *Game) handleInventoryNavigationInput() error {
 
 func (g *Game) handleItemDescriptionInput() error {
 	if inpututil.IsKeyJustPressed(ebiten.KeyX) {
-		g.showItemDescription = false // Toggle the item description
+		g.overlays.Close(OverlayItemDescription) // Toggle the item description
 		return nil
 	}
 
@@ -247,32 +326,30 @@ func (g *Game) handleItemDescriptionInput() error {
 
 func (g *Game) handleInventoryInput() error {
 	cPressed := inpututil.IsKeyJustPressed(ebiten.KeyC)
-	if cPressed && !g.ShowGroundItem && !g.showStairsPrompt && !g.showInventory {
-		g.showInventory = true
+	if cPressed && g.overlays.Empty() {
+		g.inventoryFilter = kindAll
+		g.overlays.Open(OverlayInventory)
 		return nil // Skip other updates when the inventory window is active
 	}
 
 	xPressed := inpututil.IsKeyJustPressed(ebiten.KeyX)
 
-	if xPressed && g.showInventory && !g.showItemActions && !g.useidentifyItem {
+	if xPressed && g.overlays.Top() == OverlayInventory && !g.overlays.Has(OverlayItemSelect) {
 		g.selectedItemIndex = 0
 		g.selectedActionIndex = 0
 		g.selectedGroundActionIndex = 0
-		g.showInventory = false
+		g.overlays.Close(OverlayInventory)
 		return nil // Skip other updates when the inventory window is active
 	}
 
-	if g.showInventory {
-
-		if g.showItemActions && !g.showItemDescription {
-			return g.handleItemActionsInput()
-		} else if !g.showItemActions && !g.showItemDescription {
-			return g.handleInventoryNavigationInput()
-		} else if g.showItemDescription {
-			return g.handleItemDescriptionInput()
-		}
-
-		return nil // Skip other updates when the inventory window is active
+	// 一番上のウィンドウがキー入力を受け取る
+	switch g.overlays.Top() {
+	case OverlayInventory:
+		return g.handleInventoryNavigationInput()
+	case OverlayItemActions:
+		return g.handleItemActionsInput()
+	case OverlayItemDescription:
+		return g.handleItemDescriptionInput()
 	}
 
 	return nil
@@ -133,18 +133,20 @@ type Equipable interface {
 // UpdatePlayerStats is a method to update player stats when equipping/unequipping an item
 // This method needs to be implemented by each equipable item type (Weapon, Armor, Arrow, Accessory)
 func (w *Weapon) UpdatePlayerStats(player *Player, equip bool) {
+	bonus := w.AttackPower + w.Sharpness + curseStatModifier(w)
 	if equip {
-		player.AttackPower += w.AttackPower + w.Sharpness
+		player.AttackPower += bonus
 	} else {
-		player.AttackPower -= w.AttackPower + w.Sharpness
+		player.AttackPower -= bonus
 	}
 }
 
 func (a *Armor) UpdatePlayerStats(player *Player, equip bool) {
+	bonus := a.DefensePower + a.Sharpness + curseStatModifier(a)
 	if equip {
-		player.DefensePower += a.DefensePower + a.Sharpness
+		player.DefensePower += bonus
 	} else {
-		player.DefensePower -= a.DefensePower + a.Sharpness
+		player.DefensePower -= bonus
 	}
 }
 
@@ -154,30 +156,9 @@ func (ar *Arrow) UpdatePlayerStats(player *Player, equip bool) {
 }
 
 func (ac *Accessory) UpdatePlayerStats(player *Player, equip bool) {
-	// Accessories might affect various stats
-	// Implement logic accordingly
-	// もし鼓舞の指輪を装備した場合は、プレイヤーのパワーとパワーの最大値を3上昇させる
-	if ac.Name == "鼓舞の指輪" {
-		if equip {
-			// もし鼓舞の指輪が呪われている場合は、プレイヤーのパワーとパワーの最大値を3減少させる
-			if ac.Cursed {
-				player.Power -= 3
-				player.MaxPower -= 3
-			} else {
-				player.Power += 3
-				player.MaxPower += 3
-			}
-		} else {
-			// もし鼓舞の指輪が呪われている場合は、プレイヤーのパワーとパワーの最大値を3上昇させる
-			if ac.Cursed {
-				player.Power += 3
-				player.MaxPower += 3
-			} else {
-				player.Power -= 3
-				player.MaxPower -= 3
-			}
-		}
-	}
+	// アクセサリの能力変化はStatsに定義されている
+	// 呪われている場合は効果が反転し、祝福されている場合は効果が強まる
+	ac.Stats.withCurse(ac.Cursed, ac.Blessed).apply(player, equip)
 }
 
 func (bi BaseItem) GetID() int {
@@ -208,6 +189,102 @@ func (bi *BaseItem) SetPosition(x, y int) {
 	bi.X, bi.Y = x, y
 }
 
+func (w *Weapon) IsCursed() bool {
+	return w.Cursed
+}
+
+func (a *Armor) IsCursed() bool {
+	return a.Cursed
+}
+
+func (a *Arrow) IsCursed() bool {
+	return a.Cursed
+}
+
+func (ac *Accessory) IsCursed() bool {
+	return ac.Cursed
+}
+
+func (w *Weapon) SetCursed(value bool) {
+	w.Cursed = value
+}
+
+func (a *Armor) SetCursed(value bool) {
+	a.Cursed = value
+}
+
+func (a *Arrow) SetCursed(value bool) {
+	a.Cursed = value
+}
+
+func (ac *Accessory) SetCursed(value bool) {
+	ac.Cursed = value
+}
+
+func (w *Weapon) IsBlessed() bool {
+	return w.Blessed
+}
+
+func (a *Armor) IsBlessed() bool {
+	return a.Blessed
+}
+
+func (a *Arrow) IsBlessed() bool {
+	return a.Blessed
+}
+
+func (ac *Accessory) IsBlessed() bool {
+	return ac.Blessed
+}
+
+func (w *Weapon) SetBlessed(value bool) {
+	w.Blessed = value
+}
+
+func (a *Armor) SetBlessed(value bool) {
+	a.Blessed = value
+}
+
+func (a *Arrow) SetBlessed(value bool) {
+	a.Blessed = value
+}
+
+func (ac *Accessory) SetBlessed(value bool) {
+	ac.Blessed = value
+}
+
+func (w *Weapon) IsCurseKnown() bool {
+	return w.CurseKnown
+}
+
+func (a *Armor) IsCurseKnown() bool {
+	return a.CurseKnown
+}
+
+func (a *Arrow) IsCurseKnown() bool {
+	return a.CurseKnown
+}
+
+func (ac *Accessory) IsCurseKnown() bool {
+	return ac.CurseKnown
+}
+
+func (w *Weapon) SetCurseKnown(value bool) {
+	w.CurseKnown = value
+}
+
+func (a *Armor) SetCurseKnown(value bool) {
+	a.CurseKnown = value
+}
+
+func (a *Arrow) SetCurseKnown(value bool) {
+	a.CurseKnown = value
+}
+
+func (ac *Accessory) SetCurseKnown(value bool) {
+	ac.CurseKnown = value
+}
+
 type Identifiable interface {
 	IsIdentified() bool
 	GetName() string
@@ -235,6 +312,10 @@ func (c *Cane) IsIdentified() bool {
 	return c.Identified
 }
 
+func (p *Pot) IsIdentified() bool {
+	return p.Identified
+}
+
 func (m *Money) IsIdentified() bool {
 	return m.Identified
 }
@@ -262,6 +343,10 @@ func (c *Cane) SetIdentified(value bool) {
 	c.Identified = value
 }
 
+func (p *Pot) SetIdentified(value bool) {
+	p.Identified = value
+}
+
 func (m *Money) SetIdentified(value bool) {
 	m.Identified = value
 }
@@ -284,6 +369,10 @@ func (ac *Accessory) GetIdentified() bool {
 	return ac.Identified
 }
 
+func (p *Pot) GetIdentified() bool {
+	return p.Identified
+}
+
 func (c *Cane) GetIdentified() bool {
 	// CaneのIdentified状態を取得するロジック
 	return c.Identified
@@ -347,6 +436,18 @@ func (c *Cane) Use(g *Game) {
 	}
 }
 
+func (k *Key) Use(g *Game) {
+	if action, exists := k.UseActions["UseKey"]; exists {
+		action(g)
+	}
+}
+
+func (p *Pot) Use(g *Game) {
+	if action, exists := p.UseActions["UsePot"]; exists {
+		action(g)
+	}
+}
+
 func (a *Accessory) Use(g *Game) {
 	if action, exists := a.UseActions["AccessoryEffect"]; exists {
 		action(g)
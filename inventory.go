@@ -0,0 +1,91 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"sort"
+)
+
+// itemKindOrder returns the sort key used to group items by kind.
+func itemKindOrder(item Item) int {
+	switch item.(type) {
+	case *Weapon:
+		return kindWeapon
+	case *Armor:
+		return kindArmor
+	case *Arrow:
+		return kindArrow
+	case *Cane:
+		return kindCane
+	case *Card, *Trap:
+		return kindCard
+	case *Food:
+		return kindFood
+	case *Potion:
+		return kindPotion
+	case *Pot:
+		return kindPot
+	case *Money:
+		return kindMoney
+	default:
+		return kindOther
+	}
+}
+
+// IsInventoryFull reports whether the player can no longer carry another item.
+func (p *Player) IsInventoryFull() bool {
+	return len(p.Inventory) >= p.MaxInventory
+}
+
+// sortInventory groups the inventory by item kind. Equipped items are pinned
+// to the top of the list and items of the same kind keep their ID order.
+func (g *Game) sortInventory() {
+	equipped := func(item Item) bool {
+		equipableItem, ok := item.(Equipable)
+		return ok && isEquipped(g.state.Player.EquippedItems[:], equipableItem)
+	}
+
+	sort.SliceStable(g.state.Player.Inventory, func(i, j int) bool {
+		a, b := g.state.Player.Inventory[i], g.state.Player.Inventory[j]
+		if equipped(a) != equipped(b) {
+			return equipped(a)
+		}
+		if itemKindOrder(a) != itemKindOrder(b) {
+			return itemKindOrder(a) < itemKindOrder(b)
+		}
+		return a.GetID() < b.GetID()
+	})
+}
+
+// inventoryKinds returns the itemKindOrder of each item in the inventory.
+func (g *Game) inventoryKinds() []int {
+	kinds := make([]int, len(g.state.Player.Inventory))
+	for i, item := range g.state.Player.Inventory {
+		kinds[i] = itemKindOrder(item)
+	}
+	return kinds
+}
+
+// inventoryView returns the inventory indices shown with the current kind filter.
+func (g *Game) inventoryView() []int {
+	return filterByKind(g.inventoryKinds(), g.inventoryFilter)
+}
+
+// cycleInventoryFilter shows the next kind the inventory has, or every item again.
+func (g *Game) cycleInventoryFilter() {
+	g.inventoryFilter = nextKindFilter(g.inventoryKinds(), g.inventoryFilter)
+	g.moveInventoryCursor(0)
+}
+
+// moveInventoryCursor moves the cursor by delta items among the items shown. Moving
+// past the first or the last item stops on it, so a page jump can reach a short last
+// page. When no item of the filtered kind is left, every item is shown again.
+func (g *Game) moveInventoryCursor(delta int) {
+	view := g.inventoryView()
+	if len(view) == 0 && g.inventoryFilter != kindAll {
+		g.inventoryFilter = kindAll
+		view = g.inventoryView()
+	}
+	g.selectedItemIndex = max(moveViewCursor(view, g.selectedItemIndex, delta), 0)
+}
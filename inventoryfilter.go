@@ -0,0 +1,78 @@
+package main
+
+const itemsPerPage = 10 // インベントリ1ページに表示するアイテムの数
+
+// インベントリをソートしたときの種類ごとの並び順。絞り込みの種類にも使う
+const (
+	kindAll = iota // 絞り込まない (itemKindOrder がこの値を返すことはない)
+	kindWeapon
+	kindArmor
+	kindArrow
+	kindCane
+	kindCard
+	kindFood
+	kindPotion
+	kindPot
+	kindMoney
+	kindOther
+	kindCount
+)
+
+var kindLabels = [kindCount]string{"すべて", "武器", "防具", "矢", "杖", "カード", "食べ物", "薬", "壺", "お金", "その他"}
+
+// kindLabel returns the name of the kind shown in the header of the inventory.
+func kindLabel(kind int) string {
+	return kindLabels[kind]
+}
+
+// filterByKind returns the indices of the items of the kind, kinds[i] being the
+// itemKindOrder of the i-th item. kindAll keeps every item.
+func filterByKind(kinds []int, kind int) []int {
+	var view []int
+	for i, k := range kinds {
+		if kind == kindAll || k == kind {
+			view = append(view, i)
+		}
+	}
+	return view
+}
+
+// nextKindFilter returns the filter after kind: the next kind the inventory has,
+// and every item again after the last one.
+func nextKindFilter(kinds []int, kind int) int {
+	for next := kind + 1; next < kindCount; next++ {
+		for _, k := range kinds {
+			if k == next {
+				return next
+			}
+		}
+	}
+	return kindAll
+}
+
+// moveViewCursor moves the cursor by delta inside the view and returns the index of
+// the item it stops on. A cursor on an item outside the view starts from the first
+// visible item. It returns -1 when the view is empty.
+func moveViewCursor(view []int, index, delta int) int {
+	if len(view) == 0 {
+		return -1
+	}
+	pos := 0
+	for p, i := range view {
+		if i == index {
+			pos = p
+			break
+		}
+	}
+	return view[moveCursor(pos, delta, len(view))]
+}
+
+// viewPage returns the page of the view the item at index is shown on.
+func viewPage(view []int, index int) int {
+	for p, i := range view {
+		if i == index {
+			return p / itemsPerPage
+		}
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestInventoryKindFilter cycles the filter through an inventory and moves the cursor
+// inside the filtered view.
+func TestInventoryKindFilter(t *testing.T) {
+	kinds := []int{kindWeapon, kindFood, kindWeapon, kindPotion, kindFood}
+
+	if got, want := filterByKind(kinds, kindAll), []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterByKind(all) = %v, want %v", got, want)
+	}
+	if got, want := filterByKind(kinds, kindFood), []int{1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterByKind(food) = %v, want %v", got, want)
+	}
+	if got := filterByKind(kinds, kindArmor); len(got) != 0 {
+		t.Errorf("filterByKind(armor) = %v, want nothing", got)
+	}
+
+	// 持っていない種類は飛ばし、最後の種類の次はすべてに戻る
+	var cycle []int
+	for kind := nextKindFilter(kinds, kindAll); kind != kindAll; kind = nextKindFilter(kinds, kind) {
+		cycle = append(cycle, kind)
+	}
+	if want := []int{kindWeapon, kindFood, kindPotion}; !reflect.DeepEqual(cycle, want) {
+		t.Errorf("filter cycle = %v, want %v", cycle, want)
+	}
+
+	view := filterByKind(kinds, kindWeapon)
+	tests := []struct {
+		index, delta int
+		want         int
+	}{
+		{0, 1, 2},
+		{2, 1, 2},  // 最後のアイテムで止まる
+		{2, -5, 0}, // 最初のアイテムで止まる
+		{1, 0, 0},  // 隠れたアイテムからは最初のアイテムへ
+		{3, 1, 2},
+	}
+	for _, tt := range tests {
+		if got := moveViewCursor(view, tt.index, tt.delta); got != tt.want {
+			t.Errorf("moveViewCursor(%v, %d, %d) = %d, want %d", view, tt.index, tt.delta, got, tt.want)
+		}
+	}
+	if got := moveViewCursor(nil, 3, 1); got != -1 {
+		t.Errorf("moveViewCursor on an empty view = %d, want -1", got)
+	}
+}
@@ -36,52 +36,14 @@ func (g *Game) UpdateThrownItem() {
 			if (g.ThrownItem.DY >= 0 && g.ThrownItem.Y*tileSize >= g.ThrownItemDestination.Y*tileSize) ||
 				(g.ThrownItem.DY < 0 && g.ThrownItem.Y*tileSize <= g.ThrownItemDestination.Y*tileSize) {
 
-				itemExists := false
-				for _, item := range g.state.Items {
-					x, y := item.GetPosition()
-					if x == g.ThrownItem.X && y == g.ThrownItem.Y {
-						itemExists = true
-						break
-					}
-				}
-
-				if itemExists && g.TargetEnemy == nil {
-					// Check surrounding tiles for placement
-					directions := []Coordinate{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
-					placed := false
-					for _, dir := range directions {
-						newX := g.ThrownItem.X + dir.X
-						newY := g.ThrownItem.Y + dir.Y
-						// Check map boundaries and tile type
-						if newX >= 0 && newY >= 0 && newX < len(g.state.Map[0]) && newY < len(g.state.Map) &&
-							g.state.Map[newY][newX].Type != "wall" {
-							emptyTile := true
-							for _, item := range g.state.Items {
-								x, y := item.GetPosition()
-								if x == newX && y == newY {
-									emptyTile = false
-									break
-								}
-							}
-							if emptyTile {
-								g.state.Items = append(g.state.Items, g.ThrownItem.Item)
-								g.ThrownItem.Item.SetPosition(newX, newY)
-								placed = true
-								break
-							}
-						}
-					}
-					if !placed {
-						// If no empty tile, do not place the item
-					}
-				} else {
-					// g.ThrownItemがCane型かつTypeが"Effect"の場合、g.state.Itemsにg.ThrownItem.Itemを追加する処理を行わない
-					if caneItem, ok := g.ThrownItem.Item.(*Cane); ok && caneItem.BaseItem.Type == "Effect" {
-						// Do nothing
-					} else if g.TargetEnemy == nil {
-						// Place the item normally if no item exists at the destination
-						g.state.Items = append(g.state.Items, g.ThrownItem.Item)
-					}
+				// g.ThrownItemがCane型かつTypeが"Effect"の場合、g.state.Itemsにg.ThrownItem.Itemを追加する処理を行わない
+				if caneItem, ok := g.ThrownItem.Item.(*Cane); ok && caneItem.BaseItem.Type == "Effect" {
+					// Do nothing
+				} else if projectile, ok := g.ThrownItem.Item.(*Projectile); ok && !projectile.Remains {
+					// 魔法弾などは床に残らない
+				} else if g.TargetEnemy == nil && !g.ThrownItem.Hit {
+					// 落ちた場所にアイテムがあれば周囲の空いている場所に置く (空きがなければアイテムは消える)
+					g.placeItem(g.ThrownItem.Item, g.ThrownItemDestination.X, g.ThrownItemDestination.Y)
 				}
 				g.miniMapDirty = true
 
@@ -117,6 +79,7 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 	}
 
 	x, y := character.GetPosition()
+	_, byPlayer := character.(*Player) // 敵が撃った場合はfalse
 	var itemName string
 	message := ""
 	identified := true
@@ -134,7 +97,9 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 	}
 
 	// メッセージの設定
-	if caneItem, ok := item.(*Cane); ok && caneItem.BaseItem.Type == "Effect" {
+	if !byPlayer {
+		message = fmt.Sprintf("%sは%sを%s", character.GetName(), itemName, throwVerb(item))
+	} else if caneItem, ok := item.(*Cane); ok && caneItem.BaseItem.Type == "Effect" {
 		message = fmt.Sprintf("%sを使った", itemName) // Cane型でかつTypeが"Effect"の場合
 	} else if g.dPressed {
 		message = fmt.Sprintf("%sを撃った", itemName) // Dキーが押された場合
@@ -147,19 +112,21 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 		ItemName: itemName,
 		Execute: func(g *Game) {
 			g.ThrownItem = ThrownItem{
-				Item:  item,
-				Image: g.getItemImage(item),
-				X:     x,
-				Y:     y,
-				DX:    dx,
-				DY:    dy,
+				Item:      item,
+				Image:     g.getItemImage(item),
+				X:         x,
+				Y:         y,
+				DX:        dx,
+				DY:        dy,
+				Direction: character.GetDirection(),
+				Shot:      g.dPressed || !byPlayer,
 			}
 			var i int
 			for i = 1; i <= throwRange; i++ {
 				targetX := x + i*dx
 				targetY := y + i*dy
 				tile := mapState[targetY][targetX]
-				if tile.Type == "wall" {
+				if terrainOf(tile).StopsThrown { // 水や溶岩の上は飛び越える
 					//log.Printf("Cane item: %+v", item)
 					//log.Printf("Thrown item: %+v", g.ThrownItem)
 					// アイテムがCane型であり、BaseItem.Typeが"Effect"であるかチェック
@@ -181,8 +148,12 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 						return
 					}
 				}
-				for index, enemy := range enemies {
+				for _, enemy := range enemies {
 					if enemy.X == targetX && enemy.Y == targetY {
+						index := g.enemyIndexByUID(enemy.UID) // 投げた後に敵が倒されていれば当たらない
+						if index < 0 {
+							continue
+						}
 
 						g.TargetEnemyIndex = index
 
@@ -193,7 +164,9 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 
 						// Remove the item from the player's inventory
 						// Check if the item is of type Arrow and whether the D key was pressed
-						if _, ok := item.(*Arrow); ok && g.dPressed {
+						if !byPlayer {
+							// 敵が撃った飛び道具は海老さんの持ち物ではない
+						} else if _, ok := item.(*Arrow); ok && g.dPressed {
 							// If it's an arrow and D key was pressed, only remove it from inventory if ShotCount is 0
 							for i, inventoryItem := range g.state.Player.Inventory {
 								if arrow, ok := inventoryItem.(*Arrow); ok && arrow.ShotCount == 0 {
@@ -225,8 +198,7 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 
 						onTargetHit(&enemy, item, index)
 
-						g.showItemActions = false
-						g.showInventory = false
+						g.overlays.Close(OverlayInventory)
 
 						g.selectedItemIndex = 0
 						g.selectedActionIndex = 0
@@ -239,6 +211,7 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 						X: targetX,
 						Y: targetY,
 					}
+					g.ThrownItem.Hit = true                                 // 当たった飛び道具は床に残らない
 					onTargetHit(&g.state.Player, item, g.selectedItemIndex) // Passing a pointer to g.state.Player
 					return
 				}
@@ -257,12 +230,19 @@ func (g *Game) ThrowItem(item Item, throwRange int, character Character, mapStat
 }
 
 func (g *Game) onWallHit(item Item, position Coordinate, itemIndex int) {
+	// 穴掘りの杖の魔法は当たった壁からトンネルを掘る
+	if caneItem, ok := item.(*Cane); ok && caneItem.BaseItem.Type == "Effect" && caneItem.ID == digCaneItemID {
+		g.digTunnelFrom(position)
+	}
+
 	// Set the position of the item to the position before hitting the wall
 	item.SetPosition(position.X, position.Y)
 
 	// Update the ThrownItemDestination to the position before hitting the wall
 	g.ThrownItemDestination = position
 
+	g.makeNoise(position.X, position.Y, throwNoiseRadius) // 落ちた物音で近くの敵が気づく
+
 	// Remove the item from the player's inventory
 	// Check if the item is of type Arrow and whether the D key was pressed
 	if _, ok := item.(*Arrow); ok && g.dPressed {
@@ -294,8 +274,7 @@ func (g *Game) onWallHit(item Item, position Coordinate, itemIndex int) {
 	}
 
 	// Update the UI flags
-	g.showItemActions = false
-	g.showInventory = false
+	g.overlays.Close(OverlayInventory)
 	g.isActioned = true
 	g.selectedItemIndex = 0
 	g.selectedActionIndex = 0
@@ -305,7 +284,10 @@ func (g *Game) onTargetHit(target Character, item Item, index int) {
 	// Check if the item is of type Cane
 	if cane, ok := item.(*Cane); ok {
 		cane.Use(g)
+	} else if enemy, ok := target.(*Enemy); ok && !g.dPressed && g.eatThrownItem(g.enemyIndexByUID(enemy.UID), item) {
+		// アイテムを食べる敵は投げられた食べ物を食べてレベルアップする
 	} else if potion, ok := item.(*Potion); ok {
+		targetUID := characterUID(target)
 		action := Action{
 			Duration: 0.5, // Assuming a duration of 0.5 seconds for this action
 			Message:  fmt.Sprintf("%sのHPが%d回復した。", target.GetName(), potion.Health),
@@ -317,7 +299,7 @@ func (g *Game) onTargetHit(target Character, item Item, index int) {
 					if g.state.Player.Health > g.state.Player.GetMaxHealth() {
 						g.state.Player.Health = g.state.Player.GetMaxHealth()
 					}
-				} else if _, ok := target.(*Enemy); ok && index >= 0 && index < len(g.state.Enemies) {
+				} else if index := g.enemyIndexByUID(targetUID); index >= 0 {
 					// If target is of type *Enemy
 					g.state.Enemies[index].Health += potion.Health
 					if g.state.Enemies[index].Health > g.state.Enemies[index].GetMaxHealth() {
@@ -343,47 +325,7 @@ func (g *Game) onTargetHit(target Character, item Item, index int) {
 		} else {
 			damage = rand.Intn(3) + 1
 		}
-		action := Action{
-			Duration: 0.5, // Assuming a duration of 0.5 seconds for this action
-			Message:  fmt.Sprintf("%sに%dのダメージを与えた。", target.GetName(), damage),
-			Execute: func(g *Game) {
-				// Type assertion to check if target is of type *Player or *Enemy
-				if _, ok := target.(*Player); ok {
-					// If target is of type *Player
-					g.state.Player.Health -= damage
-					if g.state.Player.Health < 0 {
-						g.state.Player.Health = 0
-					}
-				} else if enemy, ok := target.(*Enemy); ok && index >= 0 && index < len(g.state.Enemies) {
-					// If target is of type *Enemy
-					g.state.Enemies[index].Health -= damage
-					if g.state.Enemies[index].Health < 0 {
-						g.state.Enemies[index].Health = 0
-					}
-					if g.state.Enemies[index].Health <= 0 {
-						// 敵のHealthが0以下の場合、敵を配列から削除
-						defeatAction := Action{
-							Duration: 0.5,
-							Message:  fmt.Sprintf("%sを倒した。", target.GetName()),
-							Execute:  func(g *Game) {},
-						}
-						g.Enqueue(defeatAction)
-
-						g.state.Enemies = append(g.state.Enemies[:index], g.state.Enemies[index+1:]...)
-
-						// 敵の経験値をプレイヤーの所持経験値に加える
-						g.state.Player.ExperiencePoints += enemy.ExperiencePoints
-
-						g.state.Player.checkLevelUp() // レベルアップをチェック
-
-						// Reset the target enemy after processing
-						// (If necessary. This part may need to be adjusted based on your game's logic)
-					}
-				}
-				g.isActioned = true
-			},
-		}
-		g.Enqueue(action)
+		g.hitWithProjectile(target, damage, playerUID)
 	}
 }
 
@@ -422,20 +364,28 @@ func getItemNameWithSharpness(item Item) string {
 		// Check if the item is identified
 		if !identifiable.IsIdentified() {
 			// For unidentified items, return the base name without sharpness
+			// 呪い・祝福だけを見抜いた持ち物には印を付ける
+			if cursable, ok := item.(Cursable); ok && cursable.IsCurseKnown() {
+				return identifiable.GetName() + curseMark(cursable)
+			}
 			return identifiable.GetName()
 		} else {
 			// Process identified items
 			switch item := item.(type) {
 			case *Weapon:
-				return fmt.Sprintf("%s%s", item.GetName(), formatSharpness(item.Sharpness))
+				return fmt.Sprintf("%s%s%s", item.GetName(), formatSharpness(item.Sharpness), curseMark(item))
 			case *Armor:
-				return fmt.Sprintf("%s%s", item.GetName(), formatSharpness(item.Sharpness))
+				return fmt.Sprintf("%s%s%s", item.GetName(), formatSharpness(item.Sharpness), curseMark(item))
+			case *Accessory:
+				return fmt.Sprintf("%s%s", item.GetName(), curseMark(item))
 			case *Money:
 				return fmt.Sprintf("%d円", item.Amount) // Format the amount as yen
 			case *Arrow:
 				return fmt.Sprintf("%d本の%s", item.ShotCount, item.GetName()) // Format the arrow item with shot count
 			case *Cane:
 				return fmt.Sprintf("%s[%d]", item.GetName(), item.Uses) // Format the cane item with uses count
+			case *Pot:
+				return fmt.Sprintf("%s[%d]", item.GetName(), item.Uses) // Format the pot item with uses count
 			default:
 				return item.GetName()
 			}
@@ -455,8 +405,6 @@ func (g *Game) executeItemSwap() {
 			selectedInventoryItem := g.state.Player.Inventory[g.selectedItemIndex]
 			//itemName := getItemNameWithSharpness(item) // You might want to adjust this if you have a different way to get the item's name.
 
-			isCursedEquipped := false
-
 			// アイテムが識別されているかどうかをチェック
 			identified := true
 			var selectedItemName string
@@ -474,23 +422,7 @@ func (g *Game) executeItemSwap() {
 			}
 
 			// Check if the selected inventory item is Equipable and cursed
-			if equipableItem, ok := selectedInventoryItem.(Equipable); ok {
-				for _, equippedItem := range g.state.Player.EquippedItems {
-					if equippedItem == equipableItem {
-						switch v := equipableItem.(type) {
-						case *Weapon:
-							if v.Cursed {
-								isCursedEquipped = true
-							}
-						case *Armor:
-							if v.Cursed {
-								isCursedEquipped = true
-							}
-						}
-						break
-					}
-				}
-			}
+			isCursedEquipped := g.isCursedEquipped(selectedInventoryItem)
 
 			if isCursedEquipped {
 				// If the selected inventory item is cursed and equipped, do not swap and enqueue an action with a message that it cannot be swapped
@@ -511,15 +443,7 @@ func (g *Game) executeItemSwap() {
 					ItemName: selectedItemName,
 					Execute: func(g *Game) {
 						// Check if the item is equipped and unequip if necessary
-						if equipableItem, ok := selectedInventoryItem.(Equipable); ok {
-							for i, equippedItem := range g.state.Player.EquippedItems {
-								if equippedItem == equipableItem {
-									g.state.Player.EquippedItems[i] = nil
-									equipableItem.UpdatePlayerStats(&g.state.Player, false) // Update player's stats when unequipping
-									break
-								}
-							}
-						}
+						g.unequipItem(selectedInventoryItem)
 						// Swap the positions of the items
 						selectedInventoryItem.SetPosition(playerX, playerY)
 						g.state.Items[i] = selectedInventoryItem
@@ -573,7 +497,8 @@ func (g *Game) PickupItem() {
 				}
 
 				// プレイヤーのインベントリサイズをチェック
-				if len(g.state.Player.Inventory) < 20 {
+				_, isMoney := item.(*Money) // お金は所持金に加算されるため満杯でも拾える
+				if !g.state.Player.IsInventoryFull() || isMoney {
 					message := fmt.Sprintf("%sを拾った", itemName) // メッセージ全体を作成
 					action := Action{
 						Duration:     0.8,
@@ -586,17 +511,21 @@ func (g *Game) PickupItem() {
 					g.Enqueue(action)
 					break // 一致するアイテムが見つかったらループを終了
 				} else {
-					// インベントリが満杯の場合のメッセージ
+					// インベントリが満杯の場合は足元メニューを開き、交換するかそのまま置いておくかを選ばせる
 					message := fmt.Sprintf("持ち物がいっぱいで%sを拾えなかった", itemName)
 					action := Action{
-						Duration:     0.5,
-						Message:      message,
-						ItemName:     itemName,
-						Execute:      func(g *Game) {},
+						Duration: 0.5,
+						Message:  message,
+						ItemName: itemName,
+						Execute: func(g *Game) {
+							g.overlays.Open(OverlayGroundItem)
+							g.selectedGroundActionIndex = 1 // "交換"を選択した状態で開く
+						},
 						IsIdentified: identified,
 						NonBlocking:  !g.IsEnemyAdjacent(),
 					}
 					g.Enqueue(action)
+					break
 				}
 			}
 		}
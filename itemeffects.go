@@ -29,6 +29,53 @@ func removeUsedItem(g *Game, isInventoryItem bool) {
 	}
 }
 
+// digCane is the effect of the dig cane on an enemy. 杖は壁に当たったときだけ穴を掘る (onWallHit)
+func digCane(g *Game) {
+	g.Enqueue(Action{Duration: 0.4, Message: "しかし何も起こらなかった。", Execute: func(g *Game) {}})
+}
+
+// digTunnelFrom digs a tunnel from the wall the dig cane hit, in the direction it flew.
+func (g *Game) digTunnelFrom(position Coordinate) {
+	if digTunnel(g.state.Map, position.X, position.Y, g.ThrownItem.DX, g.ThrownItem.DY, digTunnelLength) == 0 {
+		g.Enqueue(Action{Duration: 0.4, Message: "しかし壁は掘れなかった。", Execute: func(g *Game) {}})
+		return
+	}
+	g.Enqueue(Action{Duration: 0.4, Message: "壁に穴が開いた！", Execute: func(g *Game) {}})
+	g.miniMapDirty = true
+}
+
+// lightRooms lights every dark room on the floor.
+func lightRooms(g *Game) {
+	item, isInventoryItem := determineItemSource(g)
+	g.Enqueue(Action{Duration: 0.4, Message: fmt.Sprintf("%sを使った。", item.GetName()), Execute: func(g *Game) {}})
+	removeUsedItem(g, isInventoryItem)
+
+	lit := 0
+	for i := range g.rooms {
+		if g.rooms[i].Dark {
+			g.rooms[i].Dark = false
+			lit++
+		}
+	}
+	if lit == 0 {
+		g.Enqueue(Action{Duration: 0.4, Message: "しかし何も起こらなかった。", Execute: func(g *Game) {}})
+		return
+	}
+	g.Enqueue(Action{Duration: 0.4, Message: "フロアが明るくなった！", Execute: func(g *Game) {}})
+}
+
+// useKey opens the locked door next to the player.
+func useKey(g *Game) {
+	_, isInventoryItem := determineItemSource(g)
+	x, y, ok := g.adjacentDoor(doorLocked)
+	if !ok {
+		g.Enqueue(Action{Duration: 0.4, Message: "近くに鍵のかかった扉がない。", Execute: func(g *Game) {}})
+		return
+	}
+	removeUsedItem(g, isInventoryItem)
+	g.unlockDoor(x, y)
+}
+
 var restoreSatiety50 = func(g *Game) {
 	item, isInventoryItem := determineItemSource(g)
 
@@ -149,7 +196,7 @@ var restoreHP100 = func(g *Game) {
 	removeUsedItem(g, isInventoryItem)
 }
 
-var damageHP30 = func(g *Game) {
+func damageHP30(g *Game) {
 	item, isInventoryItem := determineItemSource(g)
 	action := Action{
 		Duration: 0.4,
@@ -182,29 +229,14 @@ var damageHP30 = func(g *Game) {
 			case DownLeft:
 				targetX, targetY = g.state.Player.X-1, g.state.Player.Y+1
 			}
-			for i, enemy := range g.state.Enemies {
+			for _, enemy := range g.state.Enemies {
 				if enemy.X == targetX && enemy.Y == targetY {
+					uid := enemy.UID
 					action := Action{
 						Duration: 0.5,
-						Message:  fmt.Sprintf("%sに30ダメージを与えた。", g.state.Enemies[i].Name),
+						Message:  fmt.Sprintf("%sに30ダメージを与えた。", enemy.Name),
 						Execute: func(g *Game) {
-							g.state.Enemies[i].Health -= 30
-							if g.state.Enemies[i].Health <= 0 {
-								// 敵のHealthが0以下の場合、敵を配列から削除
-								defeatAction := Action{
-									Duration: 0.5,
-									Message:  fmt.Sprintf("%sを倒した。", g.state.Enemies[i].Name),
-									Execute:  func(g *Game) {},
-								}
-								g.Enqueue(defeatAction)
-
-								g.state.Enemies = append(g.state.Enemies[:i], g.state.Enemies[i+1:]...)
-
-								// 敵の経験値をプレイヤーの所持経験値に加える
-								g.state.Player.ExperiencePoints += enemy.ExperiencePoints
-
-								g.state.Player.checkLevelUp() // レベルアップをチェック
-							}
+							g.DealDamage(playerUID, uid, 30, DamageMagic)
 						},
 					}
 					g.Enqueue(action)
@@ -248,11 +280,14 @@ var setTrap = func(g *Game) {
 
 var shiftChange = func(g *Game) {
 	//プレイヤーとインデックスの敵の位置を入れ替える
+	uid := g.state.Enemies[g.TargetEnemyIndex].UID
 	action := Action{
 		Duration: 0.4,
 		Message:  fmt.Sprintf("%sと入れ替わった", g.state.Enemies[g.TargetEnemyIndex].GetName()),
 		Execute: func(g *Game) {
-			g.state.Player.X, g.state.Player.Y, g.state.Enemies[g.TargetEnemyIndex].X, g.state.Enemies[g.TargetEnemyIndex].Y = g.state.Enemies[g.TargetEnemyIndex].X, g.state.Enemies[g.TargetEnemyIndex].Y, g.state.Player.X, g.state.Player.Y
+			if i := g.enemyIndexByUID(uid); i >= 0 {
+				g.state.Player.X, g.state.Player.Y, g.state.Enemies[i].X, g.state.Enemies[i].Y = g.state.Enemies[i].X, g.state.Enemies[i].Y, g.state.Player.X, g.state.Player.Y
+			}
 			g.TargetEnemyIndex = -1
 		},
 	}
@@ -269,12 +304,11 @@ var identifyItem = func(g *Game) {
 		g.tmpselectedItemIndex = g.selectedGroundItemIndex
 	}
 
-	g.useidentifyItem = true
-	g.showInventory = true
+	g.openItemSelect()
 }
 
 func (g *Game) executeItemIdentify() {
-	g.showInventory = false
+	g.overlays.Close(OverlayItemSelect)
 	item, _ := determineItemSource(g)
 
 	if identifiableItem, ok := item.(Identifiable); ok {
@@ -315,5 +349,4 @@ func (g *Game) executeItemIdentify() {
 
 	g.tmpselectedItemIndex = -1
 	g.selectedItemIndex = 0
-	g.useidentifyItem = false
 }
@@ -3,6 +3,8 @@
 
 package main
 
+import "log"
+
 type BaseItem struct {
 	Entity
 	ID            int
@@ -19,7 +21,10 @@ type Weapon struct {
 	Sharpness   int    // 例: 0-100の範囲で切れ味を表現
 	Element     string // 例: "Fire", "Ice", "Electric", etc.
 	Cursed      bool   // 武器が呪われているかどうか
+	Blessed     bool   // 武器が祝福されているかどうか
+	CurseKnown  bool   // 識別していなくても呪い・祝福がわかっているかどうか
 	Identified  bool   // 武器が識別されているかどうか
+	Digs        bool   // 正面の壁を掘れるかどうか (つるはし)
 }
 
 type Armor struct {
@@ -28,6 +33,9 @@ type Armor struct {
 	Sharpness    int
 	Element      string
 	Cursed       bool
+	Blessed      bool
+	CurseKnown   bool
+	RustProof    bool // 錆びない防具かどうか
 	Identified   bool // 鎧が識別されているかどうか
 }
 
@@ -36,6 +44,8 @@ type Arrow struct {
 	ShotCount   int
 	AttackPower int
 	Cursed      bool
+	Blessed     bool
+	CurseKnown  bool
 	Identified  bool // 矢が識別されているかどうか
 }
 
@@ -61,7 +71,11 @@ type Money struct {
 
 type Accessory struct {
 	BaseItem
+	Effect     RingEffectID // 指輪の常時効果
+	Stats      StatModifier // 装備したときのステータス変化量
 	Cursed     bool
+	Blessed    bool
+	CurseKnown bool
 	Identified bool // アクセサリが識別されているかどうか
 }
 
@@ -75,14 +89,143 @@ type Trap struct {
 	BaseItem
 }
 
-func createItem(x, y int) Item {
+// Key は鍵のかかった扉を開ける鍵
+type Key struct {
+	BaseItem
+}
+
+type Pot struct {
+	BaseItem
+	Uses       int  // 残りの使用回数
+	Identified bool // 壺が識別されているかどうか
+}
+
+// StatModifier はアイテムを装備したときのプレイヤーのステータス変化量
+type StatModifier struct {
+	AttackPower  int
+	DefensePower int
+	MaxPower     int
+	MaxHealth    int
+}
+
+// withCurse returns the modifier adjusted for the curse/blessing state.
+// 呪われている場合は効果が反転し、祝福されている場合は各効果が1ずつ強まる
+func (m StatModifier) withCurse(cursed, blessed bool) StatModifier {
+	if cursed {
+		return StatModifier{-m.AttackPower, -m.DefensePower, -m.MaxPower, -m.MaxHealth}
+	}
+	if blessed {
+		return StatModifier{
+			AttackPower:  m.AttackPower + sign(m.AttackPower),
+			DefensePower: m.DefensePower + sign(m.DefensePower),
+			MaxPower:     m.MaxPower + sign(m.MaxPower),
+			MaxHealth:    m.MaxHealth + sign(m.MaxHealth),
+		}
+	}
+	return m
+}
+
+// apply adds (equip) or removes (unequip) the modifier from the player stats
+func (m StatModifier) apply(player *Player, equip bool) {
+	factor := 1
+	if !equip {
+		factor = -1
+	}
+	player.AttackPower += factor * m.AttackPower
+	player.DefensePower += factor * m.DefensePower
+	player.Power += factor * m.MaxPower
+	player.MaxPower += factor * m.MaxPower
+	player.MaxHealth += factor * m.MaxHealth
+	if player.Health > player.MaxHealth {
+		player.Health = player.MaxHealth
+	}
+}
+
+// itemNames はアイテムの一覧。添字がアイテムのID (newItem・ダンジョンの定義・敵のドロップ表の番号) になる。
+// 定義ファイルの番号が変わらないように、新しいアイテムは末尾に足すこと
+var itemNames = []string{
+	"小銭",
+	"ウインナー",
+	"ミンティア",
+	"すごいミンティア",
+	"伝説の剣",
+	"光の角",
+	"銀の弓矢",
+	"黒炎弾のカード",
+	"炸裂装甲のカード",
+	"シフトチェンジの杖",
+	"指輪",
+	"真実の眼のカード",
+	"解呪のカード",
+	"武器強化のカード",
+	"防具強化のカード",
+	"強化の壺",
+	"錆びない鱗",
+	"レベルダウンの杖",
+	"つるはし",
+	"穴掘りの杖",
+	"灯りのカード",
+	"扉の鍵",
+	"呪い探知のカード",
+}
+
+// specialItems は特別な場所にだけ置かれ、ランダムには作られないアイテム
+var specialItems = map[string]bool{
+	"扉の鍵": true, // 鍵は鍵のかかった扉のあるフロアにだけ置かれる
+}
+
+var (
+	ringItemID      = itemIDByName("指輪")
+	pickaxeItemID   = itemIDByName("つるはし")
+	digCaneItemID   = itemIDByName("穴掘りの杖")
+	lightCardItemID = itemIDByName("灯りのカード")
+	keyItemID       = itemIDByName("扉の鍵")
+)
+
+// itemIDByName returns the ID of the item with the name. The names are written in the
+// tables of the game, so a misspelled name stops the game when it starts.
+func itemIDByName(name string) int {
+	for id, n := range itemNames {
+		if n == name {
+			return id
+		}
+	}
+	log.Fatalf("unknown item name %q", name)
+	return -1
+}
+
+// randomItemIDs returns the IDs of the items that can be found anywhere.
+func randomItemIDs() []int {
+	var ids []int
+	for id, name := range itemNames {
+		if !specialItems[name] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// createItem creates a random item found in the dungeon.
+func (d *DungeonDef) createItem(x, y int) Item {
+	ids, weights := d.itemTable()
+	if i := pickWeighted(weights, localRand.Intn); i >= 0 {
+		return newItem(ids[i], x, y)
+	}
+	ids = randomItemIDs()
+	return newItem(ids[localRand.Intn(len(ids))], x, y)
+}
+
+// newItem creates the item with the given ID.
+func newItem(id, x, y int) Item {
 	var item Item
-	randomValue := localRand.Intn(12) // Store the random value to ensure it's only generated once
-	//randomValue := 9
 	sharpnessValue := localRand.Intn(5) - 1
 	//sharpnessValue := -1
-	switch randomValue {
-	case 0:
+	blessed := sharpnessValue >= 0 && localRand.Intn(10) == 0 // 呪われていない装備品は1割の確率で祝福されている
+	if id < 0 || id >= len(itemNames) {
+		return nil
+	}
+	switch itemNames[id] {
+	case "小銭":
 		item = &Money{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -90,7 +233,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          0,
+				ID:          id,
 				Type:        "Kane",
 				Name:        "小銭",
 				Description: "小銭。それは海老さんが絆と呼ぶもの。",
@@ -101,7 +244,7 @@ func createItem(x, y int) Item {
 			Amount:     localRand.Intn(2001), // Generates a random integer between 0 and 2000
 			Identified: true,
 		}
-	case 1:
+	case "ウインナー":
 		item = &Food{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -109,7 +252,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          1,
+				ID:          id,
 				Type:        "Sausage",
 				Name:        "ウインナー",
 				Description: "海老さんが配信中に食べる食事。満腹度を50回復する。",
@@ -119,7 +262,7 @@ func createItem(x, y int) Item {
 			},
 			Satiety: 50,
 		}
-	case 2:
+	case "ミンティア":
 		item = &Potion{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -127,7 +270,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          2,
+				ID:          id,
 				Type:        "Mintia",
 				Name:        "ミンティア",
 				Description: "海老さんを元気にする薬。HPを30回復する。",
@@ -137,7 +280,7 @@ func createItem(x, y int) Item {
 			},
 			Health: 30,
 		}
-	case 3:
+	case "すごいミンティア":
 		item = &Potion{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -145,7 +288,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          3,
+				ID:          id,
 				Type:        "Mintia",
 				Name:        "すごいミンティア",
 				Description: "海老さんをすごく元気にする薬。HPを100回復する。",
@@ -155,7 +298,7 @@ func createItem(x, y int) Item {
 			},
 			Health: 100,
 		}
-	case 4:
+	case "伝説の剣":
 		item = &Weapon{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -163,7 +306,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          4,
+				ID:          id,
 				Type:        "Weapon",
 				Name:        "伝説の剣",
 				Description: "伝説の剣。攻撃力が8上昇する。",
@@ -176,8 +319,9 @@ func createItem(x, y int) Item {
 			Sharpness:   sharpnessValue,
 			Element:     "None",
 			Cursed:      sharpnessValue == -1,
+			Blessed:     blessed,
 		}
-	case 5:
+	case "光の角":
 		item = &Armor{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -185,7 +329,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          5,
+				ID:          id,
 				Type:        "Armor",
 				Name:        "光の角",
 				Description: "光の角。防御力が8上昇する。",
@@ -198,9 +342,10 @@ func createItem(x, y int) Item {
 			Sharpness:    sharpnessValue,
 			Element:      "None",
 			Cursed:       sharpnessValue == -1,
+			Blessed:      blessed,
 		}
 
-	case 6:
+	case "銀の弓矢":
 		item = &Arrow{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -208,7 +353,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          6,
+				ID:          id,
 				Type:        "Arrow",
 				Name:        "銀の弓矢",
 				Description: "銀の弓矢。攻撃力が5上昇する。",
@@ -223,7 +368,7 @@ func createItem(x, y int) Item {
 			Identified:  true,
 		}
 
-	case 7:
+	case "黒炎弾のカード":
 		item = &Card{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -231,7 +376,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          7,
+				ID:          id,
 				Type:        "Card",
 				Name:        "黒炎弾のカード",
 				Description: "眼の前の敵に30ダメージを与える。",
@@ -241,7 +386,7 @@ func createItem(x, y int) Item {
 			},
 		}
 
-	case 8:
+	case "炸裂装甲のカード":
 		item = &Trap{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -249,7 +394,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          8,
+				ID:          id,
 				Type:        "Card",
 				Name:        "炸裂装甲のカード",
 				Description: "セットして使用する罠カード。攻撃を行った敵を破壊する",
@@ -259,7 +404,7 @@ func createItem(x, y int) Item {
 			},
 		}
 
-	case 9:
+	case "シフトチェンジの杖":
 		item = &Cane{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -267,7 +412,7 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          9,
+				ID:          id,
 				Type:        "Cane",
 				Name:        "シフトチェンジの杖",
 				Description: "敵に当たった場合、自分と位置を交換する。",
@@ -278,27 +423,184 @@ func createItem(x, y int) Item {
 			Uses:       5,
 			Identified: false,
 		}
-	case 10:
-		item = &Accessory{
+	case "指輪":
+		// 指輪の種類は定義の中からランダムに選ぶ
+		ringDef := ringDefinitions[localRand.Intn(len(ringDefinitions))]
+		item = newAccessory(ringDef, x, y, sharpnessValue == -1, blessed)
+	case "真実の眼のカード":
+		item = &Card{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Card",
+				Name:        "真実の眼のカード",
+				Description: "所持アイテムを1つ識別する。",
+				UseActions: map[string]UseAction{
+					"UseCard": identifyItem,
+				},
+			},
+		}
+	case "解呪のカード":
+		item = &Card{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Card",
+				Name:        "解呪のカード",
+				Description: "持ち物の呪いをすべて解く。",
+				UseActions: map[string]UseAction{
+					"UseCard": removeCurse,
+				},
+			},
+		}
+	case "武器強化のカード":
+		item = &Card{
 			BaseItem: BaseItem{
 				Entity: Entity{
 					X:    x,
 					Y:    y,
 					Char: '!',
 				},
-				ID:          10,
-				Type:        "Accessory",
-				Name:        "鼓舞の指輪",
-				Description: "アクセサリ。パワーの最大値が3上昇する。",
+				ID:          id,
+				Type:        "Card",
+				Name:        "武器強化のカード",
+				Description: "装備している武器の強さを1上げる。",
 				UseActions: map[string]UseAction{
-					"AccessoryEffect": func(g *Game) {
+					"UseCard": enhanceWeapon,
+				},
+			},
+		}
+	case "防具強化のカード":
+		item = &Card{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Card",
+				Name:        "防具強化のカード",
+				Description: "装備している防具の強さを1上げる。",
+				UseActions: map[string]UseAction{
+					"UseCard": enhanceArmor,
+				},
+			},
+		}
+	case "強化の壺":
+		item = &Pot{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Pot",
+				Name:        "強化の壺",
+				Description: "武器や防具を入れると強さが1上がる。",
+				UseActions: map[string]UseAction{
+					"UsePot": useEnhancePot,
+				},
+			},
+			Uses:       localRand.Intn(3) + 2, // 2～4回使える
+			Identified: true,
+		}
+	case "錆びない鱗":
+		item = &Armor{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Armor",
+				Name:        "錆びない鱗",
+				Description: "錆びない鱗。防御力が5上昇する。錆びることがない。",
+				UseActions: map[string]UseAction{
+					"ArmorEffect": func(g *Game) {
 					},
 				},
 			},
-			Cursed:     false,
+			DefensePower: 5,
+			Sharpness:    sharpnessValue,
+			Element:      "None",
+			Cursed:       sharpnessValue == -1,
+			Blessed:      blessed,
+			RustProof:    true,
+		}
+	case "レベルダウンの杖":
+		item = &Cane{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Cane",
+				Name:        "レベルダウンの杖",
+				Description: "敵に当たった場合、その敵のレベルを1つ下げる。",
+				UseActions: map[string]UseAction{
+					"CaneEffect": levelDownCane,
+				},
+			},
+			Uses:       4,
 			Identified: false,
 		}
-	case 11:
+	case "つるはし":
+		item = &Weapon{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Weapon",
+				Name:        "つるはし",
+				Description: "攻撃力が2上昇する。装備して正面の壁を攻撃すると壁を掘れる。",
+				UseActions: map[string]UseAction{
+					"WeaponEffect": func(g *Game) {
+					},
+				},
+			},
+			AttackPower: 2,
+			Sharpness:   sharpnessValue,
+			Element:     "None",
+			Cursed:      sharpnessValue == -1,
+			Blessed:     blessed,
+			Digs:        true,
+		}
+	case "穴掘りの杖":
+		item = &Cane{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Cane",
+				Name:        "穴掘りの杖",
+				Description: "振った方向の壁にまっすぐトンネルを掘る。",
+				UseActions: map[string]UseAction{
+					"CaneEffect": digCane,
+				},
+			},
+			Uses:       3,
+			Identified: false,
+		}
+	case "灯りのカード":
 		item = &Card{
 			BaseItem: BaseItem{
 				Entity: Entity{
@@ -306,12 +608,46 @@ func createItem(x, y int) Item {
 					Y:    y,
 					Char: '!',
 				},
-				ID:          11,
+				ID:          id,
 				Type:        "Card",
-				Name:        "真実の眼のカード",
-				Description: "所持アイテムを1つ識別する。",
+				Name:        "灯りのカード",
+				Description: "フロアの暗い部屋をすべて明るくする。",
 				UseActions: map[string]UseAction{
-					"UseCard": identifyItem,
+					"UseCard": lightRooms,
+				},
+			},
+		}
+	case "扉の鍵":
+		item = &Key{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Key",
+				Name:        "扉の鍵",
+				Description: "鍵のかかった扉を1つ開ける。",
+				UseActions: map[string]UseAction{
+					"UseKey": useKey,
+				},
+			},
+		}
+	case "呪い探知のカード":
+		item = &Card{
+			BaseItem: BaseItem{
+				Entity: Entity{
+					X:    x,
+					Y:    y,
+					Char: '!',
+				},
+				ID:          id,
+				Type:        "Card",
+				Name:        "呪い探知のカード",
+				Description: "識別しなくても、持ち物が呪われているか祝福されているかがわかる。",
+				UseActions: map[string]UseAction{
+					"UseCard": detectCurse,
 				},
 			},
 		}
@@ -0,0 +1,77 @@
+package main
+
+const (
+	ambientBrightness = 0.2 // 光の届かない場所の明るさ
+	litThreshold      = 0.5 // この明るさ以上の場所にいる敵やアイテムが見える
+	baseLightRadius   = 1   // 海老さんの周りの照らされる範囲
+	lanternLightBonus = 2   // ランタンを装備すると広がる範囲
+)
+
+// LightSource は周りを照らすもの (海老さん自身など)
+type LightSource struct {
+	X, Y   int
+	Radius int
+}
+
+// lightLevel returns the brightness at the distance from a light of the radius.
+// 中心が最も明るく、範囲の端に向かって litThreshold まで暗くなる
+func lightLevel(distance, radius int) float64 {
+	if distance > radius {
+		return ambientBrightness
+	}
+	return 1.0 - (1.0-litThreshold)*float64(distance)/float64(radius+1)
+}
+
+// isLit reports whether the tile is bright enough to see what is on it.
+func isLit(t Tile) bool {
+	return t.Brightness >= litThreshold
+}
+
+// resetLighting makes every tile dark.
+func resetLighting(tiles [][]Tile) {
+	for y := range tiles {
+		for x := range tiles[y] {
+			tiles[y][x].Brightness = ambientBrightness
+		}
+	}
+}
+
+// castLight brightens the tiles the light reaches. A tile keeps the brightest light
+// that falls on it, so lights can be cast one after another.
+func castLight(tiles [][]Tile, light LightSource) {
+	for y := light.Y - light.Radius; y <= light.Y+light.Radius; y++ {
+		for x := light.X - light.Radius; x <= light.X+light.Radius; x++ {
+			if y < 0 || y >= len(tiles) || x < 0 || x >= len(tiles[y]) || !lightReaches(tiles, light.X, light.Y, x, y) {
+				continue
+			}
+			level := lightLevel(max(abs(x-light.X), abs(y-light.Y)), light.Radius)
+			if level > tiles[y][x].Brightness {
+				tiles[y][x].Brightness = level
+			}
+		}
+	}
+}
+
+// lightReaches reports whether nothing between (x0, y0) and (x1, y1) blocks sight.
+// 壁そのものは照らされるが、壁の向こうには光が届かない
+func lightReaches(tiles [][]Tile, x0, y0, x1, y1 int) bool {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	stepX, stepY := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+	x, y := x0, y0
+	for x != x1 || y != y1 {
+		if (x != x0 || y != y0) && tiles[y][x].BlockSight {
+			return false
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += stepX
+		}
+		if e2 <= dx {
+			err += dx
+			y += stepY
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestLightLevel(t *testing.T) {
+	for radius := 1; radius <= 3; radius++ {
+		previous := 1.1
+		for d := 0; d <= radius; d++ {
+			level := lightLevel(d, radius)
+			if level < litThreshold || level >= previous {
+				t.Errorf("lightLevel(%d, %d) = %v, want a lit level darker than %v", d, radius, level, previous)
+			}
+			previous = level
+		}
+		if level := lightLevel(radius+1, radius); level != ambientBrightness {
+			t.Errorf("lightLevel outside radius %d = %v, want %v", radius, level, ambientBrightness)
+		}
+	}
+}
+
+func TestCastLight(t *testing.T) {
+	// 7x3の部屋の真ん中に仕切りの壁がある
+	//	.......
+	//	...#...
+	//	.......
+	tiles := make([][]Tile, 3)
+	for y := range tiles {
+		tiles[y] = make([]Tile, 7)
+		for x := range tiles[y] {
+			tiles[y][x] = Tile{Type: "floor"}
+		}
+	}
+	tiles[1][3] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+	resetLighting(tiles)
+	castLight(tiles, LightSource{X: 1, Y: 1, Radius: 3})
+
+	if !isLit(tiles[1][1]) || tiles[1][1].Brightness != 1.0 {
+		t.Errorf("light source brightness = %v, want 1", tiles[1][1].Brightness)
+	}
+	if !isLit(tiles[1][3]) {
+		t.Error("the wall in front of the light is not lit")
+	}
+	if isLit(tiles[1][4]) {
+		t.Error("the light reaches behind the wall")
+	}
+	if !isLit(tiles[0][4]) {
+		t.Error("the tile beside the wall is not lit")
+	}
+	if isLit(tiles[1][5]) || isLit(tiles[0][6]) {
+		t.Error("tiles outside the radius are lit")
+	}
+}
@@ -6,7 +6,6 @@ package main
 import (
 	_ "image/png" // PNG画像を読み込むために必要
 	"log"
-	"math/rand"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -27,7 +26,6 @@ const (
 	DownLeft      = 7
 )
 
-var localRand *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
 var levelExpRequirements = []int{0, 5, 12, 22, 35, 51, 70, 92, 118, 148, 181} // レベル10までの経験値要件
 
 type Tile struct {
@@ -36,6 +34,7 @@ type Tile struct {
 	BlockSight bool   // タイルが視界を遮るかどうか
 	Visited    bool   // プレイヤーがこのタイルを通過したかどうか
 	Brightness float64
+	Variant    int // 床と通路の見た目の種類 (assignFloorVariants で選ぶ)
 }
 
 type Entity struct {
@@ -48,30 +47,32 @@ type Player struct {
 	Entity           // PlayerはEntityのフィールドを継承します
 	Health           int
 	MaxHealth        int
-	AttackPower      int       // 攻撃力
-	DefensePower     int       // 防御力
-	Power            int       // プレイヤーのパワー
-	MaxPower         int       // プレイヤーの最大パワー
-	Satiety          int       // 満腹度
-	MaxSatiety       int       // 最大満腹度
-	Inventory        []Item    // 所持アイテム
-	MaxInventory     int       // 最大所持アイテム数
-	ExperiencePoints int       // 所持経験値
-	Level            int       // プレイヤーのレベル
-	Direction        Direction // Uninitialized: uninitialized, Up: Up, Down: Down, Left: Left, Right: Right, UpRight: UpRight, DownRight: DownRight, UpLeft: UpLeft, DownLeft: DownLeft
-	EquippedItems    [5]Item   // Array to hold equipped items
-	Cash             int       // 所持金
-	SetTrap          Item      // トラップを設置する
+	AttackPower      int                  // 攻撃力
+	DefensePower     int                  // 防御力
+	Power            int                  // プレイヤーのパワー
+	MaxPower         int                  // プレイヤーの最大パワー
+	Satiety          int                  // 満腹度
+	MaxSatiety       int                  // 最大満腹度
+	Inventory        []Item               // 所持アイテム
+	MaxInventory     int                  // 最大所持アイテム数
+	ExperiencePoints int                  // 所持経験値
+	Level            int                  // プレイヤーのレベル
+	Direction        Direction            // Uninitialized: uninitialized, Up: Up, Down: Down, Left: Left, Right: Right, UpRight: UpRight, DownRight: DownRight, UpLeft: UpLeft, DownLeft: DownLeft
+	EquippedItems    [equipSlotCount]Item // Equipped items indexed by EquipSlot
+	Cash             int                  // 所持金
+	SetTrap          Item                 // トラップを設置する
+	SleepTurns       int                  // 眠っている残りターン数
 }
 
 type Coordinate struct {
 	X, Y int
 }
 type GameState struct {
-	Map     [][]Tile // ゲームのマップ
-	Player  Player   // プレイヤーキャラクター
-	Enemies []Enemy  // 敵キャラクターのリスト
-	Items   []Item   // マップ上のアイテムのリスト
+	Map     [][]Tile    // ゲームのマップ
+	Player  Player      // プレイヤーキャラクター
+	Enemies []Enemy     // 敵キャラクターのリスト
+	Items   []Item      // マップ上のアイテムのリスト
+	Traps   []FloorTrap // マップ上の罠のリスト
 }
 
 type Attack struct {
@@ -98,10 +99,13 @@ type ActionQueue struct {
 type Direction int
 
 type ThrownItem struct {
-	Item   Item
-	Image  *ebiten.Image
-	X, Y   int // 投げられたアイテムの現在の位置
-	DX, DY int // アイテムの移動方向と速度
+	Item      Item
+	Image     *ebiten.Image
+	X, Y      int       // 投げられたアイテムの現在の位置
+	DX, DY    int       // アイテムの移動方向と速度
+	Direction Direction // 投げた向き
+	Shot      bool      // 弓で撃たれた矢かどうか (矢の向きに回転して描画する)
+	Hit       bool      // 誰かに当たったかどうか
 }
 
 type Game struct {
@@ -110,6 +114,9 @@ type Game struct {
 	playerImg                 *ebiten.Image
 	ebiImg                    *ebiten.Image
 	snakeImg                  *ebiten.Image
+	bigEbiImg                 *ebiten.Image
+	ebiKingImg                *ebiten.Image
+	seaSnakeImg               *ebiten.Image
 	kaneImg                   *ebiten.Image
 	cardImg                   *ebiten.Image
 	mintiaImg                 *ebiten.Image
@@ -121,6 +128,9 @@ type Game struct {
 	caneImg                   *ebiten.Image
 	effectImg                 *ebiten.Image
 	accessoryImg              *ebiten.Image
+	potImg                    *ebiten.Image
+	stoneImg                  *ebiten.Image
+	keyImg                    *ebiten.Image
 	offsetX                   int
 	offsetY                   int
 	moveCount                 int
@@ -128,13 +138,9 @@ type Game struct {
 	lastIncrement             time.Time
 	lastArrowPress            time.Time // 矢印キーが最後に押された時間を追跡
 	lastDashStop              time.Time // 最後にダッシュが停止した時間
-	showInventory             bool      // true when the inventory window should be displayed
 	selectedItemIndex         int
-	showItemActions           bool
+	inventoryFilter           int // 持ち物で表示するアイテムの種類 (kindAll ならすべて)
 	selectedActionIndex       int
-	showDescription           bool
-	descriptionText           string
-	showItemDescription       bool
 	itemdescriptionText       string
 	Animating                 bool
 	AnimationProgress         float64
@@ -152,7 +158,6 @@ type Game struct {
 	xPressed                  bool
 	dashStopped               bool // ダッシュ停止状態
 	dPressed                  bool
-	ShowGroundItem            bool
 	selectedGroundActionIndex int
 	selectedGroundItemIndex   int
 	GroundItemActioned        bool
@@ -162,7 +167,6 @@ type Game struct {
 	ThrownItemDestination     Coordinate
 	TargetEnemy               *Enemy
 	TargetEnemyIndex          int
-	showStairsPrompt          bool
 	selectedOption            int // 0 for "Proceed", 1 for "Cancel"
 	ignoreStairs              bool
 	miniMap                   *ebiten.Image // ミニマップのキャッシュ
@@ -174,8 +178,22 @@ type Game struct {
 	frameCounter              int
 	enemyYOffset              int
 	enemyYOffsetTimer         int
-	useidentifyItem           bool
 	tmpselectedItemIndex      int
+	itemSelectPurpose         ItemSelectPurpose // 「どれを？」ウィンドウでアイテムを選ぶ目的
+	selectSourceItem          Item              // アイテム選択を始めたアイテム (強化の壺など)
+	overlays                  OverlayStack      // 開いているウィンドウ (持ち物・行動メニュー・説明・装備画面・足元・階段・どれを？)
+	selectedEquipSlot         EquipSlot         // 装備画面で選択中の装備欄
+	showAIDebug               bool              // 敵の思考状態を表示するデバッグ表示
+	floorTurns                int               // 現在のフロアに来てからのターン数
+	monsterHouseFlashTimer    float64           // モンスターハウスの演出で画面が赤く光る残り時間
+	bossFloor                 bool              // 現在のフロアがボスの地図から作られたかどうか
+	bossDefeated              bool              // 現在のボスフロアのボスを倒したかどうか
+	combatListeners           []CombatListener  // ダメージや撃破の出来事を受け取る処理
+	dungeon                   *DungeonDef       // 遊んでいるダンジョンの定義
+	location                  FloorKey          // 今いるフロア (本道か脇道か、何階か)
+	floorCache                FloorCache        // 離れたフロアの様子 (戻ってきたときに元に戻す)
+	stairsTaken               string            // 暗転の後に通る階段の種類
+	theme                     TileTheme         // 今いるフロアの見た目のテーマ
 }
 
 func (g *Game) CanAcceptInput() bool {
@@ -202,11 +220,19 @@ func (g *Game) IsEnemyAdjacent() bool {
 
 func (g *Game) Update() error {
 
-	if !g.showInventory && g.CanAcceptInput() && !g.ShowGroundItem && !g.showStairsPrompt {
+	// Escキーで冒険を中断してタイトル画面へ (「つづきから」で戻れる)
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && g.CanAcceptInput() && !g.fadingOut && !g.fadingIn {
+		scenes.suspend(g)
+		return nil
+	}
+
+	if g.CanAcceptInput() && g.handleSleep() {
+		// 眠っている間は入力を受け付けずにターンが進む
+	} else if g.overlays.Empty() && g.CanAcceptInput() {
 		dx, dy := g.HandleInput()
 		//dx, dy := g.CheatHandleInput()
 
-		if g.zPressed && !g.ShowGroundItem {
+		if g.zPressed {
 			g.CheckForEnemies(dx, dy)
 			g.zPressed = false
 			return nil
@@ -245,6 +271,8 @@ func (g *Game) Update() error {
 
 	g.MarkVisitedTiles(playerX, playerY)
 	g.MarkRoomVisited(playerX, playerY)
+	g.checkForMonsterHouse()
+	g.checkBossDefeated()
 	g.CheckPlayerMovement()
 
 	g.updateItemVisibility()
@@ -257,6 +285,12 @@ func (g *Game) Update() error {
 
 	g.HandleGroundItemInput()
 
+	g.handleEquipmentInput()
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.showAIDebug = !g.showAIDebug // 敵の思考状態のデバッグ表示を切り替える
+	}
+
 	g.HandleAnimationProgress()
 
 	g.UpdateAttackTimer()
@@ -267,8 +301,6 @@ func (g *Game) Update() error {
 
 	g.HandleEnemyAttackTimers()
 
-	g.ManageDescriptions()
-
 	g.HandleActionQueue()
 
 	g.CheckCombatState()
@@ -300,24 +332,29 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	offsetX, offsetY := g.CalculateAnimationOffset(screen)
 
 	g.DrawMap(screen, offsetX, offsetY)
+	g.DrawTraps(screen, offsetX, offsetY)
 	g.DrawItems(screen, offsetX, offsetY)
 	g.DrawThrownItem(screen, offsetX, offsetY)
 	g.DrawEnemies(screen, offsetX, offsetY)
+	g.drawAIDebugOverlay(screen, offsetX, offsetY)
 	g.DrawHUD(screen)
 	g.DrawPlayer(screen, centerX, centerY)
 
-	// Draw the inventory window if the showInventory flag is set
-	if g.showInventory {
-		g.showDescription = false
+	// 持ち物のウィンドウが開いていれば描く
+	if g.overlays.Has(OverlayInventory) {
 		if err := g.drawInventoryWindow(screen); err != nil {
 			log.Printf("Error drawing inventory window: %v", err)
 		}
 	}
 
-	if g.useidentifyItem {
+	if g.overlays.Has(OverlayItemSelect) {
 		g.drawUseIdentifyItemWindow(screen)
 	}
 
+	if g.overlays.Has(OverlayEquipment) {
+		g.drawEquipmentWindow(screen)
+	}
+
 	g.drawActionMenu(screen)
 
 	g.drawItemDescription(screen)
@@ -328,7 +365,11 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	g.DrawStairsPrompt(screen)
 
-	g.UpdateAndDrawMiniMap(screen)
+	if settings.ShowMiniMap {
+		g.UpdateAndDrawMiniMap(screen)
+	}
+
+	g.drawMonsterHouseFlash(screen)
 
 	if g.fadeAlpha > 0 {
 		g.drawOverlay(screen)
@@ -336,10 +377,6 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 }
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return 640, 480
-}
-
 // loadImage is a helper function to load an image from a file.
 func loadImage(filepath string) *ebiten.Image {
 	img, _, err := ebitenutil.NewImageFromFile(filepath)
@@ -349,13 +386,18 @@ func loadImage(filepath string) *ebiten.Image {
 	return img
 }
 
-// NewGame function initializes a new game and returns a pointer to a Game object.
+// NewGame function initializes a new game in the active dungeon and returns a pointer to a Game object.
 func NewGame() *Game {
+	dungeon := &dungeons[activeDungeon]
+
 	img := loadImage("img/ebisan.png")
 	tilesetImg := loadImage("img/tileset.png")
 	ebiImg := loadImage("img/ebi.png")
 	kaneImg := loadImage("img/kane.png")
 	snakeImg := loadImage("img/snake.png")
+	bigEbiImg := loadImage("img/bigebi.png")
+	ebiKingImg := loadImage("img/ebiking.png")
+	seaSnakeImg := loadImage("img/seasnake.png")
 	cardImg := loadImage("img/card.png")
 	sausageImg := loadImage("img/sausage.png")
 	mintiaImg := loadImage("img/mintia.png")
@@ -365,6 +407,9 @@ func NewGame() *Game {
 	caneImg := loadImage("img/cane.png")
 	effectImg := loadImage("img/effect.png")
 	accessoryImg := loadImage("img/ring.png")
+	potImg := loadImage("img/pot.png")
+	stoneImg := loadImage("img/stone.png")
+	keyImg := loadImage("img/key.png")
 
 	// プレイヤーの初期化
 	player := Player{
@@ -386,8 +431,20 @@ func NewGame() *Game {
 		Cash:             0,
 	}
 
+	// ダンジョンの決まりに従って開始時のレベルと持ち物を用意する
+	for player.Level < dungeon.Rules.StartLevel && player.Level < len(levelExpRequirements) {
+		player.Level++
+		player.MaxHealth += 10
+	}
+	player.Health = player.MaxHealth
+	player.ExperiencePoints = levelExpRequirements[player.Level-1]
+	for _, id := range dungeon.Rules.StartItems {
+		player.Inventory = append(player.Inventory, newItem(id, 0, 0))
+	}
+
 	// 最初のマップを生成
-	mapGrid, enemies, items, newFloor, newRoom := GenerateRandomMap(70, 70, 0, &player) // 初期階層は1です
+	const newFloor = 1 // 初期階層は1です
+	mapGrid, enemies, items, traps, newRoom := GenerateRandomMap(dungeon, newFloor, &player)
 
 	game := &Game{
 		state: GameState{
@@ -395,12 +452,17 @@ func NewGame() *Game {
 			Player:  player,
 			Enemies: enemies,
 			Items:   items,
+			Traps:   traps,
 		},
 		rooms:            newRoom,
+		dungeon:          dungeon,
 		playerImg:        img,
 		tilesetImg:       tilesetImg,
 		ebiImg:           ebiImg,
 		snakeImg:         snakeImg,
+		bigEbiImg:        bigEbiImg,
+		ebiKingImg:       ebiKingImg,
+		seaSnakeImg:      seaSnakeImg,
 		kaneImg:          kaneImg,
 		cardImg:          cardImg,
 		mintiaImg:        mintiaImg,
@@ -411,6 +473,9 @@ func NewGame() *Game {
 		caneImg:          caneImg,
 		effectImg:        effectImg,
 		accessoryImg:     accessoryImg,
+		potImg:           potImg,
+		stoneImg:         stoneImg,
+		keyImg:           keyImg,
 		offsetX:          0,
 		offsetY:          0,
 		Floor:            newFloor,
@@ -425,15 +490,26 @@ func NewGame() *Game {
 		tmpselectedItemIndex: -1,
 	}
 
+	game.AddCombatListener(wakeOnDamage)
+	game.location = FloorKey{Floor: newFloor}
+	game.floorCache = FloorCache{}
+	game.bossFloor = game.hasBoss()
+	game.placeStairs()
+	game.updateTheme()
+
 	return game
 }
 
 func main() {
-	game := NewGame()
+	dungeons = loadDungeons(dungeonDir)
+	prefabs = loadPrefabs(prefabDir)
+	settings = loadSettings(settingsFile)
+	scenes.records = loadRecords(recordsFile)
+	scenes.Switch(newTitleScene())
 
-	ebiten.SetWindowSize(1280, 960)
+	applyWindowScale()
 	ebiten.SetWindowTitle("ebirogue")
-	if err := ebiten.RunGame(game); err != nil {
+	if err := ebiten.RunGame(scenes); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -6,33 +6,27 @@ package main
 import (
 	"fmt"
 	_ "image/png" // PNG画像を読み込むために必要
-	"math"
+	"log"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
-type Room struct {
-	ID            int
-	X, Y          int
-	Width, Height int
-	Center        Coordinate
-}
-
 func (g *Game) handleFadingOut() {
 	g.fadeAlpha += 1.0 / 60 // 1秒かけて暗くする
 	if g.fadeAlpha >= 1.0 {
 		g.fadeAlpha = 1.0
 		if g.frameCounter == 0 {
-			// マップ生成
-			mapGrid, enemies, items, newFloor, newRoom := GenerateRandomMap(70, 70, g.Floor, &g.state.Player)
-			// 新しいマップ情報を設定
-			g.miniMap = nil
-			g.state.Map = mapGrid
-			g.state.Enemies = enemies
-			g.state.Items = items
-			g.Floor = newFloor
-			g.rooms = newRoom
+			to, ok := g.dungeon.stairsDestination(g.location, g.stairsTaken)
+			if !ok {
+				// 最深部の階段を降りたらエンディングへ
+				scenes.clearRun(g)
+				g.fadingOut = false
+				g.fadeAlpha = 0.0
+				return
+			}
+			// 階段の行き先のフロアへ (来たことがあれば元のまま)
+			g.changeFloor(to)
 		}
 		g.frameCounter++
 		if g.frameCounter >= 60 { // 1秒経過した後
@@ -48,7 +42,7 @@ func (g *Game) handleFadingIn() {
 	if g.fadeAlpha <= 0.0 {
 		g.fadeAlpha = 0.0
 		g.fadingIn = false
-		g.showStairsPrompt = false
+		g.overlays.Close(OverlayStairsPrompt)
 	}
 }
 
@@ -63,33 +57,32 @@ func getPlayerRoom(playerX, playerY int, rooms []Room) *Room {
 	return nil
 }
 
+// updateTileBrightness builds the lighting map of the floor. The lit room the player is in
+// is fully bright, and the player's own light falls off around them in corridors and dark rooms.
 func (g *Game) updateTileBrightness() {
 	playerX, playerY := g.state.Player.GetPosition()
-	inRoom := isInsideRoom(playerX, playerY, g.rooms)
-
-	playerRoom := getPlayerRoom(playerX, playerY, g.rooms) // プレイヤーの部屋を取得
-
-	for y, row := range g.state.Map {
-		for x := range row {
-			if inRoom && playerRoom != nil {
-				// Check if the tile is in the same room as the player
-				if x >= playerRoom.X && x <= playerRoom.X+playerRoom.Width-1 &&
-					y >= playerRoom.Y && y <= playerRoom.Y+playerRoom.Height-1 {
-					g.state.Map[y][x].Brightness = 1.0 // Fully bright
-				} else {
-					g.state.Map[y][x].Brightness = 0.2 // Fully dark
-				}
-			} else {
-				// Check if the tile is adjacent to the player
-				adjacent := (math.Abs(float64(playerX-x)) <= 1 && math.Abs(float64(playerY-y)) <= 1)
-				if adjacent {
-					g.state.Map[y][x].Brightness = 1.0 // Fully bright
-				} else {
-					g.state.Map[y][x].Brightness = 0.2 // Fully dark
+	resetLighting(g.state.Map)
+
+	if isInsideRoom(playerX, playerY, g.rooms) {
+		if playerRoom := getPlayerRoom(playerX, playerY, g.rooms); playerRoom != nil && !playerRoom.Dark {
+			for y := playerRoom.Y; y < playerRoom.Y+playerRoom.Height; y++ {
+				for x := playerRoom.X; x < playerRoom.X+playerRoom.Width; x++ {
+					g.state.Map[y][x].Brightness = 1.0
 				}
 			}
 		}
 	}
+	castLight(g.state.Map, LightSource{X: playerX, Y: playerY, Radius: g.state.Player.lightRadius()})
+}
+
+// isDarkFloor reports whether every room of the floor is dark.
+func isDarkFloor(rooms []Room) bool {
+	for _, room := range rooms {
+		if !room.Dark {
+			return false
+		}
+	}
+	return len(rooms) > 0
 }
 
 func isInsideRoom(x, y int, rooms []Room) bool {
@@ -119,7 +112,7 @@ func (g *Game) MarkVisitedTiles(playerX, playerY int) {
 		adjX, adjY := playerX+dir.dx, playerY+dir.dy
 		if adjX >= 0 && adjX < len(g.state.Map[0]) && adjY >= 0 && adjY < len(g.state.Map) {
 			adjTile := &g.state.Map[adjY][adjX]
-			if adjTile.Type == "floor" || adjTile.Type == "corridor" {
+			if adjTile.Type == "floor" || adjTile.Type == "corridor" || adjTile.Type == doorOpen || isClosedDoor(*adjTile) {
 				adjTile.Visited = true
 			}
 		}
@@ -158,7 +151,7 @@ func (g *Game) CheckPlayerMovement() {
 
 // handleStairsPrompt handles user input for the stairs prompt.
 func (g *Game) handleStairsPrompt() {
-	if g.showStairsPrompt {
+	if g.overlays.Top() == OverlayStairsPrompt {
 		if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
 			g.selectedOption = (g.selectedOption + 1) % 2
 		}
@@ -167,19 +160,20 @@ func (g *Game) handleStairsPrompt() {
 		}
 		if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
 			if g.selectedOption == 0 { // "Proceed" is selected
+				g.stairsTaken = g.state.Map[g.state.Player.Y][g.state.Player.X].Type
 				g.fadingOut = true // 暗転開始
 				g.fadeAlpha = 0.0
 			} else { // "Cancel" is selected
 				g.selectedOption = 0
 				g.ignoreStairs = true
 			}
-			g.showStairsPrompt = false // Close the prompt window
+			g.overlays.Close(OverlayStairsPrompt) // Close the prompt window
 			g.selectedOption = 0
 		}
 		if inpututil.IsKeyJustPressed(ebiten.KeyX) {
 			g.selectedOption = 0
 			g.ignoreStairs = true
-			g.showStairsPrompt = false // Close the prompt window
+			g.overlays.Close(OverlayStairsPrompt) // Close the prompt window
 		}
 	}
 }
@@ -188,7 +182,7 @@ func (g *Game) handleStairsPrompt() {
 func (g *Game) ResetStairsIgnoreFlag() {
 	player := &g.state.Player
 	playerTile := g.state.Map[player.Y][player.X]
-	if playerTile.Type != "stairs" {
+	if !isStairs(playerTile) {
 		g.ignoreStairs = false
 	}
 }
@@ -197,163 +191,49 @@ func (g *Game) checkForStairs() {
 	player := &g.state.Player
 	playerTile := g.state.Map[player.Y][player.X]
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyS) && g.ignoreStairs && playerTile.Type == "stairs" {
-		g.showStairsPrompt = true
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) && g.ignoreStairs && isStairs(playerTile) && g.overlays.Empty() {
+		g.overlays.Open(OverlayStairsPrompt)
 		g.ignoreStairs = false // Optionally reset ignoreStairs flag
 		return
 	}
 
-	if playerTile.Type == "stairs" && !g.ignoreStairs && !g.showStairsPrompt {
-		g.showStairsPrompt = true
-	}
-}
-
-func isInsideRoomOrOnBoundary(x, y int, rooms []Room) bool {
-	for _, room := range rooms {
-		if x >= room.X && x <= room.X+room.Width-1 &&
-			y >= room.Y && y <= room.Y+room.Height-1 {
-			return true
-		}
+	if isStairs(playerTile) && !g.ignoreStairs && g.overlays.Empty() {
+		g.overlays.Open(OverlayStairsPrompt)
 	}
-	return false
 }
 
-func placeDoor(mapGrid [][]Tile, x, y int) {
-	mapGrid[y][x] = Tile{Type: "door", Blocked: false, BlockSight: true}
-}
-
-func drawCorridor(mapGrid [][]Tile, room1, room2 Room, rooms []Room) {
-	// Get the center coordinates of the rooms
-	x1, y1 := room1.Center.X, room1.Center.Y
-	x2, y2 := room2.Center.X, room2.Center.Y
-
-	// Determine the turning point
-	turnX, turnY := x1, y2
-
-	_, _, vertexDetected := detectVertex(mapGrid, x1, y1, turnX, turnY, rooms)
-	if vertexDetected {
-		turnX, turnY = x2, y1
-	}
-
-	_, _, vertexDetected = detectVertex(mapGrid, turnX, turnY, x2, y2, rooms)
-
-	if vertexDetected {
-		turnX, turnY = x2, y1
-	}
-
-	// Draw vertical corridor from the center of room1 to the turning point
-	drawSegment(mapGrid, x1, y1, x1, turnY, rooms)
-
-	// Draw horizontal corridor from the turning point to the center of room2
-	drawSegment(mapGrid, turnX, turnY, x2, turnY, rooms)
-}
-
-func detectVertex(mapGrid [][]Tile, startX, startY, endX, endY int, rooms []Room) (int, int, bool) {
-	// Determine the direction of the scan based on the start and end coordinates
-	deltaX := 0
-	deltaY := 0
-	if startX != endX {
-		deltaX = increment(startX, endX)
-	} else {
-		deltaY = increment(startY, endY)
-	}
-
-	// Initialize current position to the start coordinates
-	currentX := startX
-	currentY := startY
-
-	// Continue the scan until the end point is reached or an edge is detected
-	for currentX != endX || currentY != endY {
-		// Update the current position
-		currentX += deltaX
-		currentY += deltaY
-		for _, room := range rooms {
-			// Calculate the vertices of the room
-			topLeftX, topLeftY := room.X, room.Y
-			topRightX, topRightY := room.X+room.Width-1, room.Y
-			bottomLeftX, bottomLeftY := room.X, room.Y+room.Height-1
-			bottomRightX, bottomRightY := room.X+room.Width-1, room.Y+room.Height-1
-
-			// Check if the current position is near any of the vertices of the room
-			if (currentX == topLeftX && currentY == topLeftY) ||
-				(currentX == topRightX && currentY == topRightY) ||
-				(currentX == bottomLeftX && currentY == bottomLeftY) ||
-				(currentX == bottomRightX && currentY == bottomRightY) {
-				// Vertex detected, stop the scan and return the current position
-				return currentX, currentY, true
+// placeKeys puts a key for each locked door where the player can reach it
+// without opening a locked door. If there is no such place, the doors are unlocked.
+func placeKeys(mapGrid [][]Tile, player Coordinate, spawns []Coordinate, items []Item, count int) []Item {
+	candidates := reachableWithoutKey(mapGrid, player, spawns)
+	for i := 0; i < count; i++ {
+		placed := false
+		for attempt := 0; attempt < 20 && len(candidates) > 0 && !placed; attempt++ {
+			spot := candidates[localRand.Intn(len(candidates))]
+			if spot != player && !itemAt(items, spot.X, spot.Y) {
+				items = append(items, newItem(keyItemID, spot.X, spot.Y))
+				placed = true
 			}
 		}
+		if !placed {
+			unlockDoors(mapGrid)
+			break
+		}
 	}
-
-	// No vertex detected, return the original turnY value and false
-	return currentX, currentY, false
-}
-
-func increment(start, end int) int {
-	if start < end {
-		return 1 // Increment positively
-	}
-	return -1 // Increment negatively
+	return items
 }
 
-func drawSegment(mapGrid [][]Tile, startX, startY, endX, endY int, rooms []Room) {
-	for x := min(startX, endX); x <= max(startX, endX); x++ {
-		for y := min(startY, endY); y <= max(startY, endY); y++ {
-			isBoundary := false
-			for _, room := range rooms {
-				if isOnBoundary(x, y, room) {
-					isBoundary = true
-					//placeDoor(mapGrid, x, y)
-					mapGrid[y][x] = Tile{Type: "corridor", Blocked: false, BlockSight: false}
-					break
-				}
-			}
-			if !isBoundary && !isInsideRoomOrOnBoundary(x, y, rooms) {
-				mapGrid[y][x] = Tile{Type: "corridor", Blocked: false, BlockSight: false}
+// unlockDoors turns every locked door on the floor into a normal closed door.
+func unlockDoors(mapGrid [][]Tile) {
+	for y, row := range mapGrid {
+		for x, tile := range row {
+			if tile.Type == doorLocked {
+				placeDoor(mapGrid, x, y, doorClosed)
 			}
 		}
 	}
 }
 
-func isOnBoundary(x, y int, room Room) bool {
-	left := room.X
-	right := room.X + room.Width - 1
-	top := room.Y
-	bottom := room.Y + room.Height - 1
-
-	// Check if (x, y) is on the left, right, top, or bottom edge of the room
-	isOnLeftEdge := x == left && y >= top && y <= bottom
-	isOnRightEdge := x == right && y >= top && y <= bottom
-	isOnTopEdge := y == top && x >= left && x <= right
-	isOnBottomEdge := y == bottom && x >= left && x <= right
-
-	return isOnLeftEdge || isOnRightEdge || isOnTopEdge || isOnBottomEdge
-}
-
-func connectRooms(rooms []Room, mapGrid [][]Tile) {
-	if len(rooms) == 0 {
-		fmt.Println("No rooms to connect")
-		return
-	}
-
-	// Step 2: Connect each room to its nearest neighbor
-	for _, room := range rooms {
-		nearestNeighbor := findNearestNeighbor(room, rooms)
-		// Assuming drawCorridor is updated to take Room structs or center coordinates as arguments
-		drawCorridor(mapGrid, room, nearestNeighbor, rooms)
-	}
-
-	// Step 3: Connect all rooms in a circular manner
-	for i := 0; i < len(rooms); i++ {
-		// Get the next room index, wrapping back to 0 if at the end of the rooms slice
-		nextRoomIndex := (i + 1) % len(rooms)
-		// Again, assuming drawCorridor is updated to take Room structs or center coordinates as arguments
-		drawCorridor(mapGrid, rooms[i], rooms[nextRoomIndex], rooms)
-	}
-
-	fmt.Println("All rooms are connected")
-}
-
 func logCurrentRoom(player Player, rooms []Room) string {
 	for _, room := range rooms {
 		// Check if the player is within the bounds of the current room
@@ -372,249 +252,108 @@ func logRooms(rooms []Room) {
 	}
 }
 
-// Updated calculateDistance function to accept Room structures as arguments
-func calculateDistance(room1, room2 Room) float64 {
-	deltaX := float64(room2.Center.X - room1.Center.X)
-	deltaY := float64(room2.Center.Y - room1.Center.Y)
-	return math.Sqrt(deltaX*deltaX + deltaY*deltaY)
-}
-
-func findNearestNeighbor(room Room, rooms []Room) Room {
-	minDistance := math.MaxFloat64
-	var nearestRoom Room
-
-	for _, neighbor := range rooms {
-		// Skip if it's the same room
-		if room.ID == neighbor.ID {
-			continue
-		}
-
-		distance := calculateDistance(room, neighbor) // Updated to pass Room structures
-		if distance < minDistance {
-			minDistance = distance
-			nearestRoom = neighbor
-		}
-	}
-
-	return nearestRoom
-}
-
-func (r *Room) IsSeparatedBy(other Room, tiles int) bool {
-	// Horizontal separation
-	if r.X+r.Width+tiles <= other.X || other.X+other.Width+tiles <= r.X {
-		return true
-	}
-	// Vertical separation
-	if r.Y+r.Height+tiles <= other.Y || other.Y+other.Height+tiles <= r.Y {
-		return true
+// generateItems places the items on different spawn points.
+func generateItems(d *DungeonDef, spawns []Coordinate, count int) []Item {
+	var items []Item
+	for _, spawn := range pickSpawns(spawns, count, nil, localRand.Perm) {
+		items = append(items, d.createItem(spawn.X, spawn.Y))
 	}
-	return false
+	return items
 }
 
-// Helper function to calculate the distance between two points
-func distance(x1, y1, x2, y2 int) int {
-	dx := x2 - x1
-	dy := y2 - y1
-	return int(math.Sqrt(float64(dx*dx + dy*dy)))
-}
+// GenerateRandomMap generates the given floor of the dungeon.
+func GenerateRandomMap(dungeon *DungeonDef, floor int, player *Player) ([][]Tile, []Enemy, []Item, []FloorTrap, []Room) {
+	config := dungeon.Floor(floor)
+	width, height := config.Width, config.Height
 
-// Helper function to check if the distance between the center of the new room
-// and the center of any existing room is within a specific range
-func isWithinDistanceRange(newRoom Room, rooms []Room, minDistance, maxDistance int) bool {
-	for _, room := range rooms {
-		dist := distance(newRoom.Center.X, newRoom.Center.Y, room.Center.X, room.Center.Y)
-		if dist < minDistance || dist > maxDistance {
-			return false
+	// ボスフロアは地図ファイルから読み込む
+	if boss, ok := dungeon.SpecialFloor(floor); ok {
+		mapGrid, enemies, rooms, err := generateBossFloor(width, height, boss, player)
+		if err == nil {
+			assignFloorVariants(mapGrid, localRand.Intn)
+			return mapGrid, enemies, []Item{}, []FloorTrap{}, rooms
 		}
+		log.Printf("failed to load boss floor from %s: %v", boss.MapFile, err)
 	}
-	return true
-}
 
-func generateRooms(mapGrid [][]Tile, width, height, numRooms int) []Room {
+	var mapGrid [][]Tile
 	var rooms []Room
-
-	for i := 0; i < numRooms; i++ { // Attempt to create a specified number of rooms
-		for attempt := 0; attempt < 100; attempt++ { // Limit of 100 attempts per room
-			var roomX, roomY, roomWidth, roomHeight int
-
-			// If there are already rooms created, try to align the new room with one of them
-			if len(rooms) > 0 {
-				alignWith := rooms[localRand.Intn(len(rooms))] // Randomly select a room to align with
-
-				// Randomly decide to align horizontally or vertically
-				if localRand.Intn(2) == 0 {
-					// Align horizontally
-					roomWidth = localRand.Intn(10) + 6 // Random width between 6 and 15
-					roomHeight = alignWith.Height      // Match the height of the room to align with
-					roomX = localRand.Intn(width-roomWidth-1) + 1
-					roomY = alignWith.Y
-				} else {
-					// Align vertically
-					roomWidth = alignWith.Width         // Match the width of the room to align with
-					roomHeight = localRand.Intn(10) + 6 // Random height between 6 and 15
-					roomX = alignWith.X
-					roomY = localRand.Intn(height-roomHeight-1) + 1
-				}
-			} else {
-				// If this is the first room, generate random dimensions and position
-				roomWidth = localRand.Intn(min(10, width-2)) + 6   // Random width between 6 and 15, but not exceeding map width
-				roomHeight = localRand.Intn(min(10, height-2)) + 6 // Random height between 6 and 15, but not exceeding map height
-				roomX = localRand.Intn(width-roomWidth-1) + 1
-				roomY = localRand.Intn(height-roomHeight-1) + 1
-			}
-
-			newRoom := Room{
-				ID:     i, // Assign the unique ID to the room
-				X:      roomX,
-				Y:      roomY,
-				Width:  roomWidth,
-				Height: roomHeight,
-			}
-			valid := true
-			for _, room := range rooms {
-				if !newRoom.IsSeparatedBy(room, 5) {
-					valid = false
-					break
-				}
-			}
-
-			if valid {
-				// New validation to ensure rooms are not too far apart
-				if !isWithinDistanceRange(newRoom, rooms, 10, 100) { // Assume min distance is 10 and max distance is 50 for now
-					continue // Skip the rest of the loop and try again if the room is too far or too close
-				}
-				setRoomCenter(&newRoom)
-				rooms = append(rooms, newRoom)
-				for y := roomY; y < roomY+roomHeight; y++ {
-					for x := roomX; x < roomX+roomWidth; x++ {
-						if x == roomX || x == roomX+roomWidth-1 || y == roomY || y == roomY+roomHeight-1 {
-							mapGrid[y][x] = Tile{Type: "wall", Blocked: true, BlockSight: true}
-						} else {
-							mapGrid[y][x] = Tile{Type: "floor", Blocked: false, BlockSight: false}
-						}
-					}
-				}
-				break // Exit the inner loop as soon as a room is successfully created
-			}
+	var spawns []Coordinate
+	var playerPos Coordinate
+	var lockedDoors int
+	generator := generatorForFloor(config, floor)
+	for attempt := 1; ; attempt++ {
+		layout := generator.Generate(width, height)
+		mapGrid, rooms, spawns = layout.Tiles, layout.Rooms, layout.SpawnPoints
+
+		// プレイヤーの新しい位置を設定 (モンスターハウスの中には置かない)
+		playerPos = spawns[localRand.Intn(len(spawns))]
+		for i := 0; isInMonsterHouse(playerPos, rooms) && i < 10; i++ {
+			playerPos = spawns[localRand.Intn(len(spawns))]
+		}
+		if isInMonsterHouse(playerPos, rooms) {
+			playerPos = spawns[0] // 最初の部屋は必ず普通の部屋
 		}
-	}
-
-	return rooms
-}
-
-func setRoomCenter(room *Room) {
-	// Calculate the center coordinates
-	centerX := room.X + room.Width/2
-	centerY := room.Y + room.Height/2
-
-	// If the calculated center coordinates are even, increment them by 1 to make them odd
-	if centerX%2 == 0 {
-		centerX++
-	}
-	if centerY%2 == 0 {
-		centerY++
-	}
-
-	// Set the center coordinates
-	room.Center = Coordinate{X: centerX, Y: centerY}
-}
 
-func generateEnemies(rooms []Room, playerRoom Room) []Enemy {
-	var enemies []Enemy
-	for i := 0; i < 1; i++ {
-		var enemyRoom Room
-		var enemyX, enemyY int
-		for {
-			enemyRoom = rooms[localRand.Intn(len(rooms))]
-			if enemyRoom.ID != playerRoom.ID {
-				enemyX = localRand.Intn(enemyRoom.Width-2) + enemyRoom.X + 1
-				enemyY = localRand.Intn(enemyRoom.Height-2) + enemyRoom.Y + 1
-				occupied := false
-				for _, enemy := range enemies {
-					if enemy.X == enemyX && enemy.Y == enemyY {
-						occupied = true
-						break
-					}
-				}
-				if !occupied {
-					break
-				}
-			}
+		// 階段のランダムな位置を選ぶ (海老さんの足元は避ける)
+		stairs := spawns[localRand.Intn(len(spawns))]
+		for i := 0; stairs == playerPos && i < 10; i++ {
+			stairs = spawns[localRand.Intn(len(spawns))]
 		}
+		// 階段タイルを配置
+		mapGrid[stairs.Y][stairs.X] = Tile{Type: "stairs", Blocked: false, BlockSight: false}
 
-		enemies = append(enemies, createEnemy(enemyX, enemyY))
-	}
-	return enemies
-}
+		// 部屋の入口に扉を置く
+		lockedDoors = placeDoors(mapGrid, rooms)
 
-func generateItems(rooms []Room) []Item {
-	var items []Item
-	for i := 0; i < 10; i++ {
-		var itemRoom Room
-		var itemX, itemY int
-		for {
-			itemRoom = rooms[localRand.Intn(len(rooms))]
-			itemX = localRand.Intn(itemRoom.Width-2) + itemRoom.X + 1
-			itemY = localRand.Intn(itemRoom.Height-2) + itemRoom.Y + 1
-			occupied := false
-			for _, item := range items {
-				newitemX, newitemY := item.GetPosition()
-				if itemX == newitemX && itemY == newitemY {
-					occupied = true
-					break
-				}
-			}
-			if !occupied {
-				break
-			}
+		// 階段と全ての部屋に行けることを確かめ、行けなければ通路を掘るか作り直す
+		targets := []Coordinate{stairs}
+		for _, room := range rooms {
+			targets = append(targets, room.Center)
 		}
-
-		items = append(items, createItem(itemX, itemY))
+		result, dug := validateFloor(mapGrid, playerPos, targets)
+		generationStats.DugTiles += dug
+		if result != FloorBroken {
+			generationStats.Record(result)
+			break
+		}
+		generationStats.Regenerated++
+		if attempt == maxGenerationAttempts {
+			// 作り直しても直らなければ、必ずつながる大部屋にする (壊れたフロアは使わない)
+			log.Printf("floor %d is not fully connected after %d attempts, falling back to %s", floor, attempt, fallbackMapGenerator)
+			generationStats.Failed++
+			generator = mapGenerators[fallbackMapGenerator]
+		}
+		log.Printf("regenerating floor %d (attempt %d): %v", floor, attempt, generationStats)
 	}
-	return items
-}
+	player.Entity.X = playerPos.X
+	player.Entity.Y = playerPos.Y
 
-func GenerateRandomMap(width, height, currentFloor int, player *Player) ([][]Tile, []Enemy, []Item, int, []Room) {
-	// Step 1: Initialize all tiles to "other" type
-	mapGrid := make([][]Tile, height)
-	for y := range mapGrid {
-		mapGrid[y] = make([]Tile, width)
-		for x := range mapGrid[y] {
-			mapGrid[y][x] = Tile{Type: "other", Blocked: true, BlockSight: true}
+	// 暗いフロアでは全ての部屋が暗くなる
+	if localRand.Float64() < config.DarkChance {
+		for i := range rooms {
+			rooms[i].Dark = true
 		}
 	}
 
-	// Generate a random float between 0 and 1
-	//randomFloat := rand.Float64()
-
-	// Apply exponential decay
-	//decayFactor := 0.5 // Adjust this value to control the rate of decay
-	//prob := math.Pow(randomFloat, decayFactor)
-
-	// Scale and transform to get the number of rooms between 4 and 10
-	//numRooms := int(prob*7) + 4                              // This will give a value between 4 and 10 with a decreasing probability as the number of rooms increases
-	rooms := generateRooms(mapGrid, width, height, 6) // Step 2: Generate rooms
-
-	connectRooms(rooms, mapGrid)
-
-	// プレイヤーの新しい位置を設定
-	playerRoom := rooms[localRand.Intn(len(rooms))]
-	playerX := localRand.Intn(playerRoom.Width-2) + playerRoom.X + 1  // Exclude walls
-	playerY := localRand.Intn(playerRoom.Height-2) + playerRoom.Y + 1 // Exclude walls
-	player.Entity.X = playerX
-	player.Entity.Y = playerY
-
-	// 階段タイルを配置するためのランダムな部屋を選択
-	stairsRoom := rooms[localRand.Intn(len(rooms))]
-	// 階段のランダムな位置を選ぶ（壁を避ける）
-	stairsX := localRand.Intn(stairsRoom.Width-2) + stairsRoom.X + 1
-	stairsY := localRand.Intn(stairsRoom.Height-2) + stairsRoom.Y + 1
-	// 階段タイルを配置
-	mapGrid[stairsY][stairsX] = Tile{Type: "stairs", Blocked: false, BlockSight: false}
+	// 呪いを解く祠をまれに配置
+	placeShrine(mapGrid, spawns, 0.2)
 
 	// Call the newly created functions to generate enemies and items
-	enemies := generateEnemies(rooms, playerRoom)
-	items := generateItems(rooms)
-
-	return mapGrid, enemies, items, currentFloor + 1, rooms
+	enemies := generateEnemies(dungeon, spawns, rooms, playerPos, floor)
+	itemCount := config.ItemCount
+	if player.HasRingEffect(RingItemFind) {
+		itemCount += itemFindBonus // 拾い物の指輪でアイテムが増える
+	}
+	items := generateItems(dungeon, spawns, itemCount)
+	traps := generateTraps(mapGrid, spawns, 3)
+	enemies, items = populateMonsterHouse(dungeon, mapGrid, rooms, floor, enemies, items)
+	enemies, items = populatePrefabRooms(dungeon, rooms, floor, playerPos, enemies, items)
+	// 鍵のかかった扉があれば、その扉を通らずに行ける場所に鍵を置く
+	items = placeKeys(mapGrid, playerPos, spawns, items, lockedDoors)
+
+	// 床の見た目をランダムに選ぶ
+	assignFloorVariants(mapGrid, localRand.Intn)
+
+	return mapGrid, enemies, items, traps, rooms
 }
@@ -0,0 +1,376 @@
+package main
+
+import "log"
+
+// MapLayout は地図生成アルゴリズムが作った地形
+type MapLayout struct {
+	Tiles       [][]Tile
+	Rooms       []Room       // 部屋 (洞窟のように部屋がない地形では空)
+	SpawnPoints []Coordinate // 海老さん・敵・アイテム・階段を置ける床
+}
+
+// MapGenerator はフロアの地形を作るアルゴリズム
+type MapGenerator interface {
+	Generate(width, height int) MapLayout
+}
+
+const (
+	defaultMapGenerator  = "classic"
+	fallbackMapGenerator = "bigroom" // 作り直しても直らないフロアに使う、必ずつながる生成方法
+)
+
+var mapGenerators = map[string]MapGenerator{
+	"classic": classicGenerator{},
+	"grid":    gridGenerator{MinRooms: 4, ExtraCorridorChance: 0.15},
+	"bsp":     bspGenerator{MinLeafSize: 14, StopChance: 0.2},
+	"cave":    caveGenerator{FillRatio: 0.45, Steps: 4, MinFloorRatio: 0.25},
+	"bigroom": bigRoomGenerator{Margin: 3},
+}
+
+// generatorForFloor returns the map generator used on the floor of the dungeon.
+func generatorForFloor(config FloorDef, floor int) MapGenerator {
+	name := defaultMapGenerator
+	if len(config.Generators) > 0 {
+		name = config.Generators[localRand.Intn(len(config.Generators))]
+	}
+	generator, ok := mapGenerators[name]
+	if !ok {
+		log.Printf("unknown map generator %q on floor %d", name, floor)
+		generator = mapGenerators[defaultMapGenerator]
+	}
+	// 部屋をばらまく生成方法はフロアごとの設定を使う
+	if _, classic := generator.(classicGenerator); classic {
+		return classicGenerator{Params: config.Generation}
+	}
+	return generator
+}
+
+// newMapGrid returns a map filled with solid rock.
+func newMapGrid(width, height int) [][]Tile {
+	mapGrid := make([][]Tile, height)
+	for y := range mapGrid {
+		mapGrid[y] = make([]Tile, width)
+		for x := range mapGrid[y] {
+			mapGrid[y][x] = Tile{Type: "other", Blocked: true, BlockSight: true}
+		}
+	}
+	return mapGrid
+}
+
+// addRoom carves a new room and appends it to rooms.
+func addRoom(mapGrid [][]Tile, rooms []Room, x, y, width, height int) []Room {
+	room := Room{ID: len(rooms), X: x, Y: y, Width: width, Height: height, Kind: chooseRoomKind(rooms)}
+	return placeRoom(mapGrid, rooms, room)
+}
+
+// roomSpawnPoints returns the floor tiles inside the rooms, room by room.
+func roomSpawnPoints(mapGrid [][]Tile, rooms []Room) []Coordinate {
+	var points []Coordinate
+	for _, room := range rooms {
+		for y := room.Y + 1; y < room.Y+room.Height-1; y++ {
+			for x := room.X + 1; x < room.X+room.Width-1; x++ {
+				if mapGrid[y][x].Type == "floor" {
+					points = append(points, Coordinate{X: x, Y: y})
+				}
+			}
+		}
+	}
+	return points
+}
+
+// classicGenerator は部屋をばらまいて最寄りの部屋と環状の通路でつなぐ従来の生成方法
+type classicGenerator struct {
+	Params GenParams // 0の項目は地図の大きさに合わせた既定値になる
+}
+
+func (c classicGenerator) Generate(width, height int) MapLayout {
+	p := c.Params.withDefaults(width, height)
+	if err := p.validate(); err != nil {
+		log.Printf("invalid generation parameters, using the defaults: %v", err)
+		p = scaledGenParams(width, height)
+	}
+	mapGrid := newMapGrid(width, height)
+	rooms := generateRooms(mapGrid, p)
+	connectRooms(rooms, mapGrid, p.CorridorStyle, p.ExtraLoopChance)
+	return MapLayout{Tiles: mapGrid, Rooms: rooms, SpawnPoints: roomSpawnPoints(mapGrid, rooms)}
+}
+
+// gridGenerator は地図を3x3の区画に分け、区画ごとに部屋か通路の分岐点を置く
+// 不思議のダンジョンでおなじみの生成方法
+type gridGenerator struct {
+	MinRooms            int     // 部屋を置く区画の最小数 (残りは通路の分岐点になる)
+	ExtraCorridorChance float64 // 全域木に加えて隣の区画とつなぐ確率
+}
+
+const gridSize = 3
+
+func (gen gridGenerator) Generate(width, height int) MapLayout {
+	mapGrid := newMapGrid(width, height)
+	cellWidth, cellHeight := (width-2)/gridSize, (height-2)/gridSize
+	cellCount := gridSize * gridSize
+
+	// 部屋を置く区画をランダムに選ぶ
+	roomCount := gen.MinRooms + localRand.Intn(cellCount-gen.MinRooms+1)
+	hasRoom := make([]bool, cellCount)
+	for _, cell := range localRand.Perm(cellCount)[:roomCount] {
+		hasRoom[cell] = true
+	}
+
+	// 区画ごとの通路の端 (部屋の中心か分岐点)。分岐点は大きさのない部屋として扱う
+	var rooms []Room
+	anchors := make([]Room, cellCount)
+	for cell := 0; cell < cellCount; cell++ {
+		cellX := 1 + (cell%gridSize)*cellWidth
+		cellY := 1 + (cell/gridSize)*cellHeight
+		if hasRoom[cell] {
+			roomWidth := 6 + localRand.Intn(cellWidth-4-6+1)
+			roomHeight := 6 + localRand.Intn(cellHeight-4-6+1)
+			roomX := cellX + 2 + localRand.Intn(cellWidth-4-roomWidth+1)
+			roomY := cellY + 2 + localRand.Intn(cellHeight-4-roomHeight+1)
+			rooms = addRoom(mapGrid, rooms, roomX, roomY, roomWidth, roomHeight)
+			anchors[cell] = rooms[len(rooms)-1]
+		} else {
+			anchors[cell] = Room{Center: Coordinate{
+				X: cellX + 4 + localRand.Intn(cellWidth-8),
+				Y: cellY + 4 + localRand.Intn(cellHeight-8),
+			}}
+		}
+	}
+
+	// 全ての区画がつながるように全域木を作り、いくつかの通路を追加する
+	inTree := make([]bool, cellCount)
+	inTree[localRand.Intn(cellCount)] = true
+	connected := make(map[[2]int]bool)
+	for added := 1; added < cellCount; added++ {
+		var candidates [][2]int
+		for cell := 0; cell < cellCount; cell++ {
+			if !inTree[cell] {
+				continue
+			}
+			for _, next := range gridNeighbors(cell) {
+				if !inTree[next] {
+					candidates = append(candidates, [2]int{cell, next})
+				}
+			}
+		}
+		edge := candidates[localRand.Intn(len(candidates))]
+		inTree[edge[1]] = true
+		connected[edge] = true
+		drawCorridor(mapGrid, anchors[edge[0]], anchors[edge[1]], rooms)
+	}
+	for cell := 0; cell < cellCount; cell++ {
+		for _, next := range gridNeighbors(cell) {
+			if next < cell || connected[[2]int{cell, next}] || connected[[2]int{next, cell}] {
+				continue
+			}
+			if localRand.Float64() < gen.ExtraCorridorChance {
+				drawCorridor(mapGrid, anchors[cell], anchors[next], rooms)
+			}
+		}
+	}
+
+	return MapLayout{Tiles: mapGrid, Rooms: rooms, SpawnPoints: roomSpawnPoints(mapGrid, rooms)}
+}
+
+// gridNeighbors returns the cells next to the cell in the 3x3 grid.
+func gridNeighbors(cell int) []int {
+	var neighbors []int
+	x, y := cell%gridSize, cell/gridSize
+	if x > 0 {
+		neighbors = append(neighbors, cell-1)
+	}
+	if x < gridSize-1 {
+		neighbors = append(neighbors, cell+1)
+	}
+	if y > 0 {
+		neighbors = append(neighbors, cell-gridSize)
+	}
+	if y < gridSize-1 {
+		neighbors = append(neighbors, cell+gridSize)
+	}
+	return neighbors
+}
+
+// bspGenerator は地図を再帰的に二分割し、分割した区画ごとに部屋を置く
+type bspGenerator struct {
+	MinLeafSize int     // 区画の最小の幅と高さ
+	StopChance  float64 // まだ分割できる区画の分割をやめる確率
+}
+
+// bspBuild は bspGenerator が地図を作る間の状態
+type bspBuild struct {
+	bspGenerator
+	mapGrid [][]Tile
+	rooms   []Room
+	links   [][2][]int // 分割した2つの区画に含まれる部屋の添字。それぞれから1部屋ずつつなぐ
+}
+
+func (gen bspGenerator) Generate(width, height int) MapLayout {
+	b := &bspBuild{bspGenerator: gen, mapGrid: newMapGrid(width, height)}
+	b.split(1, 1, width-2, height-2, true)
+
+	// 部屋を全て作ってから通路を引く (後から作った部屋で通路が埋まらないように)
+	for _, link := range b.links {
+		from, to := b.closestPair(link[0], link[1])
+		drawCorridor(b.mapGrid, b.rooms[from], b.rooms[to], b.rooms)
+	}
+	return MapLayout{Tiles: b.mapGrid, Rooms: b.rooms, SpawnPoints: roomSpawnPoints(b.mapGrid, b.rooms)}
+}
+
+// split divides the area into two, or places a room if it is a leaf.
+// It returns the indices of the rooms placed in the area.
+func (b *bspBuild) split(x, y, width, height int, root bool) []int {
+	canSplitX := width >= 2*b.MinLeafSize
+	canSplitY := height >= 2*b.MinLeafSize
+	if !canSplitX && !canSplitY || !root && localRand.Float64() < b.StopChance {
+		roomWidth := 6 + localRand.Intn(max(1, width-2-6+1))
+		roomHeight := 6 + localRand.Intn(max(1, height-2-6+1))
+		roomX := x + 1 + localRand.Intn(max(1, width-2-roomWidth+1))
+		roomY := y + 1 + localRand.Intn(max(1, height-2-roomHeight+1))
+		b.rooms = addRoom(b.mapGrid, b.rooms, roomX, roomY, roomWidth, roomHeight)
+		return []int{len(b.rooms) - 1}
+	}
+
+	// 長い方の辺を分割する
+	splitX := canSplitX && (!canSplitY || width > height || width == height && localRand.Intn(2) == 0)
+	var first, second []int
+	if splitX {
+		at := b.MinLeafSize + localRand.Intn(width-2*b.MinLeafSize+1)
+		first = b.split(x, y, at, height, false)
+		second = b.split(x+at, y, width-at, height, false)
+	} else {
+		at := b.MinLeafSize + localRand.Intn(height-2*b.MinLeafSize+1)
+		first = b.split(x, y, width, at, false)
+		second = b.split(x, y+at, width, height-at, false)
+	}
+	b.links = append(b.links, [2][]int{first, second})
+	return append(first, second...)
+}
+
+// closestPair returns the pair of rooms, one from each group, whose centers are the closest.
+func (b *bspBuild) closestPair(first, second []int) (int, int) {
+	bestFrom, bestTo := first[0], second[0]
+	bestDistance := calculateDistance(b.rooms[bestFrom], b.rooms[bestTo])
+	for _, from := range first {
+		for _, to := range second {
+			if d := calculateDistance(b.rooms[from], b.rooms[to]); d < bestDistance {
+				bestFrom, bestTo, bestDistance = from, to, d
+			}
+		}
+	}
+	return bestFrom, bestTo
+}
+
+// caveGenerator はセル・オートマトンで部屋のない洞窟を作る
+type caveGenerator struct {
+	FillRatio     float64 // 最初に岩にするセルの割合
+	Steps         int     // セル・オートマトンを繰り返す回数
+	MinFloorRatio float64 // 一番大きな空洞の床がこの割合に満たなければ作り直す
+}
+
+const caveAttempts = 10
+
+func (c caveGenerator) Generate(width, height int) MapLayout {
+	var open [][]bool
+	var cave []Coordinate
+	for attempt := 0; attempt < caveAttempts; attempt++ {
+		open = c.automaton(width, height)
+		cave = largestRegion(open)
+		if float64(len(cave)) >= c.MinFloorRatio*float64(width*height) {
+			break
+		}
+	}
+
+	// 一番大きな空洞だけを床にして、それに接する岩を壁にする
+	mapGrid := newMapGrid(width, height)
+	for _, p := range cave {
+		mapGrid[p.Y][p.X] = Tile{Type: "floor", Blocked: false, BlockSight: false}
+	}
+	for _, p := range cave {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if mapGrid[p.Y+dy][p.X+dx].Type == "other" {
+					mapGrid[p.Y+dy][p.X+dx] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+				}
+			}
+		}
+	}
+	return MapLayout{Tiles: mapGrid, SpawnPoints: cave}
+}
+
+// automaton fills the map with random rock and smooths it into caves.
+// 地図の外周は必ず岩になる
+func (c caveGenerator) automaton(width, height int) [][]bool {
+	open := make([][]bool, height)
+	for y := range open {
+		open[y] = make([]bool, width)
+		for x := range open[y] {
+			open[y][x] = x > 0 && y > 0 && x < width-1 && y < height-1 && localRand.Float64() >= c.FillRatio
+		}
+	}
+	for step := 0; step < c.Steps; step++ {
+		next := make([][]bool, height)
+		for y := range next {
+			next[y] = make([]bool, width)
+			if y == 0 || y == height-1 {
+				continue
+			}
+			for x := 1; x < width-1; x++ {
+				rocks := 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if (dx != 0 || dy != 0) && !open[y+dy][x+dx] {
+							rocks++
+						}
+					}
+				}
+				next[y][x] = rocks < 5 && (open[y][x] || rocks < 4)
+			}
+		}
+		open = next
+	}
+	return open
+}
+
+// largestRegion returns the tiles of the largest open region, connected up, down, left and right.
+func largestRegion(open [][]bool) []Coordinate {
+	seen := make([][]bool, len(open))
+	for y := range seen {
+		seen[y] = make([]bool, len(open[y]))
+	}
+	var largest []Coordinate
+	for y := range open {
+		for x := range open[y] {
+			if !open[y][x] || seen[y][x] {
+				continue
+			}
+			region := []Coordinate{{X: x, Y: y}}
+			seen[y][x] = true
+			for i := 0; i < len(region); i++ {
+				p := region[i]
+				for _, d := range []Coordinate{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+					nx, ny := p.X+d.X, p.Y+d.Y
+					if ny >= 0 && ny < len(open) && nx >= 0 && nx < len(open[ny]) && open[ny][nx] && !seen[ny][nx] {
+						seen[ny][nx] = true
+						region = append(region, Coordinate{X: nx, Y: ny})
+					}
+				}
+			}
+			if len(region) > len(largest) {
+				largest = region
+			}
+		}
+	}
+	return largest
+}
+
+// bigRoomGenerator はフロア全体が一つの大部屋になる
+type bigRoomGenerator struct {
+	Margin int // 地図の端から部屋までの距離
+}
+
+func (b bigRoomGenerator) Generate(width, height int) MapLayout {
+	mapGrid := newMapGrid(width, height)
+	rooms := addRoom(mapGrid, nil, b.Margin, b.Margin, width-2*b.Margin, height-2*b.Margin)
+	return MapLayout{Tiles: mapGrid, Rooms: rooms, SpawnPoints: roomSpawnPoints(mapGrid, rooms)}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestGeneratorsFuzz runs every map generator on many seeds, places the stairs and the
+// doors as GenerateRandomMap does and checks the floor with validateFloor. Every floor
+// must be repaired, and then the player must reach the stairs and every room.
+func TestGeneratorsFuzz(t *testing.T) {
+	savedRand, savedPrefabs := localRand, prefabs
+	defer func() { localRand, prefabs = savedRand, savedPrefabs }()
+	prefabs = loadPrefabs(prefabDir)
+
+	var names []string
+	for name := range mapGenerators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sizes := []struct{ width, height int }{{70, 70}, {50, 50}, {40, 40}}
+
+	seeds := int64(2000)
+	if testing.Short() {
+		seeds = 200
+	}
+	for _, name := range names {
+		for _, size := range sizes {
+			for seed := int64(0); seed < seeds; seed++ {
+				localRand = rand.New(rand.NewSource(seed))
+				layout := mapGenerators[name].Generate(size.width, size.height)
+				if len(layout.SpawnPoints) < 2 {
+					t.Fatalf("%s %dx%d seed %d: %d spawn points", name, size.width, size.height, seed, len(layout.SpawnPoints))
+				}
+				tiles := layout.Tiles
+				start := layout.SpawnPoints[0]
+				stairs := layout.SpawnPoints[len(layout.SpawnPoints)-1]
+				tiles[stairs.Y][stairs.X] = Tile{Type: "stairs"}
+				placeDoors(tiles, layout.Rooms)
+
+				targets := []Coordinate{stairs}
+				for _, room := range layout.Rooms {
+					targets = append(targets, room.Center)
+				}
+				result, _ := validateFloor(tiles, start, targets)
+				if result == FloorBroken {
+					t.Fatalf("%s %dx%d seed %d: validateFloor could not repair the floor", name, size.width, size.height, seed)
+				}
+				reached := floodFill(tiles, start)
+				for _, target := range targets {
+					if !reached[target.Y][target.X] {
+						t.Fatalf("%s %dx%d seed %d: target %v unreachable after validateFloor returned %v", name, size.width, size.height, seed, target, result)
+					}
+				}
+			}
+		}
+	}
+}
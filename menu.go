@@ -0,0 +1,28 @@
+package main
+
+// MenuItem はメニューの1行
+type MenuItem struct {
+	Label    string
+	Disabled bool // 選べない項目 (灰色で表示し、カーソルは止まらない)
+}
+
+// Menu はカーソルで項目を選ぶ縦のメニュー (タイトル画面・設定画面)
+type Menu struct {
+	Items  []MenuItem
+	Cursor int
+}
+
+// move moves the cursor by delta, wrapping around and skipping the disabled items.
+func (m *Menu) move(delta int) {
+	for range m.Items {
+		m.Cursor = (m.Cursor + delta + len(m.Items)) % len(m.Items)
+		if !m.Items[m.Cursor].Disabled {
+			return
+		}
+	}
+}
+
+// selected reports whether the item under the cursor can be chosen.
+func (m *Menu) selected() bool {
+	return m.Cursor >= 0 && m.Cursor < len(m.Items) && !m.Items[m.Cursor].Disabled
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestMenuMoveSkipsDisabled(t *testing.T) {
+	menu := Menu{Items: []MenuItem{{Label: "a"}, {Label: "b", Disabled: true}, {Label: "c"}}}
+	menu.move(1)
+	if menu.Cursor != 2 {
+		t.Errorf("cursor = %d after moving down, want 2", menu.Cursor)
+	}
+	menu.move(1) // 最後の項目から先頭に戻る
+	if menu.Cursor != 0 || !menu.selected() {
+		t.Errorf("cursor = %d after wrapping, want 0", menu.Cursor)
+	}
+	menu.move(-1)
+	if menu.Cursor != 2 {
+		t.Errorf("cursor = %d after moving up, want 2", menu.Cursor)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	monsterHouseDensity  = 5   // この床面積ごとに敵とアイテムを1つずつ置く
+	maxMonsterHouseCount = 12  // モンスターハウスに置く敵の上限
+	monsterHouseFlash    = 1.0 // モンスターハウスに入ったときに画面が赤く光る時間 (秒)
+)
+
+// populateMonsterHouse fills the monster houses with sleeping enemies and items.
+func populateMonsterHouse(d *DungeonDef, mapGrid [][]Tile, rooms []Room, floor int, enemies []Enemy, items []Item) ([]Enemy, []Item) {
+	for _, room := range rooms {
+		if room.Kind != RoomMonsterHouse {
+			continue
+		}
+		count := min(maxMonsterHouseCount, (room.Width-2)*(room.Height-2)/monsterHouseDensity)
+		for i := 0; i < count; i++ {
+			x := localRand.Intn(room.Width-2) + room.X + 1
+			y := localRand.Intn(room.Height-2) + room.Y + 1
+			if mapGrid[y][x].Type != "floor" || enemyAt(enemies, x, y) {
+				continue
+			}
+			enemy := d.createEnemy(x, y, floor)
+			enemy.State = StateSleeping
+			enemies = append(enemies, enemy)
+		}
+		for i := 0; i < count; i++ {
+			x := localRand.Intn(room.Width-2) + room.X + 1
+			y := localRand.Intn(room.Height-2) + room.Y + 1
+			if mapGrid[y][x].Type == "floor" && !itemAt(items, x, y) {
+				items = append(items, d.createItem(x, y))
+			}
+		}
+	}
+	return enemies, items
+}
+
+// isInMonsterHouse reports whether the position is inside a monster house.
+func isInMonsterHouse(p Coordinate, rooms []Room) bool {
+	for _, room := range rooms {
+		if room.Kind == RoomMonsterHouse && isInsideRoom(p.X, p.Y, []Room{room}) {
+			return true
+		}
+	}
+	return false
+}
+
+func enemyAt(enemies []Enemy, x, y int) bool {
+	for _, enemy := range enemies {
+		if enemy.X == x && enemy.Y == y {
+			return true
+		}
+	}
+	return false
+}
+
+func itemAt(items []Item, x, y int) bool {
+	for _, item := range items {
+		itemX, itemY := item.GetPosition()
+		if itemX == x && itemY == y {
+			return true
+		}
+	}
+	return false
+}
+
+// checkForMonsterHouse wakes every enemy in the monster house the player has entered.
+func (g *Game) checkForMonsterHouse() {
+	if g.monsterHouseFlashTimer > 0 {
+		g.monsterHouseFlashTimer -= 1 / 60.0 // assuming Update is called 60 times per second
+	}
+
+	player := g.state.Player
+	for i := range g.rooms {
+		room := &g.rooms[i]
+		if room.Kind != RoomMonsterHouse || room.Triggered || !isInsideRoom(player.X, player.Y, []Room{*room}) {
+			continue
+		}
+		room.Triggered = true
+
+		action := Action{
+			Duration: 1.0,
+			Message:  "モンスターハウスだ！",
+			Execute: func(g *Game) {
+				g.monsterHouseFlashTimer = monsterHouseFlash
+			},
+		}
+		g.Enqueue(action)
+
+		for j := range g.state.Enemies {
+			enemy := &g.state.Enemies[j]
+			if isInsideRoom(enemy.X, enemy.Y, []Room{*room}) && enemy.State == StateSleeping {
+				enemy.State = StateHunting
+				enemy.PlayerDiscovered = true
+			}
+		}
+	}
+}
+
+// drawMonsterHouseFlash flashes the screen red when a monster house wakes up.
+func (g *Game) drawMonsterHouseFlash(screen *ebiten.Image) {
+	if g.monsterHouseFlashTimer <= 0 {
+		return
+	}
+	screenWidth, screenHeight := screen.Bounds().Dx(), screen.Bounds().Dy()
+	overlay := ebiten.NewImage(screenWidth, screenHeight)
+	overlay.Fill(color.NRGBA{0xff, 0x00, 0x00, 0xff})
+
+	opts := &ebiten.DrawImageOptions{}
+	opts.ColorScale.ScaleAlpha(float32(g.monsterHouseFlashTimer / monsterHouseFlash * 0.5))
+	screen.DrawImage(overlay, opts)
+}
@@ -4,14 +4,19 @@
 package main
 
 import (
+	"fmt"
 	_ "image/png" // PNG画像を読み込むために必要
 	"math/rand"
 )
 
 func (g *Game) IncrementMoveCount() {
 	g.moveCount++
-	// Check if moveCount has increased by 5
-	if g.moveCount%5 == 0 && g.moveCount != 0 {
+	// Check if moveCount has increased by 5 (回復の指輪を装備している場合はより短い間隔で回復する)
+	regenInterval := 5
+	if g.state.Player.HasRingEffect(RingRegeneration) {
+		regenInterval = regenerationInterval
+	}
+	if g.moveCount%regenInterval == 0 && g.moveCount != 0 {
 		// Recover 1 HP for the player
 		g.state.Player.Health += 1
 		// Ensure player's health does not exceed MaxHealth
@@ -19,8 +24,12 @@ func (g *Game) IncrementMoveCount() {
 			g.state.Player.Health = g.state.Player.MaxHealth
 		}
 	}
-	// Existing satiety reduction logic
-	if g.moveCount%10 == 0 && g.moveCount != 0 {
+	// Existing satiety reduction logic (満腹の指輪を装備している場合は減りにくい)
+	hungerInterval := 10
+	if g.state.Player.HasRingEffect(RingSlowHunger) {
+		hungerInterval = slowHungerInterval
+	}
+	if g.moveCount%hungerInterval == 0 && g.moveCount != 0 {
 		g.state.Player.Satiety -= 1
 		if g.state.Player.Satiety < 0 {
 			g.state.Player.Satiety = 0
@@ -36,7 +45,7 @@ func moveEnemy(g *Game, i int, dx, dy int) bool {
 	blockUp, blockDown, blockLeft, blockRight := isBlocked(g, enemy.X, enemy.Y)
 
 	if newX >= 0 && newX < len(g.state.Map[0]) && newY >= 0 && newY < len(g.state.Map) &&
-		!g.state.Map[newY][newX].Blocked && !isOccupied(g, newX, newY) && ((dx > 0 && dy > 0 && !(blockDown || blockRight)) ||
+		canEnter(g.state.Map[newY][newX], enemyDefinitions[enemy.ID].Flying) && !isOccupied(g, newX, newY) && ((dx > 0 && dy > 0 && !(blockDown || blockRight)) ||
 		(dx > 0 && dy < 0 && !(blockUp || blockRight)) ||
 		(dx < 0 && dy > 0 && !(blockDown || blockLeft)) ||
 		(dx < 0 && dy < 0 && !(blockUp || blockLeft)) ||
@@ -205,8 +214,9 @@ func isPositionFree(g *Game, x, y, enemyIndex int) bool {
 		return false
 	}
 
-	// Check if the position is blocked on the map.
-	if g.state.Map[y][x].Blocked {
+	// Check if the position is blocked on the map. 空を飛ぶ敵は水や溶岩の上に行ける
+	flying := enemyIndex >= 0 && enemyDefinitions[g.state.Enemies[enemyIndex].ID].Flying
+	if !canEnter(g.state.Map[y][x], flying) {
 		return false
 	}
 
@@ -270,6 +280,10 @@ func (g *Game) MoveTowardsPlayer(enemyIndex int) {
 
 	//log.Printf("Enemy %d: (%d, %d) -> (%d, %d)\n", enemyIndex, enemy.X, enemy.Y, newX, newY)
 
+	if g.enemyOpenDoor(enemyIndex, newX, newY) {
+		return
+	}
+
 	if isPositionFree(g, newX, newY, enemyIndex) {
 		g.state.Enemies[enemyIndex].X = newX
 		g.state.Enemies[enemyIndex].Y = newY
@@ -589,66 +603,87 @@ func determineDirection(dx, dy int) Direction {
 }
 
 func (g *Game) MoveEnemies() {
-	for i, enemy := range g.state.Enemies {
-		// Variables to store the difference in position
-		dx := enemy.X - g.state.Player.X
-		dy := enemy.Y - g.state.Player.Y
+	for i := range g.state.Enemies {
+		g.updateAIState(i)
 
-		// Calculate Manhattan distance between enemy and player
-		distance := abs(dx) + abs(dy)
+		// 眠っている敵は動かず、アイテムも行動パターンも使わない
+		if g.state.Enemies[i].State == StateSleeping {
+			if sleeping, ok := g.state.Enemies[i].Behavior.(SleepingBehavior); ok {
+				sleeping.ActAsleep(g, i)
+			}
+			continue
+		}
 
-		// Check if the enemy and player are in the same room
-		inSameRoom := isSameRoom(enemy.X, enemy.Y, g.state.Player.X, g.state.Player.Y, g.rooms)
+		// 持っているアイテムを使ったり投げたりする
+		if g.useCarriedItem(i) {
+			continue
+		}
 
-		if distance >= 15 && !inSameRoom {
-			g.state.Enemies[i].PlayerDiscovered = false
-		} else if inSameRoom {
-			g.state.Enemies[i].PlayerDiscovered = true
+		// 行動パターンを持つ敵は、その行動で手番を終えることがある
+		if behavior := g.state.Enemies[i].Behavior; behavior != nil && behavior.Act(g, i) {
+			continue
 		}
 
-		// Check if the enemy is adjacent or diagonally adjacent to the player
-		if abs(dx) <= 1 && abs(dy) <= 1 {
-			g.state.Enemies[i].PlayerDiscovered = true
-			//log.Printf("Enemy position: (%d, %d), Player position: (%d, %d)\n", enemy.X, enemy.Y, g.state.Player.X, g.state.Player.Y)
-			// Determine if there are walls that should prevent attacking
-			blockUp := enemy.Y > 0 && g.state.Map[enemy.Y-1][enemy.X].Blocked
-			blockDown := enemy.Y < len(g.state.Map)-1 && g.state.Map[enemy.Y+1][enemy.X].Blocked
-			blockLeft := enemy.X > 0 && g.state.Map[enemy.Y][enemy.X-1].Blocked
-			blockRight := enemy.X < len(g.state.Map[0])-1 && g.state.Map[enemy.Y][enemy.X+1].Blocked
-
-			// Log the values of blockUp, blockDown, blockLeft, blockRight
-			//log.Printf("blockUp: %v, blockDown: %v, blockLeft: %v, blockRight: %v\n", blockUp, blockDown, blockLeft, blockRight)
-
-			preventAttack := false
-
-			if dx == 1 && dy == 1 { // Player is to the top-left of enemy
-				//log.Printf("the top-left of enemy")
-				preventAttack = blockUp || blockLeft
-			} else if dx == -1 && dy == 1 { // Player is to the top-right of enemy
-				//log.Printf("the top-right of enemy")
-				preventAttack = blockUp || blockRight
-			} else if dx == 1 && dy == -1 { // Player is to the bottom-left of enemy
-				//log.Printf("the bottom-left of enemy")
-				preventAttack = blockDown || blockLeft
-			} else if dx == -1 && dy == -1 { // Player is to the bottom-right of enemy
-				//log.Printf("the bottom-right of enemy")
-				preventAttack = blockDown || blockRight
-			}
+		switch g.state.Enemies[i].State {
+		case StateHunting:
+			g.huntPlayer(i)
+		case StateFleeing:
+			g.fleeFromPlayer(i)
+		case StateGuarding:
+			g.guardPost(i)
+		case StateFollowing:
+			g.followLeader(i)
+		default:
+			moveRandomly(g, i) // Call function to move enemy randomly
+		}
+	}
+}
 
-			// Log the value of preventAttack
-			//log.Printf("preventAttack: %v\n", preventAttack)
+// huntPlayer attacks the player if adjacent, otherwise moves towards the player.
+func (g *Game) huntPlayer(i int) {
+	enemy := g.state.Enemies[i]
+	// Variables to store the difference in position
+	dx := enemy.X - g.state.Player.X
+	dy := enemy.Y - g.state.Player.Y
+
+	// Check if the enemy is adjacent or diagonally adjacent to the player
+	if abs(dx) <= 1 && abs(dy) <= 1 {
+		//log.Printf("Enemy position: (%d, %d), Player position: (%d, %d)\n", enemy.X, enemy.Y, g.state.Player.X, g.state.Player.Y)
+		// Determine if there are walls that should prevent attacking
+		blockUp := enemy.Y > 0 && g.state.Map[enemy.Y-1][enemy.X].Blocked
+		blockDown := enemy.Y < len(g.state.Map)-1 && g.state.Map[enemy.Y+1][enemy.X].Blocked
+		blockLeft := enemy.X > 0 && g.state.Map[enemy.Y][enemy.X-1].Blocked
+		blockRight := enemy.X < len(g.state.Map[0])-1 && g.state.Map[enemy.Y][enemy.X+1].Blocked
+
+		// Log the values of blockUp, blockDown, blockLeft, blockRight
+		//log.Printf("blockUp: %v, blockDown: %v, blockLeft: %v, blockRight: %v\n", blockUp, blockDown, blockLeft, blockRight)
+
+		preventAttack := false
+
+		if dx == 1 && dy == 1 { // Player is to the top-left of enemy
+			//log.Printf("the top-left of enemy")
+			preventAttack = blockUp || blockLeft
+		} else if dx == -1 && dy == 1 { // Player is to the top-right of enemy
+			//log.Printf("the top-right of enemy")
+			preventAttack = blockUp || blockRight
+		} else if dx == 1 && dy == -1 { // Player is to the bottom-left of enemy
+			//log.Printf("the bottom-left of enemy")
+			preventAttack = blockDown || blockLeft
+		} else if dx == -1 && dy == -1 { // Player is to the bottom-right of enemy
+			//log.Printf("the bottom-right of enemy")
+			preventAttack = blockDown || blockRight
+		}
 
-			if preventAttack {
-				g.MoveTowardsPlayer(i) // Call function to move enemy towards player
-			} else {
-				g.AttackFromEnemy(i) // Call function to attack player
-			}
+		// Log the value of preventAttack
+		//log.Printf("preventAttack: %v\n", preventAttack)
 
-		} else if g.state.Enemies[i].PlayerDiscovered {
+		if preventAttack {
 			g.MoveTowardsPlayer(i) // Call function to move enemy towards player
 		} else {
-			moveRandomly(g, i) // Call function to move enemy randomly
+			g.AttackFromEnemy(i) // Call function to attack player
 		}
+	} else {
+		g.MoveTowardsPlayer(i) // Call function to move enemy towards player
 	}
 }
 
@@ -660,33 +695,6 @@ func (p *Player) checkLevelUp() {
 	}
 }
 
-func isSameRoom(x1, y1, x2, y2 int, rooms []Room) bool {
-	var room1, room2 Room
-	foundRoom1, foundRoom2 := false, false // New variables to track if room1 and room2 are found
-
-	//log.Printf("Checking if points (%d, %d) and (%d, %d) are in the same room\n", x1, y1, x2, y2) // Log input points
-	for _, room := range rooms {
-		// Adjust the conditions to check if the points are within the inner boundaries of the room
-		if x1 > room.X && x1 < room.X+room.Width-1 && y1 > room.Y && y1 < room.Y+room.Height-1 {
-			room1 = room
-			foundRoom1 = true // Set foundRoom1 to true if room1 is found
-		}
-		if x2 > room.X && x2 < room.X+room.Width-1 && y2 > room.Y && y2 < room.Y+room.Height-1 {
-			room2 = room
-			foundRoom2 = true // Set foundRoom2 to true if room2 is found
-		}
-	}
-
-	// If either point is not in a room, return false
-	if !foundRoom1 || !foundRoom2 {
-		return false
-	}
-
-	result := room1.ID == room2.ID
-
-	return result
-}
-
 func (g *Game) CheatMovePlayer(dx, dy int) bool {
 	// dx と dy が両方とも0の場合、移動は発生していない
 	if dx == 0 && dy == 0 {
@@ -793,11 +801,29 @@ func (g *Game) MovePlayer(dx, dy int) bool {
 		g.state.Player.Y = newPY
 		g.isActioned = true
 		g.PickupItem()
+		g.checkForShrine()
+		g.checkForTrap()
+		g.checkTerrainDamage()
 		return true
 	}
 	return false
 }
 
+// checkTerrainDamage hurts the player standing on lava.
+func (g *Game) checkTerrainDamage() {
+	damage := terrainOf(g.state.Map[g.state.Player.Y][g.state.Player.X]).Damage
+	if damage == 0 {
+		return
+	}
+	g.Enqueue(Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("溶岩で%dダメージを受けた。", damage),
+		Execute: func(g *Game) {
+			g.DealDamage(noSource, playerUID, damage, DamageTerrain)
+		},
+	})
+}
+
 func isOccupied(g *Game, x, y int) bool {
 	for _, enemy := range g.state.Enemies {
 		if enemy.X == x && enemy.Y == y {
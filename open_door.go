@@ -16,9 +16,8 @@ func (g *Game) OpenDoor() {
 		if ny < 0 || ny >= len(g.state.Map) || nx < 0 || nx >= len(g.state.Map[0]) {
 			continue
 		}
-		tile := g.state.Map[ny][nx]
-		if tile.Type == "door" {
-			g.state.Map[ny][nx] = Tile{Type: "corridor"}
+		if next, result := doorTransition(g.state.Map[ny][nx].Type, false, false); result == doorOpened {
+			g.state.Map[ny][nx] = doorTile(next)
 			g.isActioned = true
 		}
 	}
@@ -11,14 +11,14 @@ func TestOpenDoorEdge(t *testing.T) {
 		m[y] = make([]Tile, width)
 	}
 	// place a door to the right of the player
-	m[0][1] = Tile{Type: "door"}
+	m[0][1] = doorTile(doorClosed)
 
 	g := &Game{state: GameState{Map: m, Player: Player{Entity: Entity{X: 0, Y: 0}}}}
 
 	// Should not panic
 	g.OpenDoor()
 
-	if m[0][1].Type != "corridor" {
+	if m[0][1].Type != doorOpen {
 		t.Errorf("expected tile to be opened, got %s", m[0][1].Type)
 	}
 }
@@ -0,0 +1,57 @@
+package main
+
+// Overlay はダンジョンの画面に重ねて開くウィンドウ
+type Overlay int
+
+const (
+	OverlayInventory       Overlay = iota + 1 // 持ち物
+	OverlayItemActions                        // 持ち物に対する行動 (使う・投げる・置く・説明)
+	OverlayItemDescription                    // 持ち物の説明
+	OverlayEquipment                          // 装備画面
+	OverlayGroundItem                         // 足元のアイテムのメニュー
+	OverlayStairsPrompt                       // 階段を進むかどうかの確認
+	OverlayItemSelect                         // 巻物や壺を使う持ち物を選ぶ「どれを？」(上に持ち物を開く)
+)
+
+// OverlayStack は開いているウィンドウ。後から開いたものほど上にあり、キー入力は一番上のウィンドウが受け取る
+type OverlayStack []Overlay
+
+// Open opens the overlay on top. An overlay that is already open stays where it is.
+func (s *OverlayStack) Open(o Overlay) {
+	if !s.Has(o) {
+		*s = append(*s, o)
+	}
+}
+
+// Close closes the overlay and every overlay opened on top of it.
+func (s *OverlayStack) Close(o Overlay) {
+	for i, open := range *s {
+		if open == o {
+			*s = (*s)[:i]
+			return
+		}
+	}
+}
+
+// Has reports whether the overlay is open.
+func (s OverlayStack) Has(o Overlay) bool {
+	for _, open := range s {
+		if open == o {
+			return true
+		}
+	}
+	return false
+}
+
+// Top returns the overlay on top, or 0 if none is open.
+func (s OverlayStack) Top() Overlay {
+	if len(s) == 0 {
+		return 0
+	}
+	return s[len(s)-1]
+}
+
+// Empty reports whether no overlay is open.
+func (s OverlayStack) Empty() bool {
+	return len(s) == 0
+}
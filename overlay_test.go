@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverlayStack(t *testing.T) {
+	var s OverlayStack
+	if !s.Empty() || s.Top() != 0 {
+		t.Fatalf("new stack = %v, want empty", s)
+	}
+	s.Open(OverlayInventory)
+	s.Open(OverlayItemActions)
+	s.Open(OverlayInventory) // 開いているウィンドウはそのまま
+	s.Open(OverlayItemDescription)
+	if want := (OverlayStack{OverlayInventory, OverlayItemActions, OverlayItemDescription}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("stack = %v, want %v", s, want)
+	}
+
+	// 説明を閉じると行動メニューに戻る
+	s.Close(OverlayItemDescription)
+	if s.Top() != OverlayItemActions {
+		t.Errorf("Top after closing the description = %v, want %v", s.Top(), OverlayItemActions)
+	}
+	// 持ち物を閉じると上に重なったウィンドウも閉じる
+	s.Open(OverlayItemDescription)
+	s.Close(OverlayInventory)
+	if !s.Empty() {
+		t.Errorf("stack after closing the inventory = %v, want empty", s)
+	}
+	s.Close(OverlayEquipment) // 開いていないウィンドウを閉じても何も起きない
+	if !s.Empty() {
+		t.Errorf("stack after closing a closed overlay = %v, want empty", s)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	prefabDir      = "prefabs" // 部屋の型のファイルを置くディレクトリ
+	maxPrefabRooms = 2         // 1フロアに置く部屋の型の上限
+	minPrefabSize  = 5
+)
+
+// Prefab はテキストで描いた部屋の型 (宝物庫・水堀の部屋・柱の広間など)
+type Prefab struct {
+	Name   string
+	Chance float64  // 部屋がこの型になる確率
+	Rotate bool     // 90度ずつ回転して置いてよいかどうか
+	Mirror bool     // 左右反転して置いてよいかどうか
+	Rows   []string // 外周の壁を含む部屋の形
+}
+
+// parsePrefab parses a prefab file: "key: value" header lines, a blank line and the rows.
+// Each character of the rows is one tile:
+//
+//	'#' 壁, '.' 床, 'O' 柱, '~' 水, '^' 溶岩, '+' 扉, 'L' 鍵のかかった扉, '*' アイテム, 'E' 敵, ' ' 何もない場所
+//
+// 外周はすべて壁にすること (通路は外周の壁を破って部屋に入る)
+func parsePrefab(data string) (Prefab, error) {
+	var p Prefab
+	header, body, found := strings.Cut(strings.ReplaceAll(data, "\r", ""), "\n\n")
+	if !found {
+		return p, fmt.Errorf("prefab has no blank line between the header and the rows")
+	}
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return p, fmt.Errorf("invalid header line %q", line)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch strings.TrimSpace(key) {
+		case "name":
+			p.Name = value
+		case "chance":
+			p.Chance, err = strconv.ParseFloat(value, 64)
+		case "rotate":
+			p.Rotate, err = strconv.ParseBool(value)
+		case "mirror":
+			p.Mirror, err = strconv.ParseBool(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return p, fmt.Errorf("header line %q: %v", line, err)
+		}
+	}
+	if p.Name == "" {
+		return p, fmt.Errorf("prefab has no name")
+	}
+	if p.Chance < 0 || p.Chance > 1 {
+		return p, fmt.Errorf("prefab %q: chance must be between 0 and 1", p.Name)
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		p.Rows = append(p.Rows, line)
+	}
+	width := len([]rune(p.Rows[0]))
+	if width < minPrefabSize || len(p.Rows) < minPrefabSize {
+		return p, fmt.Errorf("prefab %q must be at least %dx%d", p.Name, minPrefabSize, minPrefabSize)
+	}
+	walkable := 0
+	for y, row := range p.Rows {
+		runes := []rune(row)
+		if len(runes) != width {
+			return p, fmt.Errorf("prefab %q: row %d has %d tiles, want %d", p.Name, y+1, len(runes), width)
+		}
+		for x, c := range runes {
+			tile, err := prefabTile(c)
+			if err != nil {
+				return p, fmt.Errorf("prefab %q: row %d, column %d: %v", p.Name, y+1, x+1, err)
+			}
+			edge := x == 0 || y == 0 || x == width-1 || y == len(p.Rows)-1
+			if edge && c != '#' {
+				return p, fmt.Errorf("prefab %q: the outer edge must be wall, found %q at row %d, column %d", p.Name, c, y+1, x+1)
+			}
+			if !tile.Blocked {
+				walkable++
+			}
+		}
+	}
+	if walkable == 0 {
+		return p, fmt.Errorf("prefab %q has no floor", p.Name)
+	}
+	return p, nil
+}
+
+// prefabTile returns the tile drawn by the character of a prefab.
+func prefabTile(c rune) (Tile, error) {
+	switch c {
+	case '#', 'O':
+		return Tile{Type: "wall", Blocked: true, BlockSight: true}, nil
+	case '.', '*', 'E':
+		return Tile{Type: "floor", Blocked: false, BlockSight: false}, nil
+	case '~':
+		return terrainTile("water"), nil
+	case '^':
+		return terrainTile("lava"), nil
+	case '+':
+		return doorTile(doorClosed), nil
+	case 'L':
+		return doorTile(doorLocked), nil
+	case ' ':
+		return Tile{Type: "other", Blocked: true, BlockSight: true}, nil
+	}
+	return Tile{}, fmt.Errorf("unknown tile %q", c)
+}
+
+// Variants returns the shapes the prefab can be placed in, rotated and mirrored
+// as the prefab allows. The first variant is the prefab as it is drawn.
+func (p Prefab) Variants() [][]string {
+	variants := [][]string{p.Rows}
+	if p.Rotate {
+		rows := p.Rows
+		for i := 0; i < 3; i++ {
+			rows = rotateRows(rows)
+			variants = append(variants, rows)
+		}
+	}
+	if p.Mirror {
+		for _, rows := range variants {
+			variants = append(variants, mirrorRows(rows))
+		}
+	}
+	return variants
+}
+
+// rotateRows rotates the rows 90 degrees clockwise.
+func rotateRows(rows []string) []string {
+	grid := make([][]rune, len(rows))
+	for y, row := range rows {
+		grid[y] = []rune(row)
+	}
+	rotated := make([]string, len(grid[0]))
+	for x := range rotated {
+		line := make([]rune, len(grid))
+		for y := range grid {
+			line[len(grid)-1-y] = grid[y][x]
+		}
+		rotated[x] = string(line)
+	}
+	return rotated
+}
+
+// mirrorRows flips the rows left to right.
+func mirrorRows(rows []string) []string {
+	mirrored := make([]string, len(rows))
+	for y, row := range rows {
+		runes := []rune(row)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		mirrored[y] = string(runes)
+	}
+	return mirrored
+}
+
+var prefabs []Prefab // prefabs/ から読み込んだ部屋の型 (起動時に main で読み込む)
+
+// loadPrefabs loads every prefab file in the directory. Files that cannot be read are skipped.
+func loadPrefabs(dir string) []Prefab {
+	loaded := []Prefab{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("failed to read prefabs: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".txt") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("failed to read %s: %v", path, err)
+			continue
+		}
+		p, err := parsePrefab(string(data))
+		if err != nil {
+			log.Printf("invalid prefab %s: %v", path, err)
+			continue
+		}
+		loaded = append(loaded, p)
+	}
+	return loaded
+}
+
+// placeRoom carves the room, or stamps a prefab in its place, and appends it to rooms.
+// モンスターハウスには部屋の型を使わない
+func placeRoom(mapGrid [][]Tile, rooms []Room, room Room) []Room {
+	if room.Kind != RoomNormal || !stampPrefab(mapGrid, rooms, &room) {
+		setRoomCenter(&room)
+		carveRoom(mapGrid, room)
+	}
+	return append(rooms, room)
+}
+
+// stampPrefab may replace the room with a prefab that fits in it. The room shrinks to
+// the size of the prefab, centered where the room was, and its center is moved to the
+// walkable tile nearest to the middle. It returns false if no prefab was stamped.
+func stampPrefab(mapGrid [][]Tile, rooms []Room, room *Room) bool {
+	stamped := 0
+	for _, r := range rooms {
+		if r.Prefab != "" {
+			stamped++
+		}
+	}
+	if stamped >= maxPrefabRooms {
+		return false
+	}
+
+	for _, i := range localRand.Perm(len(prefabs)) {
+		prefab := prefabs[i]
+		if localRand.Float64() >= prefab.Chance {
+			continue
+		}
+		// 部屋の半分より小さい型は置かない (大部屋が小部屋にならないように)
+		var fits [][]string
+		for _, rows := range prefab.Variants() {
+			w, h := len([]rune(rows[0])), len(rows)
+			if w <= room.Width && h <= room.Height && w*2 >= room.Width && h*2 >= room.Height {
+				fits = append(fits, rows)
+			}
+		}
+		if len(fits) == 0 {
+			continue
+		}
+		rows := fits[localRand.Intn(len(fits))]
+		w, h := len([]rune(rows[0])), len(rows)
+		room.X += (room.Width - w) / 2
+		room.Y += (room.Height - h) / 2
+		room.Width, room.Height = w, h
+		room.Prefab = prefab.Name
+
+		centerX, centerY := room.X+w/2, room.Y+h/2
+		bestDistance := -1
+		for y, row := range rows {
+			for x, c := range []rune(row) {
+				tile, _ := prefabTile(c)
+				mapX, mapY := room.X+x, room.Y+y
+				mapGrid[mapY][mapX] = tile
+				switch c {
+				case '*':
+					room.ItemSpawns = append(room.ItemSpawns, Coordinate{X: mapX, Y: mapY})
+				case 'E':
+					room.EnemySpawns = append(room.EnemySpawns, Coordinate{X: mapX, Y: mapY})
+				}
+				if tile.Type == "floor" {
+					if d := abs(mapX-centerX) + abs(mapY-centerY); bestDistance < 0 || d < bestDistance {
+						room.Center, bestDistance = Coordinate{X: mapX, Y: mapY}, d
+					}
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
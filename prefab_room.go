@@ -0,0 +1,25 @@
+//go:build !test
+// +build !test
+
+package main
+
+// populatePrefabRooms puts the items and the sleeping enemies the prefabs ask for.
+// 海老さんの近くの敵は置かない
+func populatePrefabRooms(d *DungeonDef, rooms []Room, floor int, player Coordinate, enemies []Enemy, items []Item) ([]Enemy, []Item) {
+	for _, room := range rooms {
+		for _, p := range room.ItemSpawns {
+			if !itemAt(items, p.X, p.Y) {
+				items = append(items, d.createItem(p.X, p.Y))
+			}
+		}
+		for _, p := range room.EnemySpawns {
+			if enemyAt(enemies, p.X, p.Y) || max(abs(p.X-player.X), abs(p.Y-player.Y)) < minSpawnDistance {
+				continue
+			}
+			enemy := d.createEnemy(p.X, p.Y, floor)
+			enemy.State = StateSleeping
+			enemies = append(enemies, enemy)
+		}
+	}
+	return enemies, items
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestPrefabFiles checks that every prefab shipped with the game can be parsed.
+func TestPrefabFiles(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(prefabDir, "*.txt"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no prefabs found: %v", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parsePrefab(string(data)); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestParsePrefabErrors(t *testing.T) {
+	rows := "#####\n#...#\n#.*.#\n#...#\n#####\n"
+	tests := []string{
+		"chance: 0.5\n\n" + rows,           // no name
+		"name: a\nchance: 2\n\n" + rows,    // chance out of range
+		"name: a\nsize: 3\n\n" + rows,      // unknown key
+		"name: a\n" + rows,                 // no blank line
+		"name: a\n\n#####\n#...#\n#####\n", // too small
+		"name: a\n\n#####\n#...#\n#.?.#\n#...#\n#####\n",
+		"name: a\n\n#####\n#...#\n#....\n#...#\n#####\n", // edge is not wall
+		"name: a\n\n#####\n#####\n#####\n#####\n#####\n", // no floor
+	}
+	for _, data := range tests {
+		if _, err := parsePrefab(data); err == nil {
+			t.Errorf("parsePrefab(%q) returned no error", data)
+		}
+	}
+}
+
+func TestPrefabVariants(t *testing.T) {
+	rows := []string{
+		"ab",
+		"cd",
+		"ef",
+	}
+	if got, want := rotateRows(rows), []string{"eca", "fdb"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rotateRows = %q, want %q", got, want)
+	}
+	if got, want := mirrorRows(rows), []string{"ba", "dc", "fe"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mirrorRows = %q, want %q", got, want)
+	}
+
+	p := Prefab{Rows: rows}
+	if n := len(p.Variants()); n != 1 {
+		t.Errorf("fixed prefab has %d variants, want 1", n)
+	}
+	p.Rotate, p.Mirror = true, true
+	variants := p.Variants()
+	if len(variants) != 8 {
+		t.Fatalf("rotating and mirroring prefab has %d variants, want 8", len(variants))
+	}
+	// 4回まわすと元に戻る
+	if back := rotateRows(variants[3]); !reflect.DeepEqual(back, rows) {
+		t.Errorf("rotating four times = %q, want %q", back, rows)
+	}
+}
@@ -0,0 +1,155 @@
+//go:build !test
+// +build !test
+
+package main
+
+import "fmt"
+
+// Projectile は敵が撃ってくる飛び道具 (石や魔法弾など)
+type Projectile struct {
+	BaseItem
+	Damage        int    // 基本ダメージ
+	Verb          string // 撃ったときのメッセージに使う動詞
+	IgnoreDefense bool   // 防御力を無視してダメージを与えるかどうか
+	Remains       bool   // 外れたときに床に残るかどうか
+}
+
+func (p *Projectile) Use(g *Game) {}
+
+// ProjectileDefinition は敵が撃ってくる飛び道具の種類ごとの定義
+type ProjectileDefinition struct {
+	ItemType      string // 描画に使うアイテムの種類
+	Damage        int
+	Verb          string
+	IgnoreDefense bool
+	Remains       bool
+}
+
+// projectileDefinitions の鍵が rangedBehavior.Projectile の名前になる
+var projectileDefinitions = map[string]ProjectileDefinition{
+	"水鉄砲": {ItemType: "Effect", Damage: 1, Verb: "撃った"},
+	"石":   {ItemType: "Stone", Damage: 3, Verb: "投げた", Remains: true},
+	"魔法弾": {ItemType: "Effect", Damage: 5, Verb: "放った", IgnoreDefense: true},
+}
+
+// newProjectile creates the projectile an enemy fires. 矢は拾って使える本物の矢になる
+func newProjectile(name string, x, y int) Item {
+	if name == "矢" {
+		return &Arrow{
+			BaseItem: BaseItem{
+				Entity:      Entity{X: x, Y: y, Char: '!'},
+				ID:          6,
+				Type:        "Arrow",
+				Name:        "矢",
+				Description: "敵が撃ってきた矢。",
+				UseActions:  map[string]UseAction{"ArrowEffect": func(g *Game) {}},
+			},
+			ShotCount:   1,
+			AttackPower: 2,
+			Identified:  true,
+		}
+	}
+	def := projectileDefinitions[name]
+	return &Projectile{
+		BaseItem: BaseItem{
+			Entity: Entity{X: x, Y: y, Char: '*'},
+			ID:     -1,
+			Type:   def.ItemType,
+			Name:   name,
+		},
+		Damage:        def.Damage,
+		Verb:          def.Verb,
+		IgnoreDefense: def.IgnoreDefense,
+		Remains:       def.Remains,
+	}
+}
+
+// throwVerb returns the verb used in the message when an enemy fires the item.
+func throwVerb(item Item) string {
+	switch item := item.(type) {
+	case *Projectile:
+		return item.Verb
+	case *Arrow:
+		return "撃った"
+	default:
+		return "投げた"
+	}
+}
+
+// enemyThrow makes the enemy at index i fire the item in the direction it faces,
+// along the same path as the items thrown by the player.
+func (g *Game) enemyThrow(i int, item Item, throwRange int) {
+	uid := g.state.Enemies[i].UID
+	onWallHit := func(item Item, position Coordinate, itemIndex int) {
+		g.onProjectileWallHit(item, position)
+	}
+	onTargetHit := func(target Character, item Item, index int) {
+		g.onProjectileHit(uid, target, item)
+	}
+	g.ThrowItem(item, throwRange, &g.state.Enemies[i], g.state.Map, g.state.Enemies, onWallHit, onTargetHit)
+}
+
+// onProjectileWallHit drops the enemy's projectile where it stopped.
+func (g *Game) onProjectileWallHit(item Item, position Coordinate) {
+	item.SetPosition(position.X, position.Y)
+	g.ThrownItemDestination = position
+	g.makeNoise(position.X, position.Y, throwNoiseRadius)
+}
+
+// onProjectileHit calculates the damage of the projectile fired by the enemy
+// and passes it to the common hit handler.
+func (g *Game) onProjectileHit(attacker int, target Character, item Item) {
+	i := g.enemyIndexByUID(attacker)
+	if i < 0 {
+		return
+	}
+	e := g.state.Enemies[i]
+	var damage int
+	switch item := item.(type) {
+	case *Projectile:
+		damage = item.Damage + e.AttackPower/2
+		if !item.IgnoreDefense {
+			damage -= target.GetDefensePower()
+		}
+	case *Arrow:
+		damage = item.AttackPower + e.AttackPower - target.GetDefensePower()
+	default:
+		damage = localRand.Intn(3) + 1
+	}
+	damage = max(0, damage+localRand.Intn(3)-1)
+	g.hitWithProjectile(target, damage, attacker)
+}
+
+// characterUID returns the UID of the player or the enemy.
+func characterUID(c Character) int {
+	if enemy, ok := c.(*Enemy); ok {
+		return enemy.UID
+	}
+	return playerUID
+}
+
+// hitWithProjectile deals the damage of a thrown item or a projectile to the target.
+// source is the UID of whoever threw it.
+func (g *Game) hitWithProjectile(target Character, damage, source int) {
+	message := fmt.Sprintf("%sに%dのダメージを与えた。", target.GetName(), damage)
+	if i := g.enemyIndexByUID(source); i >= 0 {
+		if _, ok := target.(*Player); ok {
+			message = fmt.Sprintf("%sから%dダメージを受けた", g.state.Enemies[i].Name, damage)
+		} else {
+			message = fmt.Sprintf("%sに%dのダメージ。", target.GetName(), damage)
+		}
+	}
+
+	targetUID := characterUID(target)
+	action := Action{
+		Duration: 0.5, // Assuming a duration of 0.5 seconds for this action
+		Message:  message,
+		Execute: func(g *Game) {
+			g.DealDamage(source, targetUID, damage, DamageProjectile)
+			if source == playerUID {
+				g.isActioned = true // 海老さんが投げた場合は敵のターンになる
+			}
+		},
+	}
+	g.Enqueue(action)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+const (
+	recordsFile = "records.json" // 冒険の記録を保存するファイル
+	maxRecords  = 10             // 残しておく記録の数
+)
+
+// RunRecord は1回の冒険の記録
+type RunRecord struct {
+	Dungeon string
+	Floor   int // 到達した階層
+	Level   int
+	Turns   int
+	Cleared bool // 最深部を踏破したかどうか (false は途中でやめた冒険)
+}
+
+// betterRun reports whether a ranks above b: cleared runs first, then deeper floors,
+// then fewer turns.
+func betterRun(a, b RunRecord) bool {
+	if a.Cleared != b.Cleared {
+		return a.Cleared
+	}
+	if a.Floor != b.Floor {
+		return a.Floor > b.Floor
+	}
+	return a.Turns < b.Turns
+}
+
+// addRecord adds the record and keeps the best maxRecords records in rank order.
+func addRecord(records []RunRecord, r RunRecord) []RunRecord {
+	records = append(append([]RunRecord(nil), records...), r)
+	sort.SliceStable(records, func(i, j int) bool { return betterRun(records[i], records[j]) })
+	if len(records) > maxRecords {
+		records = records[:maxRecords]
+	}
+	return records
+}
+
+// parseRecords parses the saved records.
+func parseRecords(data []byte) ([]RunRecord, error) {
+	var records []RunRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// recordLine returns the line of the records screen for the record of the rank.
+func recordLine(rank int, r RunRecord) string {
+	result := "中断"
+	if r.Cleared {
+		result = "踏破"
+	}
+	return fmt.Sprintf("%2d. %s %s B%dF Lv%d %dターン", rank, r.Dungeon, result, r.Floor, r.Level, r.Turns)
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestAddRecord(t *testing.T) {
+	var records []RunRecord
+	for floor := 1; floor <= maxRecords+2; floor++ {
+		records = addRecord(records, RunRecord{Dungeon: "a", Floor: floor, Turns: 100})
+	}
+	records = addRecord(records, RunRecord{Dungeon: "a", Floor: 5, Turns: 300, Cleared: true})
+	if len(records) != maxRecords {
+		t.Fatalf("%d records kept, want %d", len(records), maxRecords)
+	}
+	// 踏破した冒険が先頭で、あとは深い階層の順
+	if !records[0].Cleared || records[1].Floor != maxRecords+2 || records[len(records)-1].Floor != 4 {
+		t.Errorf("records = %+v, want the cleared run first and the deepest floors after it", records)
+	}
+}
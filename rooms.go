@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+type Room struct {
+	ID            int
+	X, Y          int
+	Width, Height int
+	Center        Coordinate
+	Kind          RoomKind     // 部屋の種類
+	Triggered     bool         // モンスターハウスが起動済みかどうか
+	Prefab        string       // 部屋の型の名前 (型を使っていない部屋では空)
+	ItemSpawns    []Coordinate // 部屋の型で必ずアイテムを置く場所
+	EnemySpawns   []Coordinate // 部屋の型で必ず敵を置く場所
+	Dark          bool         // 暗い部屋では海老さんの周りしか見えない (灯りのカードで明るくなる)
+}
+
+// RoomKind は部屋の種類
+type RoomKind int
+
+const (
+	RoomNormal       RoomKind = iota
+	RoomMonsterHouse          // 眠った敵とアイテムが詰め込まれた部屋
+)
+
+const monsterHouseChance = 0.15 // フロアにモンスターハウスができる確率 (部屋ごと)
+
+// chooseRoomKind decides the kind of a new room. Only one monster house is made per floor.
+func chooseRoomKind(rooms []Room) RoomKind {
+	if len(rooms) == 0 {
+		return RoomNormal // 最初の部屋はプレイヤーの部屋になることがあるので普通の部屋にする
+	}
+	for _, room := range rooms {
+		if room.Kind == RoomMonsterHouse {
+			return RoomNormal
+		}
+	}
+	if localRand.Float64() < monsterHouseChance {
+		return RoomMonsterHouse
+	}
+	return RoomNormal
+}
+
+// carveRoom draws the walls and the floor of the room.
+func carveRoom(mapGrid [][]Tile, room Room) {
+	for y := room.Y; y < room.Y+room.Height; y++ {
+		for x := room.X; x < room.X+room.Width; x++ {
+			if x == room.X || x == room.X+room.Width-1 || y == room.Y || y == room.Y+room.Height-1 {
+				mapGrid[y][x] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+			} else {
+				mapGrid[y][x] = Tile{Type: "floor", Blocked: false, BlockSight: false}
+			}
+		}
+	}
+}
+
+func isInsideRoomOrOnBoundary(x, y int, rooms []Room) bool {
+	for _, room := range rooms {
+		if x >= room.X && x <= room.X+room.Width-1 &&
+			y >= room.Y && y <= room.Y+room.Height-1 {
+			return true
+		}
+	}
+	return false
+}
+
+func drawCorridor(mapGrid [][]Tile, room1, room2 Room, rooms []Room) {
+	// Get the center coordinates of the rooms
+	x1, y1 := room1.Center.X, room1.Center.Y
+	x2, y2 := room2.Center.X, room2.Center.Y
+
+	// Determine the turning point
+	turnX, turnY := x1, y2
+
+	_, _, vertexDetected := detectVertex(mapGrid, x1, y1, turnX, turnY, rooms)
+	if vertexDetected {
+		turnX, turnY = x2, y1
+	}
+
+	_, _, vertexDetected = detectVertex(mapGrid, turnX, turnY, x2, y2, rooms)
+
+	if vertexDetected {
+		turnX, turnY = x2, y1
+	}
+
+	// Draw the corridor from the center of room1 to the turning point
+	drawSegment(mapGrid, x1, y1, turnX, turnY, rooms)
+
+	// Draw the corridor from the turning point to the center of room2
+	drawSegment(mapGrid, turnX, turnY, x2, y2, rooms)
+}
+
+func detectVertex(mapGrid [][]Tile, startX, startY, endX, endY int, rooms []Room) (int, int, bool) {
+	// Determine the direction of the scan based on the start and end coordinates
+	deltaX := 0
+	deltaY := 0
+	if startX != endX {
+		deltaX = increment(startX, endX)
+	} else {
+		deltaY = increment(startY, endY)
+	}
+
+	// Initialize current position to the start coordinates
+	currentX := startX
+	currentY := startY
+
+	// Continue the scan until the end point is reached or an edge is detected
+	for currentX != endX || currentY != endY {
+		// Update the current position
+		currentX += deltaX
+		currentY += deltaY
+		for _, room := range rooms {
+			// Calculate the vertices of the room
+			topLeftX, topLeftY := room.X, room.Y
+			topRightX, topRightY := room.X+room.Width-1, room.Y
+			bottomLeftX, bottomLeftY := room.X, room.Y+room.Height-1
+			bottomRightX, bottomRightY := room.X+room.Width-1, room.Y+room.Height-1
+
+			// Check if the current position is near any of the vertices of the room
+			if (currentX == topLeftX && currentY == topLeftY) ||
+				(currentX == topRightX && currentY == topRightY) ||
+				(currentX == bottomLeftX && currentY == bottomLeftY) ||
+				(currentX == bottomRightX && currentY == bottomRightY) {
+				// Vertex detected, stop the scan and return the current position
+				return currentX, currentY, true
+			}
+		}
+	}
+
+	// No vertex detected, return the original turnY value and false
+	return currentX, currentY, false
+}
+
+func increment(start, end int) int {
+	if start < end {
+		return 1 // Increment positively
+	}
+	return -1 // Increment negatively
+}
+
+func drawSegment(mapGrid [][]Tile, startX, startY, endX, endY int, rooms []Room) {
+	for x := min(startX, endX); x <= max(startX, endX); x++ {
+		for y := min(startY, endY); y <= max(startY, endY); y++ {
+			isBoundary := false
+			for _, room := range rooms {
+				if isOnBoundary(x, y, room) {
+					isBoundary = true
+					mapGrid[y][x] = Tile{Type: "corridor", Blocked: false, BlockSight: false}
+					break
+				}
+			}
+			if !isBoundary && !isInsideRoomOrOnBoundary(x, y, rooms) {
+				mapGrid[y][x] = Tile{Type: "corridor", Blocked: false, BlockSight: false}
+			}
+		}
+	}
+}
+
+func isOnBoundary(x, y int, room Room) bool {
+	left := room.X
+	right := room.X + room.Width - 1
+	top := room.Y
+	bottom := room.Y + room.Height - 1
+
+	// Check if (x, y) is on the left, right, top, or bottom edge of the room
+	isOnLeftEdge := x == left && y >= top && y <= bottom
+	isOnRightEdge := x == right && y >= top && y <= bottom
+	isOnTopEdge := y == top && x >= left && x <= right
+	isOnBottomEdge := y == bottom && x >= left && x <= right
+
+	return isOnLeftEdge || isOnRightEdge || isOnTopEdge || isOnBottomEdge
+}
+
+func connectRooms(rooms []Room, mapGrid [][]Tile, style string, extraLoopChance float64) {
+	if len(rooms) == 0 {
+		fmt.Println("No rooms to connect")
+		return
+	}
+
+	// Step 2: Connect each room to its nearest neighbor
+	for _, room := range rooms {
+		nearestNeighbor := findNearestNeighbor(room, rooms)
+		// Assuming drawCorridor is updated to take Room structs or center coordinates as arguments
+		drawCorridor(mapGrid, room, nearestNeighbor, rooms)
+	}
+
+	// Step 3: Connect all rooms in a circular manner (or in a row without closing the ring)
+	for i := 0; i < len(rooms); i++ {
+		if style == corridorTree && i == len(rooms)-1 {
+			break
+		}
+		// Get the next room index, wrapping back to 0 if at the end of the rooms slice
+		nextRoomIndex := (i + 1) % len(rooms)
+		// Again, assuming drawCorridor is updated to take Room structs or center coordinates as arguments
+		drawCorridor(mapGrid, rooms[i], rooms[nextRoomIndex], rooms)
+	}
+
+	// Step 4: Some rooms get one more corridor to a random room, making extra loops
+	for _, room := range rooms {
+		if extraLoopChance > 0 && len(rooms) > 2 && localRand.Float64() < extraLoopChance {
+			other := rooms[localRand.Intn(len(rooms))]
+			if other.ID != room.ID {
+				drawCorridor(mapGrid, room, other, rooms)
+			}
+		}
+	}
+
+	fmt.Println("All rooms are connected")
+}
+
+// Updated calculateDistance function to accept Room structures as arguments
+func calculateDistance(room1, room2 Room) float64 {
+	deltaX := float64(room2.Center.X - room1.Center.X)
+	deltaY := float64(room2.Center.Y - room1.Center.Y)
+	return math.Sqrt(deltaX*deltaX + deltaY*deltaY)
+}
+
+func findNearestNeighbor(room Room, rooms []Room) Room {
+	minDistance := math.MaxFloat64
+	var nearestRoom Room
+
+	for _, neighbor := range rooms {
+		// Skip if it's the same room
+		if room.ID == neighbor.ID {
+			continue
+		}
+
+		distance := calculateDistance(room, neighbor) // Updated to pass Room structures
+		if distance < minDistance {
+			minDistance = distance
+			nearestRoom = neighbor
+		}
+	}
+
+	return nearestRoom
+}
+
+func (r *Room) IsSeparatedBy(other Room, tiles int) bool {
+	// Horizontal separation
+	if r.X+r.Width+tiles <= other.X || other.X+other.Width+tiles <= r.X {
+		return true
+	}
+	// Vertical separation
+	if r.Y+r.Height+tiles <= other.Y || other.Y+other.Height+tiles <= r.Y {
+		return true
+	}
+	return false
+}
+
+// Helper function to calculate the distance between two points
+func distance(x1, y1, x2, y2 int) int {
+	dx := x2 - x1
+	dy := y2 - y1
+	return int(math.Sqrt(float64(dx*dx + dy*dy)))
+}
+
+// Helper function to check if the distance between the center of the new room
+// and the center of any existing room is within a specific range
+func isWithinDistanceRange(newRoom Room, rooms []Room, minDistance, maxDistance int) bool {
+	for _, room := range rooms {
+		dist := distance(newRoom.Center.X, newRoom.Center.Y, room.Center.X, room.Center.Y)
+		if dist < minDistance || dist > maxDistance {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRooms scatters rooms over the map as the parameters allow. Rooms that find
+// no place in p.RoomAttempts tries are left out.
+func generateRooms(mapGrid [][]Tile, p GenParams) []Room {
+	var rooms []Room
+	width, height := p.Width, p.Height
+	roomSize := func() int { return p.MinRoomSize + localRand.Intn(p.MaxRoomSize-p.MinRoomSize+1) }
+
+	numRooms := p.MinRooms + localRand.Intn(p.MaxRooms-p.MinRooms+1)
+	for i := 0; i < numRooms; i++ { // Attempt to create a specified number of rooms
+		for attempt := 0; attempt < p.RoomAttempts; attempt++ {
+			var roomX, roomY, roomWidth, roomHeight int
+
+			// If there are already rooms created, try to align the new room with one of them
+			if len(rooms) > 0 {
+				alignWith := rooms[localRand.Intn(len(rooms))] // Randomly select a room to align with
+
+				// Randomly decide to align horizontally or vertically
+				if localRand.Intn(2) == 0 {
+					// Align horizontally
+					roomWidth = roomSize()
+					roomHeight = alignWith.Height // Match the height of the room to align with
+					roomX = localRand.Intn(width-roomWidth-1) + 1
+					roomY = alignWith.Y
+				} else {
+					// Align vertically
+					roomWidth = alignWith.Width // Match the width of the room to align with
+					roomHeight = roomSize()
+					roomX = alignWith.X
+					roomY = localRand.Intn(height-roomHeight-1) + 1
+				}
+			} else {
+				// If this is the first room, generate random dimensions and position
+				roomWidth = roomSize()
+				roomHeight = roomSize()
+				roomX = localRand.Intn(width-roomWidth-1) + 1
+				roomY = localRand.Intn(height-roomHeight-1) + 1
+			}
+
+			newRoom := Room{
+				ID:     len(rooms), // Assign the unique ID to the room
+				X:      roomX,
+				Y:      roomY,
+				Width:  roomWidth,
+				Height: roomHeight,
+				Kind:   chooseRoomKind(rooms),
+			}
+			valid := true
+			for _, room := range rooms {
+				if !newRoom.IsSeparatedBy(room, p.RoomSeparation) {
+					valid = false
+					break
+				}
+			}
+
+			if valid {
+				// New validation to ensure rooms are not too far apart
+				setRoomCenter(&newRoom)
+				if !isWithinDistanceRange(newRoom, rooms, p.MinRoomDistance, p.MaxRoomDistance) {
+					continue // Skip the rest of the loop and try again if the room is too far or too close
+				}
+				rooms = placeRoom(mapGrid, rooms, newRoom)
+				break // Exit the inner loop as soon as a room is successfully created
+			}
+		}
+	}
+
+	return rooms
+}
+
+func setRoomCenter(room *Room) {
+	// Calculate the center coordinates
+	centerX := room.X + room.Width/2
+	centerY := room.Y + room.Height/2
+
+	// If the calculated center coordinates are even, increment them by 1 to make them odd
+	if centerX%2 == 0 {
+		centerX++
+	}
+	if centerY%2 == 0 {
+		centerY++
+	}
+
+	// Set the center coordinates
+	room.Center = Coordinate{X: centerX, Y: centerY}
+}
+
+func isSameRoom(x1, y1, x2, y2 int, rooms []Room) bool {
+	var room1, room2 Room
+	foundRoom1, foundRoom2 := false, false // New variables to track if room1 and room2 are found
+
+	//log.Printf("Checking if points (%d, %d) and (%d, %d) are in the same room\n", x1, y1, x2, y2) // Log input points
+	for _, room := range rooms {
+		// Adjust the conditions to check if the points are within the inner boundaries of the room
+		if x1 > room.X && x1 < room.X+room.Width-1 && y1 > room.Y && y1 < room.Y+room.Height-1 {
+			room1 = room
+			foundRoom1 = true // Set foundRoom1 to true if room1 is found
+		}
+		if x2 > room.X && x2 < room.X+room.Width-1 && y2 > room.Y && y2 < room.Y+room.Height-1 {
+			room2 = room
+			foundRoom2 = true // Set foundRoom2 to true if room2 is found
+		}
+	}
+
+	// If either point is not in a room, return false
+	if !foundRoom1 || !foundRoom2 {
+		return false
+	}
+
+	result := room1.ID == room2.ID
+
+	return result
+}
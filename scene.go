@@ -0,0 +1,148 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	layoutWidth  = 640 // 画面の大きさ (ウィンドウの大きさは設定の倍率を掛ける)
+	layoutHeight = 480
+)
+
+// Scene はタイトル画面やダンジョンの中など、画面ごとの処理。SceneManager が今の画面に
+// Update と Draw を任せる
+type Scene interface {
+	Update() error
+	Draw(screen *ebiten.Image)
+}
+
+// SceneManager は ebiten に渡すゲーム本体。今の画面を切り替え、中断した冒険と記録を持つ
+type SceneManager struct {
+	current   Scene
+	suspended *Game       // タイトル画面に戻って中断している冒険 (「つづきから」で戻る)。保存はしないので、ゲームを終了すると消える
+	records   []RunRecord // 冒険の記録 (良い順)
+}
+
+// scenes は起動中の SceneManager
+var scenes = &SceneManager{}
+
+func (m *SceneManager) Update() error {
+	return m.current.Update()
+}
+
+func (m *SceneManager) Draw(screen *ebiten.Image) {
+	m.current.Draw(screen)
+}
+
+func (m *SceneManager) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return layoutWidth, layoutHeight
+}
+
+// Switch shows the scene from the next frame.
+func (m *SceneManager) Switch(scene Scene) {
+	m.current = scene
+}
+
+// startGame starts a new run. A run that was suspended is given up and recorded.
+func (m *SceneManager) startGame(g *Game) {
+	m.abandon()
+	m.Switch(g)
+}
+
+// suspend keeps the run and goes back to the title screen.
+func (m *SceneManager) suspend(g *Game) {
+	m.suspended = g
+	m.Switch(newTitleScene())
+}
+
+// resume goes back into the suspended run.
+func (m *SceneManager) resume() {
+	if m.suspended == nil {
+		return
+	}
+	g := m.suspended
+	m.suspended = nil
+	m.Switch(g)
+}
+
+// abandon records the suspended run as given up, if there is one.
+func (m *SceneManager) abandon() {
+	if m.suspended != nil {
+		m.recordRun(m.suspended, false)
+		m.suspended = nil
+	}
+}
+
+// clearRun records the cleared run and shows the ending.
+func (m *SceneManager) clearRun(g *Game) {
+	m.recordRun(g, true)
+	m.Switch(&EndingScene{game: g})
+}
+
+// recordRun adds the run to the records and saves them.
+func (m *SceneManager) recordRun(g *Game, cleared bool) {
+	m.records = addRecord(m.records, RunRecord{
+		Dungeon: g.dungeon.Name,
+		Floor:   g.Floor,
+		Level:   g.state.Player.Level,
+		Turns:   g.moveCount,
+		Cleared: cleared,
+	})
+	saveJSON(recordsFile, m.records)
+}
+
+// loadSettings loads the saved settings, or the defaults if there are none.
+func loadSettings(path string) Settings {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("failed to read %s: %v", path, err)
+		}
+		return defaultSettings()
+	}
+	s, err := parseSettings(data)
+	if err != nil {
+		log.Printf("invalid settings %s: %v", path, err)
+	}
+	return s
+}
+
+// loadRecords loads the saved records, or none if there are none.
+func loadRecords(path string) []RunRecord {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("failed to read %s: %v", path, err)
+		}
+		return nil
+	}
+	records, err := parseRecords(data)
+	if err != nil {
+		log.Printf("invalid records %s: %v", path, err)
+	}
+	return records
+}
+
+// saveJSON writes the value to the file. 保存できなくても遊び続けられるのでログに残すだけ
+func saveJSON(path string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("failed to save %s: %v", path, err)
+	}
+}
+
+// applyWindowScale resizes the window to the scale in the settings.
+func applyWindowScale() {
+	ebiten.SetWindowSize(layoutWidth*settings.WindowScale, layoutHeight*settings.WindowScale)
+}
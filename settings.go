@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	settingsFile   = "settings.json" // 設定を保存するファイル
+	minWindowScale = 1
+	maxWindowScale = 3
+)
+
+// 設定画面の行
+const (
+	settingMessageSpeed = iota
+	settingMiniMap
+	settingWindowScale
+	settingCount
+)
+
+// messageSpeeds はメッセージの速さの選択肢。Factor を行動の演出の時間に掛ける
+var messageSpeeds = []struct {
+	Name   string
+	Factor float64
+}{
+	{"遅い", 1.5},
+	{"普通", 1.0},
+	{"速い", 0.5},
+}
+
+// Settings はタイトル画面の設定で変えられる遊び方の好み
+type Settings struct {
+	MessageSpeed int  // メッセージの速さ (messageSpeeds の添字)
+	ShowMiniMap  bool // ミニマップを表示するかどうか
+	WindowScale  int  // ウィンドウの大きさ (画面の何倍か)
+}
+
+// settings は今の設定 (起動時に settingsFile から読み込む)
+var settings = defaultSettings()
+
+func defaultSettings() Settings {
+	return Settings{MessageSpeed: 1, ShowMiniMap: true, WindowScale: 2}
+}
+
+// parseSettings parses the saved settings. Settings missing from the data keep the defaults.
+func parseSettings(data []byte) (Settings, error) {
+	s := defaultSettings()
+	if err := json.Unmarshal(data, &s); err != nil {
+		return defaultSettings(), err
+	}
+	if s.MessageSpeed < 0 || s.MessageSpeed >= len(messageSpeeds) {
+		return defaultSettings(), fmt.Errorf("unknown message speed %d", s.MessageSpeed)
+	}
+	if s.WindowScale < minWindowScale || s.WindowScale > maxWindowScale {
+		return defaultSettings(), fmt.Errorf("window scale %d must be between %d and %d", s.WindowScale, minWindowScale, maxWindowScale)
+	}
+	return s, nil
+}
+
+// actionDuration returns how long an action of the duration is shown at the message speed.
+func (s Settings) actionDuration(duration float64) float64 {
+	return duration * messageSpeeds[s.MessageSpeed].Factor
+}
+
+// change changes the setting on the row of the settings screen by delta, wrapping around.
+func (s *Settings) change(row, delta int) {
+	switch row {
+	case settingMessageSpeed:
+		s.MessageSpeed = (s.MessageSpeed + delta + len(messageSpeeds)) % len(messageSpeeds)
+	case settingMiniMap:
+		s.ShowMiniMap = !s.ShowMiniMap
+	case settingWindowScale:
+		count := maxWindowScale - minWindowScale + 1
+		s.WindowScale = minWindowScale + (s.WindowScale-minWindowScale+delta+count)%count
+	}
+}
+
+// labels returns the rows of the settings screen.
+func (s Settings) labels() []string {
+	miniMap := "表示しない"
+	if s.ShowMiniMap {
+		miniMap = "表示する"
+	}
+	return []string{
+		fmt.Sprintf("メッセージの速さ: %s", messageSpeeds[s.MessageSpeed].Name),
+		fmt.Sprintf("ミニマップ: %s", miniMap),
+		fmt.Sprintf("ウィンドウの大きさ: %d倍", s.WindowScale),
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseSettings(t *testing.T) {
+	s, err := parseSettings([]byte(`{"showMiniMap": false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ShowMiniMap || s.WindowScale != defaultSettings().WindowScale {
+		t.Errorf("parseSettings = %+v, want the defaults without the mini map", s)
+	}
+	for _, data := range []string{`{"messageSpeed": 9}`, `{"windowScale": 0}`, `{`} {
+		if _, err := parseSettings([]byte(data)); err == nil {
+			t.Errorf("parseSettings(%s) returned no error", data)
+		}
+	}
+}
+
+func TestSettingsChange(t *testing.T) {
+	s := Settings{MessageSpeed: 0, WindowScale: maxWindowScale}
+	s.change(settingMessageSpeed, -1) // 最初の選択肢から最後に戻る
+	s.change(settingWindowScale, 1)
+	if s.MessageSpeed != len(messageSpeeds)-1 || s.WindowScale != minWindowScale {
+		t.Errorf("settings = %+v, want both values wrapped around", s)
+	}
+	if got := s.actionDuration(1.0); got != messageSpeeds[s.MessageSpeed].Factor {
+		t.Errorf("actionDuration(1) = %v, want %v", got, messageSpeeds[s.MessageSpeed].Factor)
+	}
+}
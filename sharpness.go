@@ -0,0 +1,22 @@
+package main
+
+import "fmt"
+
+const (
+	maxSharpness = 9  // 強化値の上限
+	minSharpness = -5 // 錆びたときの強化値の下限
+)
+
+// clampSharpness returns the sharpness changed by delta within the allowed range.
+func clampSharpness(sharpness, delta int) int {
+	return max(minSharpness, min(maxSharpness, sharpness+delta))
+}
+
+// rustMessage returns the message shown when the rust attack changed the sharpness of
+// the item by rusted (0 if it did not rust).
+func rustMessage(itemName string, rusted int) string {
+	if rusted == 0 {
+		return fmt.Sprintf("%sは錆びなかった。", itemName)
+	}
+	return fmt.Sprintf("%sが錆びてしまった。", itemName)
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestRustAtMinimumSharpness(t *testing.T) {
+	if got := clampSharpness(minSharpness, -1); got != minSharpness {
+		t.Errorf("clampSharpness(%d, -1) = %d, want %d", minSharpness, got, minSharpness)
+	}
+	if got := clampSharpness(maxSharpness, 1); got != maxSharpness {
+		t.Errorf("clampSharpness(%d, 1) = %d, want %d", maxSharpness, got, maxSharpness)
+	}
+	// 下限で錆びなかった装備には「錆びてしまった」と出さない
+	rusted := clampSharpness(minSharpness, -1) - minSharpness
+	if msg := rustMessage("伝説の剣-5", rusted); msg != "伝説の剣-5は錆びなかった。" {
+		t.Errorf("rustMessage at the minimum = %q", msg)
+	}
+	if msg := rustMessage("伝説の剣", -1); msg != "伝説の剣が錆びてしまった。" {
+		t.Errorf("rustMessage = %q", msg)
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !test
+// +build !test
+
+package main
+
+// updateFloorTurn advances the turn counter of the current floor, spawns enemies
+// and blows the player off the floor after a long stay.
+func (g *Game) updateFloorTurn() {
+	g.floorTurns++
+	if g.isBossFloor() {
+		return // ボスフロアでは敵が湧かず風も吹かない
+	}
+
+	if spawnConfig.RespawnInterval > 0 && g.floorTurns%spawnConfig.RespawnInterval == 0 && len(g.state.Enemies) < spawnConfig.MaxEnemies {
+		g.spawnEnemyOutOfSight()
+	}
+
+	switch g.floorTurns {
+	case spawnConfig.WindWarningTurns:
+		g.Enqueue(Action{Duration: 0.5, Message: "風が吹いてきた…", Execute: func(g *Game) {}})
+	case spawnConfig.WindStrongTurns:
+		g.Enqueue(Action{Duration: 0.5, Message: "風が強くなってきた。早くフロアを離れないと…", Execute: func(g *Game) {}})
+	case spawnConfig.WindBlowTurns:
+		action := Action{
+			Duration: 0.5,
+			Message:  "海老さんは風に吹き飛ばされた！",
+			Execute: func(g *Game) {
+				g.overlays.Close(OverlayStairsPrompt)
+				g.stairsTaken = g.windStairs()
+				g.fadingOut = true // 次のフロアへ飛ばされる
+				g.fadeAlpha = 0.0
+			},
+		}
+		g.Enqueue(action)
+	}
+}
+
+// spawnEnemyOutOfSight places a new enemy in a room the player cannot see.
+func (g *Game) spawnEnemyOutOfSight() {
+	player := g.state.Player
+	for attempt := 0; attempt < 20; attempt++ {
+		var x, y int
+		if len(g.rooms) > 0 {
+			room := g.rooms[localRand.Intn(len(g.rooms))]
+			x = localRand.Intn(room.Width-2) + room.X + 1
+			y = localRand.Intn(room.Height-2) + room.Y + 1
+		} else {
+			// 部屋のない洞窟ではどこかの床に湧く
+			x = localRand.Intn(len(g.state.Map[0]))
+			y = localRand.Intn(len(g.state.Map))
+			if g.state.Map[y][x].Type != "floor" {
+				continue
+			}
+		}
+		if g.state.Map[y][x].Blocked || isOccupied(g, x, y) ||
+			isSameRoom(x, y, player.X, player.Y, g.rooms) || max(abs(x-player.X), abs(y-player.Y)) < minSpawnDistance {
+			continue
+		}
+		g.state.Enemies = append(g.state.Enemies, g.currentDungeon().createEnemy(x, y, g.Floor))
+		return
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+// 階段の種類 (タイルの Type)
+const (
+	stairsDown   = "stairs"        // 次の階層へ降りる階段
+	stairsUp     = "up_stairs"     // 前の階層へ戻る階段
+	stairsBranch = "branch_stairs" // 脇道へ降りる階段
+)
+
+// FloorKey は行き来できるフロアの場所。Branch が空のときは本道
+type FloorKey struct {
+	Branch string
+	Floor  int
+}
+
+// isStairs reports whether the player can take the tile to another floor.
+func isStairs(t Tile) bool {
+	return t.Type == stairsDown || t.Type == stairsUp || t.Type == stairsBranch
+}
+
+// branch returns the branch with the name.
+func (d *DungeonDef) branch(name string) (BranchDef, bool) {
+	for _, b := range d.Branches {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return BranchDef{}, false
+}
+
+// branchAt returns the branch whose stairs are on the floor of the main dungeon.
+func (d *DungeonDef) branchAt(floor int) (BranchDef, bool) {
+	for _, b := range d.Branches {
+		if b.FromFloor == floor {
+			return b, true
+		}
+	}
+	return BranchDef{}, false
+}
+
+// branchDungeon returns the branch as a dungeon of its own. The tables the branch
+// leaves empty are taken from the main dungeon, and it has no boss floors.
+func (d *DungeonDef) branchDungeon(b BranchDef) *DungeonDef {
+	view := &DungeonDef{
+		Name:    b.Name,
+		Depth:   b.FromFloor + b.Depth,
+		Floors:  b.Floors,
+		Enemies: b.Enemies,
+		Items:   b.Items,
+		Rules:   d.Rules,
+	}
+	if len(view.Floors) == 0 {
+		view.Floors = d.Floors
+	}
+	if len(view.Enemies) == 0 {
+		view.Enemies = d.Enemies
+	}
+	if len(view.Items) == 0 {
+		view.Items = d.Items
+	}
+	return view
+}
+
+// lastFloor returns the deepest floor of the main dungeon or of the branch.
+func (d *DungeonDef) lastFloor(branch string) int {
+	if b, ok := d.branch(branch); ok {
+		return b.FromFloor + b.Depth
+	}
+	return d.Depth
+}
+
+// stairsDestination returns the floor the stairs of the kind lead to. It returns false
+// if they lead nowhere, as the down stairs of the deepest floor lead out of the dungeon.
+func (d *DungeonDef) stairsDestination(from FloorKey, kind string) (FloorKey, bool) {
+	switch kind {
+	case stairsDown:
+		if from.Floor < d.lastFloor(from.Branch) {
+			return FloorKey{Branch: from.Branch, Floor: from.Floor + 1}, true
+		}
+	case stairsUp:
+		// 脇道の最初の階層からは本道に戻る
+		if b, ok := d.branch(from.Branch); ok && from.Floor-1 <= b.FromFloor {
+			return FloorKey{Floor: b.FromFloor}, true
+		}
+		if from.Floor > 1 {
+			return FloorKey{Branch: from.Branch, Floor: from.Floor - 1}, true
+		}
+	case stairsBranch:
+		if b, ok := d.branchAt(from.Floor); ok && from.Branch == "" {
+			return FloorKey{Branch: b.Name, Floor: from.Floor + 1}, true
+		}
+	}
+	return FloorKey{}, false
+}
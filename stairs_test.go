@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestStairsDestination(t *testing.T) {
+	d := &DungeonDef{Name: "a", Depth: 10, Branches: []BranchDef{{Name: "b", FromFloor: 4, Depth: 2}}}
+	tests := []struct {
+		from FloorKey
+		kind string
+		want FloorKey
+		ok   bool
+	}{
+		{FloorKey{Floor: 3}, stairsDown, FloorKey{Floor: 4}, true},
+		{FloorKey{Floor: 10}, stairsDown, FloorKey{}, false}, // 最深部の階段はダンジョンの外へ
+		{FloorKey{Floor: 3}, stairsUp, FloorKey{Floor: 2}, true},
+		{FloorKey{Floor: 1}, stairsUp, FloorKey{}, false},
+		{FloorKey{Floor: 4}, stairsBranch, FloorKey{Branch: "b", Floor: 5}, true},
+		{FloorKey{Floor: 3}, stairsBranch, FloorKey{}, false},
+		{FloorKey{Branch: "b", Floor: 5}, stairsDown, FloorKey{Branch: "b", Floor: 6}, true},
+		{FloorKey{Branch: "b", Floor: 6}, stairsDown, FloorKey{}, false}, // 脇道の最下層
+		{FloorKey{Branch: "b", Floor: 6}, stairsUp, FloorKey{Branch: "b", Floor: 5}, true},
+		{FloorKey{Branch: "b", Floor: 5}, stairsUp, FloorKey{Floor: 4}, true}, // 本道に戻る
+	}
+	for _, tt := range tests {
+		got, ok := d.stairsDestination(tt.from, tt.kind)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("stairsDestination(%+v, %s) = %+v, %v, want %+v, %v", tt.from, tt.kind, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBranchDungeon(t *testing.T) {
+	d := &DungeonDef{
+		Name:     "a",
+		Depth:    10,
+		Enemies:  []EnemySpawn{{Type: "Shrimp"}},
+		Items:    []ItemSpawn{{ID: 1}},
+		Branches: []BranchDef{{Name: "b", FromFloor: 4, Depth: 2, Items: []ItemSpawn{{ID: 2}}}},
+	}
+	view := d.branchDungeon(d.Branches[0])
+	if view.Depth != 6 {
+		t.Errorf("branch depth = %d, want 6", view.Depth)
+	}
+	if len(view.Enemies) != 1 || view.Enemies[0].Type != "Shrimp" {
+		t.Errorf("branch enemies = %+v, want the main dungeon's table", view.Enemies)
+	}
+	if len(view.Items) != 1 || view.Items[0].ID != 2 {
+		t.Errorf("branch items = %+v, want the branch's own table", view.Items)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+const (
+	lavaDamage      = 10 // 溶岩に踏み込むと受けるダメージ
+	digTunnelLength = 10 // 穴掘りの杖で掘れるトンネルの長さ
+)
+
+// Terrain はタイルの種類ごとの地形の決まり。歩けるかどうかは Tile.Blocked で決まる
+type Terrain struct {
+	Flyable     bool // 空を飛ぶ敵が上を通れるかどうか
+	StopsThrown bool // 投げた物や魔法弾が止まるかどうか
+	Damage      int  // 踏み込んだときに受けるダメージ
+	Swallows    bool // 落ちたアイテムがなくなるかどうか
+	Diggable    bool // つるはしや穴掘りの杖で壊せるかどうか
+}
+
+var terrains = map[string]Terrain{
+	"wall":          {StopsThrown: true, Diggable: true},
+	"other":         {StopsThrown: true, Diggable: true},
+	"water":         {Flyable: true, Swallows: true},
+	"lava":          {Flyable: true, Damage: lavaDamage, Swallows: true},
+	doorClosed:      {StopsThrown: true},
+	doorLocked:      {StopsThrown: true},
+	"floor":         {Flyable: true},
+	"corridor":      {Flyable: true},
+	stairsDown:      {Flyable: true},
+	stairsUp:        {Flyable: true},
+	stairsBranch:    {Flyable: true},
+	doorOpen:        {Flyable: true},
+	"shrine":        {Flyable: true},
+	"sealed_stairs": {Flyable: true},
+}
+
+// terrainOf returns the terrain rules of the tile. 表にない種類は Blocked に従う
+func terrainOf(t Tile) Terrain {
+	if terrain, ok := terrains[t.Type]; ok {
+		return terrain
+	}
+	return Terrain{Flyable: !t.Blocked, StopsThrown: t.Blocked}
+}
+
+// terrainTile returns a new tile of the terrain type.
+func terrainTile(kind string) Tile {
+	switch kind {
+	case "water":
+		return Tile{Type: kind, Blocked: true, BlockSight: false}
+	case "lava":
+		return Tile{Type: kind, Blocked: false, BlockSight: false}
+	}
+	return Tile{Type: kind, Blocked: true, BlockSight: true}
+}
+
+// canHoldItem reports whether an item can lie on the tile.
+func canHoldItem(t Tile) bool {
+	terrain := terrainOf(t)
+	return !terrain.StopsThrown && !terrain.Swallows
+}
+
+// canEnter reports whether an enemy can move onto the tile. Flying enemies cross
+// water and lava, the others walk around them.
+func canEnter(t Tile, flying bool) bool {
+	terrain := terrainOf(t)
+	if flying {
+		return terrain.Flyable
+	}
+	return !t.Blocked && terrain.Damage == 0
+}
+
+// digTile turns the diggable tile at (x, y) into corridor. The edge of the map cannot
+// be dug. It reports whether the tile was dug.
+func digTile(tiles [][]Tile, x, y int) bool {
+	if y <= 0 || y >= len(tiles)-1 || x <= 0 || x >= len(tiles[y])-1 || !terrainOf(tiles[y][x]).Diggable {
+		return false
+	}
+	visited := tiles[y][x].Visited
+	tiles[y][x] = Tile{Type: "corridor", Blocked: false, BlockSight: false, Visited: visited}
+	return true
+}
+
+// digTunnel digs up to length tiles in a straight line from (x, y). It stops at the
+// first tile that cannot be dug and returns the number of tiles dug.
+func digTunnel(tiles [][]Tile, x, y, dx, dy, length int) int {
+	dug := 0
+	for i := 0; i < length; i++ {
+		tx, ty := x+i*dx, y+i*dy
+		if ty < 0 || ty >= len(tiles) || tx < 0 || tx >= len(tiles[ty]) {
+			break
+		}
+		if !tiles[ty][tx].Blocked {
+			continue // 掘る前から通れる場所は通り抜ける
+		}
+		if !digTile(tiles, tx, ty) {
+			break
+		}
+		dug++
+	}
+	return dug
+}
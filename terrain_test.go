@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestCanEnter(t *testing.T) {
+	tests := []struct {
+		tile         Tile
+		walk, flying bool
+	}{
+		{Tile{Type: "floor"}, true, true},
+		{terrainTile("water"), false, true},
+		{terrainTile("lava"), false, true},
+		{Tile{Type: "wall", Blocked: true, BlockSight: true}, false, false},
+		{doorTile(doorOpen), true, true},
+	}
+	for _, tt := range tests {
+		if got := canEnter(tt.tile, false); got != tt.walk {
+			t.Errorf("canEnter(%s, false) = %v, want %v", tt.tile.Type, got, tt.walk)
+		}
+		if got := canEnter(tt.tile, true); got != tt.flying {
+			t.Errorf("canEnter(%s, true) = %v, want %v", tt.tile.Type, got, tt.flying)
+		}
+	}
+}
+
+func TestDigTunnel(t *testing.T) {
+	// 幅8の地図の2行目: 壁に囲まれた床2マスの右は壁、右端は地図の端
+	tiles := make([][]Tile, 3)
+	for y := range tiles {
+		tiles[y] = make([]Tile, 8)
+		for x := range tiles[y] {
+			tiles[y][x] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+		}
+	}
+	tiles[1][1] = Tile{Type: "floor"}
+	tiles[1][2] = Tile{Type: "floor"}
+
+	if dug := digTunnel(tiles, 1, 1, 1, 0, 10); dug != 4 {
+		t.Errorf("digTunnel dug %d tiles, want 4 (stopping at the edge)", dug)
+	}
+	for x := 3; x < 7; x++ {
+		if tiles[1][x].Blocked {
+			t.Errorf("tile (%d, 1) was not dug", x)
+		}
+	}
+	if !tiles[1][7].Blocked {
+		t.Error("the edge of the map was dug")
+	}
+
+	tiles[1][3] = doorTile(doorLocked)
+	tiles[1][4] = Tile{Type: "wall", Blocked: true, BlockSight: true}
+	if dug := digTunnel(tiles, 3, 1, 1, 0, 10); dug != 0 {
+		t.Errorf("digTunnel through a locked door dug %d tiles, want 0", dug)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"image"
+	"image/color"
+)
+
+const (
+	defaultThemeName  = "cave"
+	floorVariantCount = 4 // 床の見た目の種類 (反転の組み合わせと明るさの揺らぎ)
+	wallEdgeWidth     = 3 // 壁の縁取りの太さ (ピクセル)
+)
+
+// 壁の隣が開けている向き (wallMask のビット)
+const (
+	wallOpenUp = 1 << iota
+	wallOpenRight
+	wallOpenDown
+	wallOpenLeft
+	wallMaskCount = 16
+)
+
+// TileTheme はフロアの見た目のテーマ (洞窟・海底・神殿など)
+type TileTheme struct {
+	Name    string
+	Tileset string                // タイル画像 (空の場合は img/tileset.png)。並びは tileset.png と同じ
+	Tints   map[string][3]float64 // タイルの種類ごとに画像に掛ける色
+	Edge    color.RGBA            // 床に面した壁の縁の色
+}
+
+// tileThemes はダンジョンの定義 (FloorDef の Theme) で選べるテーマ
+var tileThemes = map[string]TileTheme{
+	"cave": {
+		Name: "cave",
+		Tints: map[string][3]float64{
+			"wall":     {0.85, 0.75, 0.65},
+			"floor":    {1.0, 0.95, 0.85},
+			"corridor": {0.95, 0.9, 0.8},
+		},
+		Edge: color.RGBA{0x9a, 0x7b, 0x5a, 0xff},
+	},
+	"sea": {
+		Name: "sea",
+		Tints: map[string][3]float64{
+			"wall":     {0.5, 0.7, 1.0},
+			"floor":    {0.75, 0.9, 1.0},
+			"corridor": {0.7, 0.85, 0.95},
+		},
+		Edge: color.RGBA{0x7f, 0xd8, 0xe0, 0xff},
+	},
+	"temple": {
+		Name: "temple",
+		Tints: map[string][3]float64{
+			"wall":     {1.0, 0.95, 0.8},
+			"floor":    {0.95, 0.9, 1.0},
+			"corridor": {0.9, 0.85, 0.95},
+		},
+		Edge: color.RGBA{0xe8, 0xc8, 0x60, 0xff},
+	},
+}
+
+// themeFor returns the theme with the name, or the default theme.
+func themeFor(name string) TileTheme {
+	if theme, ok := tileThemes[name]; ok {
+		return theme
+	}
+	return tileThemes[defaultThemeName]
+}
+
+// isWallTile reports whether the tile is drawn as part of a wall.
+func isWallTile(t Tile) bool {
+	return t.Type == "wall" || t.Type == "other"
+}
+
+// wallMask returns the sides of the wall at (x, y) that face an open tile. 地図の外は壁とみなす
+func wallMask(tiles [][]Tile, x, y int) int {
+	mask := 0
+	sides := []struct{ dx, dy, bit int }{{0, -1, wallOpenUp}, {1, 0, wallOpenRight}, {0, 1, wallOpenDown}, {-1, 0, wallOpenLeft}}
+	for _, side := range sides {
+		nx, ny := x+side.dx, y+side.dy
+		if ny >= 0 && ny < len(tiles) && nx >= 0 && nx < len(tiles[ny]) && !isWallTile(tiles[ny][nx]) {
+			mask |= side.bit
+		}
+	}
+	return mask
+}
+
+// wallEdgeRects returns the parts of a wall tile of the size drawn in the edge color for the mask.
+func wallEdgeRects(mask, size int) []image.Rectangle {
+	var rects []image.Rectangle
+	if mask&wallOpenUp != 0 {
+		rects = append(rects, image.Rect(0, 0, size, wallEdgeWidth))
+	}
+	if mask&wallOpenRight != 0 {
+		rects = append(rects, image.Rect(size-wallEdgeWidth, 0, size, size))
+	}
+	if mask&wallOpenDown != 0 {
+		rects = append(rects, image.Rect(0, size-wallEdgeWidth, size, size))
+	}
+	if mask&wallOpenLeft != 0 {
+		rects = append(rects, image.Rect(0, 0, wallEdgeWidth, size))
+	}
+	return rects
+}
+
+// assignFloorVariants picks the look of every floor and corridor tile.
+func assignFloorVariants(tiles [][]Tile, roll func(int) int) {
+	for y := range tiles {
+		for x := range tiles[y] {
+			if t := tiles[y][x].Type; t == "floor" || t == "corridor" {
+				tiles[y][x].Variant = roll(floorVariantCount)
+			}
+		}
+	}
+}
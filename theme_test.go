@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestWallMask(t *testing.T) {
+	// ###
+	// #..
+	// ###
+	wall := Tile{Type: "wall", Blocked: true, BlockSight: true}
+	tiles := [][]Tile{
+		{wall, wall, wall},
+		{wall, {Type: "floor"}, {Type: "corridor"}},
+		{wall, wall, wall},
+	}
+	tests := []struct {
+		x, y, want int
+	}{
+		{0, 0, 0}, // 角の壁は床に面していない
+		{1, 0, wallOpenDown},
+		{0, 1, wallOpenRight},
+		{2, 2, wallOpenUp},
+	}
+	for _, tt := range tests {
+		if got := wallMask(tiles, tt.x, tt.y); got != tt.want {
+			t.Errorf("wallMask(%d, %d) = %04b, want %04b", tt.x, tt.y, got, tt.want)
+		}
+	}
+	if n := len(wallEdgeRects(wallOpenUp|wallOpenLeft, 30)); n != 2 {
+		t.Errorf("wallEdgeRects has %d edges for two open sides, want 2", n)
+	}
+}
+
+func TestAssignFloorVariants(t *testing.T) {
+	tiles := [][]Tile{{{Type: "floor"}, {Type: "wall", Blocked: true}, {Type: "corridor"}}}
+	assignFloorVariants(tiles, func(n int) int { return n - 1 })
+	if tiles[0][0].Variant != floorVariantCount-1 || tiles[0][2].Variant != floorVariantCount-1 {
+		t.Errorf("floor variants = %d, %d, want %d", tiles[0][0].Variant, tiles[0][2].Variant, floorVariantCount-1)
+	}
+	if tiles[0][1].Variant != 0 {
+		t.Errorf("wall variant = %d, want 0", tiles[0][1].Variant)
+	}
+	if themeFor("unknown").Name != defaultThemeName {
+		t.Errorf("themeFor(unknown) = %q, want the default theme", themeFor("unknown").Name)
+	}
+}
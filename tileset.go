@@ -0,0 +1,73 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"image"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+var (
+	themeTilesets = map[string]*ebiten.Image{}                // テーマのタイル画像 (最初に使うときに読み込む)
+	themeWalls    = map[string][wallMaskCount]*ebiten.Image{} // テーマごとの縁取りした壁 (wallMask ごと)
+)
+
+// floorVariantShades は床の見た目の種類ごとの明るさの揺らぎ
+var floorVariantShades = [floorVariantCount]float64{1.0, 0.94, 1.04, 0.97}
+
+// updateTheme picks the look of the current floor from the dungeon definition.
+func (g *Game) updateTheme() {
+	g.theme = themeFor(g.currentDungeon().Floor(g.Floor).Theme)
+}
+
+// themeTileset returns the tileset image of the current theme.
+// 読み込めない画像の代わりには img/tileset.png を使う
+func (g *Game) themeTileset() *ebiten.Image {
+	if g.theme.Tileset == "" {
+		return g.tilesetImg
+	}
+	if img, ok := themeTilesets[g.theme.Tileset]; ok {
+		return img
+	}
+	img, _, err := ebitenutil.NewImageFromFile(g.theme.Tileset)
+	if err != nil {
+		log.Printf("failed to load tileset %s: %v", g.theme.Tileset, err)
+		img = g.tilesetImg
+	}
+	themeTilesets[g.theme.Tileset] = img
+	return img
+}
+
+// wallTile returns the wall of the current theme with edges on the open sides of the mask.
+func (g *Game) wallTile(mask int) *ebiten.Image {
+	walls, ok := themeWalls[g.theme.Name]
+	if !ok {
+		base := g.themeTileset().SubImage(image.Rect(0, 0, tileSize, tileSize)).(*ebiten.Image)
+		for m := range walls {
+			walls[m] = ebiten.NewImage(tileSize, tileSize)
+			walls[m].DrawImage(base, nil)
+			for _, rect := range wallEdgeRects(m, tileSize) {
+				walls[m].SubImage(rect).(*ebiten.Image).Fill(g.theme.Edge)
+			}
+		}
+		themeWalls[g.theme.Name] = walls
+	}
+	return walls[mask]
+}
+
+// flipFloorVariant flips the floor tile at the origin for its variant: the bits of the
+// variant flip it left to right and upside down.
+func flipFloorVariant(opts *ebiten.DrawImageOptions, variant int) {
+	if variant&1 != 0 {
+		opts.GeoM.Scale(-1, 1)
+		opts.GeoM.Translate(tileSize, 0)
+	}
+	if variant&2 != 0 {
+		opts.GeoM.Scale(1, -1)
+		opts.GeoM.Translate(0, tileSize)
+	}
+}
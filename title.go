@@ -0,0 +1,160 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// タイトル画面のメニューの項目
+const (
+	titleNewGame = iota
+	titleContinue
+	titleDungeonSelect
+	titleSettings
+	titleRecords
+	titleQuit
+)
+
+var disabledColor = color.RGBA{0x80, 0x80, 0x80, 0xff}
+
+// TitleScene は起動したときと冒険を中断したときに表示するタイトル画面
+type TitleScene struct {
+	menu Menu
+}
+
+func newTitleScene() *TitleScene {
+	s := &TitleScene{menu: Menu{Items: []MenuItem{
+		{Label: "はじめから"},
+		{Label: "つづきから", Disabled: scenes.suspended == nil},
+		{Label: "ダンジョン選択"},
+		{Label: "設定"},
+		{Label: "記録"},
+		{Label: "やめる"},
+	}}}
+	if scenes.suspended != nil {
+		s.menu.Cursor = titleContinue
+	}
+	return s
+}
+
+func (s *TitleScene) Update() error {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		s.menu.move(-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		s.menu.move(1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyZ) && s.menu.selected():
+		switch s.menu.Cursor {
+		case titleNewGame:
+			scenes.startGame(NewGame())
+		case titleContinue:
+			scenes.resume()
+		case titleDungeonSelect:
+			scenes.Switch(newDungeonSelectScene(nil))
+		case titleSettings:
+			scenes.Switch(&SettingsScene{})
+		case titleRecords:
+			scenes.Switch(&RecordsScene{})
+		case titleQuit:
+			scenes.abandon()
+			return ebiten.Termination
+		}
+	}
+	return nil
+}
+
+func (s *TitleScene) Draw(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+	text.Draw(screen, "ebirogue", mplusNormalFont, 120, 100, color.White)
+	text.Draw(screen, dungeons[activeDungeon].Name, mplusNormalFont, 120, 135, disabledColor)
+	drawMenu(screen, s.menu, 160, 190)
+	if scenes.suspended != nil {
+		// 中断した冒険はメモリにあるだけなので、ゲームを終了すると続きからは遊べない
+		text.Draw(screen, "中断した冒険は、ゲームを終了すると消えます", mplusNormalFont, 120, 395, disabledColor)
+	}
+	text.Draw(screen, "上下キーで選び、Zキーで決定 (冒険中はEscキーでここに戻る)", mplusNormalFont, 120, 420, disabledColor)
+}
+
+// drawMenu draws the items of the menu with the cursor, the first item at (x, y).
+func drawMenu(screen *ebiten.Image, menu Menu, x, y int) {
+	for i, item := range menu.Items {
+		clr := color.Color(color.White)
+		if item.Disabled {
+			clr = disabledColor
+		}
+		text.Draw(screen, item.Label, mplusNormalFont, x, y+i*35, clr)
+		if i == menu.Cursor {
+			text.Draw(screen, "→", mplusNormalFont, x-40, y+i*35, color.White)
+		}
+	}
+}
+
+// SettingsScene は設定画面。左右キーかZキーで値を変え、Xキーで保存してタイトルに戻る
+type SettingsScene struct {
+	cursor int
+}
+
+func (s *SettingsScene) Update() error {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		s.cursor = (s.cursor + settingCount - 1) % settingCount
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		s.cursor = (s.cursor + 1) % settingCount
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		s.change(-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight), inpututil.IsKeyJustPressed(ebiten.KeyZ):
+		s.change(1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyX):
+		saveJSON(settingsFile, settings)
+		scenes.Switch(newTitleScene())
+	}
+	return nil
+}
+
+// change changes the setting under the cursor and applies it at once.
+func (s *SettingsScene) change(delta int) {
+	settings.change(s.cursor, delta)
+	if s.cursor == settingWindowScale {
+		applyWindowScale()
+	}
+}
+
+func (s *SettingsScene) Draw(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+	text.Draw(screen, "設定", mplusNormalFont, 120, 100, color.White)
+	menu := Menu{Cursor: s.cursor}
+	for _, label := range settings.labels() {
+		menu.Items = append(menu.Items, MenuItem{Label: label})
+	}
+	drawMenu(screen, menu, 160, 160)
+	text.Draw(screen, "左右キーで変更、Xキーで戻る", mplusNormalFont, 120, 420, disabledColor)
+}
+
+// RecordsScene は冒険の記録の画面
+type RecordsScene struct{}
+
+func (s *RecordsScene) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyX) || inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		scenes.Switch(newTitleScene())
+	}
+	return nil
+}
+
+func (s *RecordsScene) Draw(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+	text.Draw(screen, "記録", mplusNormalFont, 120, 80, color.White)
+	if len(scenes.records) == 0 {
+		text.Draw(screen, "まだ記録はありません", mplusNormalFont, 120, 130, color.White)
+	}
+	for i, r := range scenes.records {
+		text.Draw(screen, recordLine(i+1, r), mplusNormalFont, 60, 130+i*28, color.White)
+	}
+	text.Draw(screen, fmt.Sprintf("Xキーで戻る (上位%d件)", maxRecords), mplusNormalFont, 120, 440, disabledColor)
+}
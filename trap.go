@@ -0,0 +1,146 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+)
+
+// FloorTrapKind はフロアに仕掛けられた罠の種類
+type FloorTrapKind int
+
+const (
+	TrapCurse  FloorTrapKind = iota // 持ち物が呪われる
+	TrapSleep                       // 数ターン眠ってしまう
+	TrapPoison                      // パワーが下がる
+	TrapRust                        // 装備が錆びる
+	trapKindCount
+)
+
+const sleepTrapTurns = 5 // 睡眠の罠で眠るターン数
+
+// FloorTrap はフロアに仕掛けられた罠
+type FloorTrap struct {
+	X, Y     int
+	Kind     FloorTrapKind
+	Revealed bool // 踏んだなどでプレイヤーに見えているかどうか
+}
+
+func (k FloorTrapKind) Name() string {
+	switch k {
+	case TrapCurse:
+		return "呪いの罠"
+	case TrapSleep:
+		return "睡眠の罠"
+	case TrapPoison:
+		return "毒の罠"
+	case TrapRust:
+		return "錆の罠"
+	default:
+		return "罠"
+	}
+}
+
+// generateTraps places hidden traps on random floor tiles.
+func generateTraps(mapGrid [][]Tile, spawns []Coordinate, count int) []FloorTrap {
+	var traps []FloorTrap
+	for i := 0; i < count && len(spawns) > 0; i++ {
+		spawn := spawns[localRand.Intn(len(spawns))]
+		x, y := spawn.X, spawn.Y
+		if mapGrid[y][x].Type != "floor" || trapAt(traps, x, y) != nil {
+			continue // 床以外や罠が重なる場所には置かない
+		}
+		traps = append(traps, FloorTrap{X: x, Y: y, Kind: FloorTrapKind(localRand.Intn(int(trapKindCount)))})
+	}
+	return traps
+}
+
+func trapAt(traps []FloorTrap, x, y int) *FloorTrap {
+	for i := range traps {
+		if traps[i].X == x && traps[i].Y == y {
+			return &traps[i]
+		}
+	}
+	return nil
+}
+
+// isTrapVisible reports whether the trap should be drawn.
+func (g *Game) isTrapVisible(trap FloorTrap) bool {
+	return trap.Revealed || g.state.Player.HasRingEffect(RingTrapSight)
+}
+
+// checkForTrap triggers the trap under the player.
+func (g *Game) checkForTrap() {
+	player := &g.state.Player
+	trap := trapAt(g.state.Traps, player.X, player.Y)
+	if trap == nil {
+		return
+	}
+	trap.Revealed = true
+
+	action := Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("%sを踏んでしまった。", trap.Kind.Name()),
+		Execute:  func(g *Game) {},
+	}
+	g.Enqueue(action)
+
+	switch trap.Kind {
+	case TrapCurse:
+		g.curseRandomItem()
+	case TrapSleep:
+		if player.HasRingEffect(RingAntiSleep) {
+			g.noticeRingEffect(RingAntiSleep)
+			g.Enqueue(Action{Duration: 0.5, Message: "しかし眠らなかった。", Execute: func(g *Game) {}})
+			return
+		}
+		g.Enqueue(Action{
+			Duration: 0.5,
+			Message:  "海老さんは眠ってしまった。",
+			Execute: func(g *Game) {
+				g.state.Player.SleepTurns = sleepTrapTurns
+			},
+		})
+	case TrapPoison:
+		g.poisonPlayer(1)
+	case TrapRust:
+		g.rustEquipment()
+	}
+}
+
+// poisonPlayer lowers the player's power unless a ring protects them.
+func (g *Game) poisonPlayer(amount int) {
+	if g.state.Player.HasRingEffect(RingPoisonImmunity) {
+		g.noticeRingEffect(RingPoisonImmunity)
+		g.Enqueue(Action{Duration: 0.5, Message: "しかし毒は効かなかった。", Execute: func(g *Game) {}})
+		return
+	}
+	if g.state.Player.Power <= 0 {
+		return
+	}
+	g.Enqueue(Action{
+		Duration: 0.5,
+		Message:  fmt.Sprintf("海老さんのパワーが%d下がった。", amount),
+		Execute: func(g *Game) {
+			g.state.Player.Power = max(0, g.state.Player.Power-amount)
+		},
+	})
+}
+
+// handleSleep advances a turn without input while the player is asleep.
+// It returns true if the player is asleep.
+func (g *Game) handleSleep() bool {
+	if g.state.Player.SleepTurns <= 0 {
+		return false
+	}
+	g.Enqueue(Action{
+		Duration: 0.3,
+		Message:  "海老さんは眠っている。",
+		Execute: func(g *Game) {
+			g.state.Player.SleepTurns--
+			g.isActioned = true
+		},
+	})
+	return true
+}
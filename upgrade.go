@@ -0,0 +1,218 @@
+//go:build !test
+// +build !test
+
+package main
+
+import (
+	"fmt"
+)
+
+// ItemSelectPurpose は「どれを？」ウィンドウでアイテムを選ぶ目的
+type ItemSelectPurpose int
+
+const (
+	SelectIdentify ItemSelectPurpose = iota // 真実の眼のカードで識別する
+	SelectEnhance                           // 強化の壺に入れて強化する
+)
+
+// Upgradable is implemented by items whose sharpness can change.
+type Upgradable interface {
+	GetSharpness() int
+	SetSharpness(value int)
+}
+
+func (w *Weapon) GetSharpness() int {
+	return w.Sharpness
+}
+
+func (w *Weapon) SetSharpness(value int) {
+	w.Sharpness = value
+}
+
+func (a *Armor) GetSharpness() int {
+	return a.Sharpness
+}
+
+func (a *Armor) SetSharpness(value int) {
+	a.Sharpness = value
+}
+
+// changeSharpness changes the sharpness of the item within the allowed range and
+// keeps the player stats consistent if it is equipped. It returns the applied change.
+func (g *Game) changeSharpness(item Item, delta int) int {
+	upgradable, ok := item.(Upgradable)
+	if !ok {
+		return 0
+	}
+	newSharpness := clampSharpness(upgradable.GetSharpness(), delta)
+	applied := newSharpness - upgradable.GetSharpness()
+	g.updateEquippedItem(item, func() {
+		upgradable.SetSharpness(newSharpness)
+	})
+	return applied
+}
+
+// equippedWeapon returns the equipped weapon, or nil.
+func (p *Player) equippedWeapon() *Weapon {
+	weapon, _ := p.EquippedItems[SlotWeapon].(*Weapon)
+	return weapon
+}
+
+// equippedArmor returns the equipped armor, or nil.
+func (p *Player) equippedArmor() *Armor {
+	armor, _ := p.EquippedItems[SlotArmor].(*Armor)
+	return armor
+}
+
+// enhanceItem raises the sharpness of the item by one and enqueues the result message.
+func (g *Game) enhanceItem(item Item) {
+	itemName := getItemNameWithSharpness(item)
+	if g.changeSharpness(item, 1) == 0 {
+		g.Enqueue(Action{
+			Duration:     0.5,
+			Message:      fmt.Sprintf("%sはこれ以上強くならない。", itemName),
+			ItemName:     itemName,
+			Execute:      func(g *Game) {},
+			IsIdentified: true,
+		})
+		return
+	}
+	if identifiableItem, ok := item.(Identifiable); ok {
+		identifiableItem.SetIdentified(true) // 強化されたアイテムは識別される
+	}
+	newName := getItemNameWithSharpness(item)
+	g.Enqueue(Action{
+		Duration:     0.5,
+		Message:      fmt.Sprintf("%sになった。", newName),
+		ItemName:     newName,
+		Execute:      func(g *Game) {},
+		IsIdentified: true,
+	})
+}
+
+var enhanceWeapon = func(g *Game) {
+	item, isInventoryItem := determineItemSource(g)
+	g.Enqueue(Action{Duration: 0.4, Message: fmt.Sprintf("%sを使った。", item.GetName()), Execute: func(g *Game) {}})
+	removeUsedItem(g, isInventoryItem)
+
+	weapon := g.state.Player.equippedWeapon()
+	if weapon == nil {
+		g.Enqueue(Action{Duration: 0.5, Message: "武器を装備していない。", Execute: func(g *Game) {}})
+		return
+	}
+	g.enhanceItem(weapon)
+}
+
+var enhanceArmor = func(g *Game) {
+	item, isInventoryItem := determineItemSource(g)
+	g.Enqueue(Action{Duration: 0.4, Message: fmt.Sprintf("%sを使った。", item.GetName()), Execute: func(g *Game) {}})
+	removeUsedItem(g, isInventoryItem)
+
+	armor := g.state.Player.equippedArmor()
+	if armor == nil {
+		g.Enqueue(Action{Duration: 0.5, Message: "防具を装備していない。", Execute: func(g *Game) {}})
+		return
+	}
+	g.enhanceItem(armor)
+}
+
+var useEnhancePot = func(g *Game) {
+	item, isInventoryItem := determineItemSource(g)
+	pot := item.(*Pot)
+	if pot.Uses <= 0 {
+		g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sはもう使えない。", pot.GetName()), Execute: func(g *Game) {}})
+		return
+	}
+
+	// 壺に入れるアイテムを選ぶ
+	g.selectSourceItem = pot
+	if isInventoryItem {
+		g.tmpselectedItemIndex = g.selectedItemIndex
+	} else {
+		g.tmpselectedItemIndex = g.selectedGroundItemIndex
+	}
+	g.itemSelectPurpose = SelectEnhance
+	g.openItemSelect()
+}
+
+// openItemSelect opens the "どれを？" window with the inventory on top of it. The menus
+// used to read the scroll or the pot are closed.
+func (g *Game) openItemSelect() {
+	g.inventoryFilter = kindAll
+	g.overlays = OverlayStack{OverlayItemSelect, OverlayInventory}
+}
+
+// executeItemSelect runs the action for the item chosen in the "どれを？" window.
+func (g *Game) executeItemSelect() {
+	switch g.itemSelectPurpose {
+	case SelectEnhance:
+		g.executeItemEnhance()
+	default:
+		g.executeItemIdentify()
+	}
+	g.itemSelectPurpose = SelectIdentify
+}
+
+func (g *Game) executeItemEnhance() {
+	g.overlays.Close(OverlayItemSelect)
+	target := g.state.Player.Inventory[g.selectedItemIndex]
+
+	pot, _ := g.selectSourceItem.(*Pot)
+	if pot != nil {
+		targetName := getItemNameWithSharpness(target)
+		g.Enqueue(Action{
+			Duration:     0.5,
+			Message:      fmt.Sprintf("%sを%sに入れた。", targetName, pot.GetName()),
+			ItemName:     targetName,
+			Execute:      func(g *Game) {},
+			IsIdentified: true,
+		})
+		if _, ok := target.(Upgradable); ok {
+			pot.Uses--
+			g.enhanceItem(target)
+		} else {
+			g.Enqueue(Action{Duration: 0.5, Message: "しかし何も起こらなかった。", Execute: func(g *Game) {}})
+		}
+	}
+
+	g.selectSourceItem = nil
+	g.tmpselectedItemIndex = -1
+	g.selectedItemIndex = 0
+	g.isActioned = true
+}
+
+// rustEquipment lowers the sharpness of the equipped weapon or armor.
+// 錆びない防具を装備している場合は防具は錆びない
+func (g *Game) rustEquipment() {
+	var candidates []Item
+	if weapon := g.state.Player.equippedWeapon(); weapon != nil {
+		candidates = append(candidates, weapon)
+	}
+	if armor := g.state.Player.equippedArmor(); armor != nil {
+		candidates = append(candidates, armor)
+	}
+	if len(candidates) == 0 {
+		g.Enqueue(Action{Duration: 0.5, Message: "しかし何も起こらなかった。", Execute: func(g *Game) {}})
+		return
+	}
+
+	target := candidates[localRand.Intn(len(candidates))]
+	itemName := getItemNameWithSharpness(target)
+	rusted := 0
+	if armor, ok := target.(*Armor); !ok || !armor.RustProof {
+		rusted = g.changeSharpness(target, -1) // 強化値が下限のときは錆びない
+	}
+	g.Enqueue(Action{
+		Duration:     0.5,
+		Message:      rustMessage(itemName, rusted),
+		ItemName:     itemName,
+		Execute:      func(g *Game) {},
+		IsIdentified: true,
+	})
+}
+
+// rustAttack is the special attack of enemies that rust the player's equipment.
+var rustAttack SpecialAttackFunc = func(e *Enemy, g *Game) {
+	g.Enqueue(Action{Duration: 0.5, Message: fmt.Sprintf("%sの錆び攻撃。", e.Name), Execute: func(g *Game) {}})
+	g.rustEquipment()
+}